@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// submittedAnswer = satu jawaban dari payload submit (assurance atau questionnaire) sebelum disimpan
+type submittedAnswer struct {
+	QuestionID uint
+	Text       string
+	FileKey    string // nama field multipart / upload_id / object key presigned upload
+}
+
+// checkInspectionDetailRules = checkInspectionQuestionRules ditambah batas jumlah evidence dan geometry SAM.
+// Geometry dinormalisasi di tempat.
+func checkInspectionDetailRules(detail *models.MstrInspectionDetail) error {
+	geometry, err := utils.NormalizeSamGeometry(detail.Geometry)
+	if err != nil {
+		return fmt.Errorf("SAM %q: %v", detail.NameCoordinate, err)
+	}
+	detail.Geometry = geometry
+	if detail.MinEvidence < 0 || detail.MaxEvidence < 0 {
+		return fmt.Errorf("SAM %q: min_evidence and max_evidence must not be negative", detail.NameCoordinate)
+	}
+	if detail.MaxEvidence > 0 && detail.MinEvidence > detail.MaxEvidence {
+		return fmt.Errorf("SAM %q: min_evidence must not exceed max_evidence", detail.NameCoordinate)
+	}
+	return checkInspectionQuestionRules(detail.Questions)
+}
+
+// checkInspectionQuestionRules menolak validation_rules / display_condition / scoring yang tidak valid saat master assurance disimpan.
+// display_condition hanya boleh mereferensikan question lain di SAM yang sama.
+func checkInspectionQuestionRules(questions []models.MstrInspectionQuestion) error {
+	conditional := make([]utils.ConditionalQuestion, 0, len(questions))
+	for _, q := range questions {
+		if !utils.ValidQuestionType(q.Type) {
+			return fmt.Errorf("question %q: %v", q.Text, utils.QuestionTypeError(q.Type))
+		}
+		if utils.QuestionTypeHasOptions(q.Type) && len(q.Options) < 2 {
+			return fmt.Errorf("question %q: multiple choice requires at least 2 options", q.Text)
+		}
+		if err := utils.CheckValidationRules(q.Type, q.ValidationRules); err != nil {
+			return fmt.Errorf("question %q: %v", q.Text, err)
+		}
+		if err := utils.CheckScoring(q.Type, q.Weight, q.IsCritical, inspectionOptionScoring(q.Options)); err != nil {
+			return fmt.Errorf("question %q: %v", q.Text, err)
+		}
+		conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+	}
+	return utils.CheckDisplayConditions(conditional)
+}
+
+// remapInspectionConditions menulis ulang display_condition question yang baru dibuat
+// dari ID lama (hasil copy / ID sementara dari client) ke ID baru.
+func remapInspectionConditions(tx *gorm.DB, questions []models.MstrInspectionQuestion, ids map[uint]uint) error {
+	for _, q := range questions {
+		if len(q.DisplayCondition) == 0 || q.ID == 0 {
+			continue
+		}
+		remapped, err := utils.RemapDisplayCondition(q.DisplayCondition, ids)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.MstrInspectionQuestion{}).Where("id = ?", q.ID).
+			Update("display_condition", datatypes.JSON(remapped)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// answerFileType = MIME dari isi file. Content-Type client hanya dipakai jika satu keluarga
+// dengan hasil sniff (m4a sering ter-sniff sebagai video/mp4).
+func answerFileType(declared, sniffed string) string {
+	declared = strings.TrimSpace(strings.Split(declared, ";")[0])
+	if declared != "" && sniffed != "" && mimeFamily(declared) == "media" && mimeFamily(sniffed) == "media" {
+		return declared
+	}
+	return sniffed
+}
+
+// sniffMultipartFile membaca 512 byte pertama file multipart
+func sniffMultipartFile(fh *multipart.FileHeader) string {
+	f, err := fh.Open()
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return http.DetectContentType(head[:n])
+}
+
+// sniffStoredObject = MIME object yang sudah ada di storage: dari metadata evidence, atau 512 byte pertama object
+func sniffStoredObject(ctx context.Context, companyID, objectKey string) string {
+	var meta models.TrxEvidenceMetadata
+	if err := config.DB.Select("mime_type").Where("company_id = ? AND object_key = ?", companyID, objectKey).
+		First(&meta).Error; err == nil && meta.MimeType != "" {
+		return meta.MimeType
+	}
+	backend, _, err := companyStorage(companyID)
+	if err != nil {
+		return ""
+	}
+	body, err := backend.GetRange(ctx, objectKey, 0, 512)
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	head, err := io.ReadAll(body)
+	if err != nil || len(head) == 0 {
+		return ""
+	}
+	return http.DetectContentType(head)
+}
+
+// submittedFile mencari ukuran & content type file jawaban tanpa meng-upload-nya:
+// evidence yang sudah diterima untuk submission ini, file di multipart request, atau presigned upload.
+// Content type diambil dari isi file, bukan dari header client.
+func submittedFile(c *gin.Context, companyID, submissionID, fileKey string) (utils.AnswerInput, bool) {
+	if fileKey == "" {
+		return utils.AnswerInput{}, false
+	}
+	ctx := c.Request.Context()
+	if submissionID != "" {
+		if evidence, err := findSubmissionEvidence(companyID, submissionID, fileKey); err == nil {
+			sniffed := sniffStoredObject(ctx, companyID, evidence.ObjectKey)
+			return utils.AnswerInput{HasFile: true, FileSize: evidence.Size, FileType: answerFileType(evidence.ContentType, sniffed)}, true
+		}
+	}
+	if fh, err := c.FormFile(fileKey); err == nil {
+		sniffed := sniffMultipartFile(fh)
+		return utils.AnswerInput{HasFile: true, FileSize: fh.Size, FileType: answerFileType(fh.Header.Get("Content-Type"), sniffed)}, true
+	}
+	if upload, err := findUploadSession(c, companyID, submissionID, fileKey); err == nil {
+		sniffed := sniffStoredObject(ctx, companyID, upload.ObjectKey)
+		return utils.AnswerInput{HasFile: true, FileSize: upload.ExpectedSize, FileType: answerFileType(upload.ContentType, sniffed)}, true
+	}
+	return utils.AnswerInput{}, false
+}
+
+// answerErrors memvalidasi satu jawaban terhadap tipe, label option dan validation_rules question
+func answerErrors(c *gin.Context, companyID, submissionID, questionType string, labels []string, rawRules []byte, a submittedAnswer) []string {
+	rules, err := utils.ParseValidationRules(rawRules)
+	if err != nil {
+		rules = nil // rules rusak tidak boleh memblokir submit, tipe question tetap dicek
+	}
+	in := utils.AnswerInput{Text: a.Text}
+	if utils.IsFileQuestionType(questionType) {
+		in, _ = submittedFile(c, companyID, submissionID, a.FileKey)
+	}
+	return utils.ValidateAnswer(questionType, labels, rules, in)
+}
+
+// answerValue = nilai jawaban yang dipakai display_condition (teks, atau key file untuk image)
+func (a submittedAnswer) answerValue() string {
+	if text := strings.TrimSpace(a.Text); text != "" {
+		return text
+	}
+	return a.FileKey
+}
+
+// hiddenQuestions = question yang tersembunyi oleh display_condition untuk jawaban yang dikirim
+func hiddenQuestions(questions []utils.ConditionalQuestion, answers []submittedAnswer) map[uint]bool {
+	values := make(map[uint]string, len(answers))
+	for _, a := range answers {
+		values[a.QuestionID] = a.answerValue()
+	}
+	hidden := make(map[uint]bool)
+	for id, visible := range utils.VisibleQuestions(questions, values) {
+		if !visible {
+			hidden[id] = true
+		}
+	}
+	return hidden
+}
+
+const errHiddenQuestion = "question is hidden by its display condition and must not be answered"
+
+func isRequiredQuestion(rawRules []byte) bool {
+	rules, err := utils.ParseValidationRules(rawRules)
+	return err == nil && rules.Required
+}
+
+// validateInspectionAnswers memvalidasi jawaban satu SAM terhadap question SAM tersebut di snapshot versi assurance.
+// Jawaban untuk question milik SAM lain ditolak.
+func validateInspectionAnswers(c *gin.Context, companyID, submissionID string, sam models.MstrInspectionDetail,
+	answers []submittedAnswer) []utils.AnswerError {
+
+	questions := make(map[uint]models.MstrInspectionQuestion, len(sam.Questions))
+	for _, q := range sam.Questions {
+		questions[q.ID] = q
+	}
+	conditional := make([]utils.ConditionalQuestion, 0, len(sam.Questions))
+	for _, q := range sam.Questions {
+		conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+	}
+	hidden := hiddenQuestions(conditional, answers)
+
+	var result []utils.AnswerError
+	answered := make(map[uint]bool, len(answers))
+	for _, a := range answers {
+		answered[a.QuestionID] = true
+		q, ok := questions[a.QuestionID]
+		if !ok {
+			result = append(result, utils.AnswerError{QuestionID: a.QuestionID, SamID: sam.Id, Errors: []string{"question not found in this SAM"}})
+			continue
+		}
+		if hidden[q.ID] {
+			// jawaban kosong untuk question tersembunyi boleh dikirim tablet
+			if a.answerValue() != "" {
+				result = append(result, utils.AnswerError{QuestionID: q.ID, SamID: sam.Id, Errors: []string{errHiddenQuestion}})
+			}
+			continue
+		}
+		labels := make([]string, 0, len(q.Options))
+		for _, o := range q.Options {
+			labels = append(labels, o.Label)
+		}
+		if errs := answerErrors(c, companyID, submissionID, q.Type, labels, q.ValidationRules, a); len(errs) > 0 {
+			result = append(result, utils.AnswerError{QuestionID: q.ID, SamID: sam.Id, Errors: errs})
+		}
+	}
+
+	// question wajib (dan tampil) di SAM ini yang tidak dijawab sama sekali
+	for _, q := range sam.Questions {
+		if !answered[q.ID] && !hidden[q.ID] && isRequiredQuestion(q.ValidationRules) {
+			result = append(result, utils.AnswerError{QuestionID: q.ID, SamID: sam.Id, Errors: []string{"answer is required"}})
+		}
+	}
+	return result
+}
+
+// validateQuestionnaireAnswers memvalidasi jawaban questionnaire; questions harus sudah preload Options.
+// Jika checkRequired, question wajib yang tidak ada di payload ikut dilaporkan.
+func validateQuestionnaireAnswers(c *gin.Context, companyID, submissionID string, questions []models.Question,
+	answers []submittedAnswer, checkRequired bool) []utils.AnswerError {
+
+	byID := make(map[uint]models.Question, len(questions))
+	conditional := make([]utils.ConditionalQuestion, 0, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+		conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+	}
+	hidden := hiddenQuestions(conditional, answers)
+
+	var result []utils.AnswerError
+	answered := make(map[uint]bool, len(answers))
+	for _, a := range answers {
+		answered[a.QuestionID] = true
+		q, ok := byID[a.QuestionID]
+		if !ok {
+			result = append(result, utils.AnswerError{QuestionID: a.QuestionID, Errors: []string{"question not found"}})
+			continue
+		}
+		if hidden[q.ID] {
+			if a.answerValue() != "" {
+				result = append(result, utils.AnswerError{QuestionID: q.ID, Errors: []string{errHiddenQuestion}})
+			}
+			continue
+		}
+		labels := make([]string, 0, len(q.Options))
+		for _, o := range q.Options {
+			labels = append(labels, o.Label)
+		}
+		if errs := answerErrors(c, companyID, submissionID, q.Type, labels, q.ValidationRules, a); len(errs) > 0 {
+			result = append(result, utils.AnswerError{QuestionID: q.ID, Errors: errs})
+		}
+	}
+
+	if checkRequired {
+		for _, q := range questions {
+			if !answered[q.ID] && !hidden[q.ID] && isRequiredQuestion(q.ValidationRules) {
+				result = append(result, utils.AnswerError{QuestionID: q.ID, Errors: []string{"answer is required"}})
+			}
+		}
+	}
+	return result
+}
+
+// checkQuestionnaireConditions cek display_condition question terhadap question lain di questionnaire yang sama
+func checkQuestionnaireConditions(db *gorm.DB, q models.Question) error {
+	var siblings []models.Question
+	if err := db.Select("id", "display_condition").
+		Where("questionnaire_id = ? AND id <> ?", q.QuestionnaireID, q.ID).
+		Find(&siblings).Error; err != nil {
+		return err
+	}
+	conditional := []utils.ConditionalQuestion{{ID: q.ID, Condition: q.DisplayCondition}}
+	for _, s := range siblings {
+		conditional = append(conditional, utils.ConditionalQuestion{ID: s.ID, Condition: s.DisplayCondition})
+	}
+	return utils.CheckDisplayConditions(conditional)
+}
+
+// questionHiddenForUser mengevaluasi display_condition terhadap jawaban terakhir user
+// untuk question lain di questionnaire yang sama (endpoint jawaban satu per satu)
+func questionHiddenForUser(question models.Question, userID uint) bool {
+	if cond, err := utils.ParseDisplayCondition(question.DisplayCondition); err != nil || cond == nil {
+		return false
+	}
+	var questions []models.Question
+	config.DB.Select("id", "display_condition").Where("questionnaire_id = ?", question.QuestionnaireID).Find(&questions)
+	conditional := make([]utils.ConditionalQuestion, 0, len(questions))
+	questionIDs := make([]uint, 0, len(questions))
+	for _, q := range questions {
+		conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+		questionIDs = append(questionIDs, q.ID)
+	}
+
+	// urut id ASC → jawaban terakhir per question yang dipakai
+	var previous []models.Answer
+	config.DB.Where("user_id = ? AND question_id IN ? AND question_id <> ?", userID, questionIDs, question.ID).
+		Order("id ASC").Find(&previous)
+	answers := make([]submittedAnswer, 0, len(previous))
+	for _, a := range previous {
+		answers = append(answers, submittedAnswer{QuestionID: a.QuestionID, Text: a.AnswerText, FileKey: a.AnswerFile})
+	}
+	return hiddenQuestions(conditional, answers)[question.ID]
+}
+
+// validateSingleAnswer untuk endpoint jawaban satu question (POST /questions/:id/answers); false = response sudah dikirim
+func validateSingleAnswer(c *gin.Context, question models.Question, userID uint, in utils.AnswerInput) bool {
+	if questionHiddenForUser(question, userID) {
+		if !in.HasFile && strings.TrimSpace(in.Text) == "" {
+			return true
+		}
+		utils.JSONAnswerErrors(c, []utils.AnswerError{{QuestionID: question.ID, Errors: []string{errHiddenQuestion}}})
+		return false
+	}
+	rules, err := utils.ParseValidationRules(question.ValidationRules)
+	if err != nil {
+		rules = nil
+	}
+	labels := make([]string, 0, len(question.Options))
+	for _, o := range question.Options {
+		labels = append(labels, o.Label)
+	}
+	if errs := utils.ValidateAnswer(question.Type, labels, rules, in); len(errs) > 0 {
+		utils.JSONAnswerErrors(c, []utils.AnswerError{{QuestionID: question.ID, Errors: errs}})
+		return false
+	}
+	return true
+}
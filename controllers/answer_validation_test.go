@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+)
+
+// multipartContext = gin context dengan satu file multipart dan Content-Type yang diklaim client
+func multipartContext(t *testing.T, field, declared string, content []byte) *gin.Context {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="`+field+`"; filename="evidence.jpg"`)
+	h.Set("Content-Type", declared)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	w.Close()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", &body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	return c
+}
+
+func TestValidateInspectionAnswersOnlySamQuestions(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	samA := models.MstrInspectionDetail{Id: 1, Questions: []models.MstrInspectionQuestion{{ID: 10, Text: "Kondisi", Type: "text"}}}
+	// question 20 milik SAM lain di snapshot yang sama
+	errs := validateInspectionAnswers(c, "COMP-A", "", samA, []submittedAnswer{
+		{QuestionID: 10, Text: "baik"},
+		{QuestionID: 20, Text: "lain"},
+	})
+	if len(errs) != 1 || errs[0].QuestionID != 20 || errs[0].SamID != 1 {
+		t.Fatalf("errors = %+v, want only question 20 rejected for SAM 1", errs)
+	}
+}
+
+func TestSubmittedFileSniffsContent(t *testing.T) {
+	newTestDB(t)
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+	tests := []struct {
+		name     string
+		declared string
+		content  []byte
+		want     string
+	}{
+		{"text claimed as jpeg", "image/jpeg", []byte("<html><script>alert(1)</script></html>"), "text/html; charset=utf-8"},
+		{"jpeg claimed as pdf", "application/pdf", jpeg, "image/jpeg"},
+		{"real jpeg", "image/jpeg", jpeg, "image/jpeg"},
+		{"m4a sniffed as video keeps audio type", "audio/mp4", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), "audio/mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := multipartContext(t, "photo", tt.declared, tt.content)
+			in, ok := submittedFile(c, "COMP-A", "", "photo")
+			if !ok || !in.HasFile {
+				t.Fatal("file not found")
+			}
+			if in.FileType != tt.want {
+				t.Errorf("FileType = %q, want %q", in.FileType, tt.want)
+			}
+		})
+	}
+
+	// file yang diklaim image tapi isinya bukan gambar ditolak oleh allowed_mime_types
+	c := multipartContext(t, "photo", "image/jpeg", []byte("not an image"))
+	rules := datatypes.JSON(`{"allowed_mime_types":["image/*"]}`)
+	if errs := answerErrors(c, "COMP-A", "", utils.QuestionImage, nil, rules, submittedAnswer{QuestionID: 1, FileKey: "photo"}); len(errs) == 0 {
+		t.Error("text file declared as image/jpeg passed allowed_mime_types image/*")
+	}
+}
+
+// Presigned upload hanya dipakai oleh device yang meminta presign, untuk submission-nya sendiri, sekali saja
+func TestSubmittedFilePresignedUpload(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", IsActive: true, StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+	subA, subB := "11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"
+	now := time.Now()
+	for _, u := range []models.TrxUploadSession{
+		{UploadID: "own", DeviceID: "TAB-1"},
+		{UploadID: "other-device", DeviceID: "TAB-2"},
+		{UploadID: "sub-a", DeviceID: "TAB-1", SubmissionUUID: subA},
+		{UploadID: "consumed", DeviceID: "TAB-1", ConsumedAt: &now},
+	} {
+		u.CompanyID, u.ObjectKey, u.ContentType, u.ExpectedSize = "COMP-A", "obj/"+u.UploadID, "image/jpeg", 10
+		u.Status, u.VerifiedAt, u.ExpiresAt = models.UploadStatusVerified, &now, now.Add(time.Hour)
+		mustCreate(t, db, &u)
+	}
+
+	tests := []struct {
+		ref          string
+		submissionID string
+		want         bool
+	}{
+		{"own", "", true},
+		{"obj/own", subB, true},
+		{"other-device", "", false},
+		{"sub-a", subA, true},
+		{"sub-a", subB, false},
+		{"sub-a", "", false},
+		{"consumed", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref+"/"+tt.submissionID, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			c.Set("company_id", "COMP-A")
+			c.Set("auth_device_id", "TAB-1")
+			if _, ok := submittedFile(c, "COMP-A", tt.submissionID, tt.ref); ok != tt.want {
+				t.Fatalf("found = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	// dipakai satu submission → submission berikutnya tidak bisa memakai upload yang sama
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Set("company_id", "COMP-A")
+	c.Set("auth_device_id", "TAB-1")
+	if _, err := storeSubmissionFile(c, "", "own", moduleTrnAssurance); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := storeSubmissionFile(c, "", "own", moduleTrnAssurance); err == nil {
+		t.Fatal("upload was accepted by a second submission")
+	}
+	if _, ok := submittedFile(c, "COMP-A", "", "own"); ok {
+		t.Error("consumed upload still passes validation")
+	}
+}
+
+// Payload details yang salah ditolak sebelum gambar assurance di-upload
+func TestCreateMstrInspectionValidatesBeforeUpload(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+
+	details, _ := json.Marshal([]gin.H{{
+		"name_coordinate": "SAM 1",
+		"questions":       []gin.H{{"text": "Kondisi", "type": "unknown"}},
+	}})
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("name_inspection", "Gudang")
+	w.WriteField("details", string(details))
+	part, _ := w.CreateFormFile("image", "denah.png")
+	part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	w.Close()
+
+	r := adminRouter("COMP-A")
+	r.POST("/mstr-inspections", CreateMstrInspection)
+	req := httptest.NewRequest(http.MethodPost, "/mstr-inspections", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if objects, _ := storage.NewMemory(t.Name()).List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("image uploaded for rejected payload: %+v", objects)
+	}
+	var count int64
+	db.Model(&models.MstrInspection{}).Count(&count)
+	if count != 0 || !strings.Contains(rec.Body.String(), "Kondisi") {
+		t.Errorf("inspections = %d, body = %s", count, rec.Body.String())
+	}
+}
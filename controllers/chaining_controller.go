@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"go-api/config"
 	"go-api/models"
 	"go-api/utils"
@@ -28,6 +29,11 @@ func CreateChaining(c *gin.Context) {
 	chaining.CreatedBy = username.(string)
 	chaining.UpdatedBy = username.(string)
 
+	if err := checkChainingItems(c, chaining.Details); err != nil {
+		utils.JSONError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
 	if err := config.DB.Create(&chaining).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -41,7 +47,7 @@ func UpdateChainingByID(c *gin.Context) {
 	var chaining models.MstrChaining
 
 	// 1. Ambil data master
-	if err := config.DB.First(&chaining, chainingID).Error; err != nil {
+	if err := utils.TenantDB(c).First(&chaining, chainingID).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Chaining not found")
 		return
 	}
@@ -52,6 +58,11 @@ func UpdateChainingByID(c *gin.Context) {
 		return
 	}
 
+	if err := checkChainingItems(c, input.Details); err != nil {
+		utils.JSONError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
 	// 2. Gunakan GORM Transaction untuk operasi atomik
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
 		// Hapus detail yang ada di DB tapi tidak di input
@@ -96,6 +107,19 @@ func UpdateChainingByID(c *gin.Context) {
 
 		// Loop dan simpan/buat detail baru atau yang diperbarui
 		for _, detailInput := range input.Details {
+			// ID detail harus milik chaining ini, jangan sampai menimpa detail chaining lain
+			if detailInput.Id != 0 {
+				owned := false
+				for _, oldDetail := range oldDetails {
+					if oldDetail.Id == detailInput.Id {
+						owned = true
+						break
+					}
+				}
+				if !owned {
+					return fmt.Errorf("chaining detail %d not found", detailInput.Id)
+				}
+			}
 			detailInput.IdChaining = chaining.Id
 			detailInput.CreatedBy = chaining.UpdatedBy
 			detailInput.UpdatedBy = chaining.UpdatedBy
@@ -123,6 +147,11 @@ func DeleteChainingByID(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrChaining{}, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Chaining not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrChaining{}).
 		Where("id = ?", id).
@@ -147,16 +176,11 @@ func GetFilteredChainings(c *gin.Context) {
 	var chainings []models.MstrChaining
 	query := config.DB.Model(&models.MstrChaining{}).
 		Preload("Events").
-		Preload("Details")
+		Preload("Details").
+		Scopes(utils.CompanyScope(c))
 
 	//config.DB.Debug().Preload("Events").Preload("Details").First(&chainings, id)
 
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
-
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
 	}
@@ -180,7 +204,7 @@ func GetChainingByID(c *gin.Context) {
 	var chaining models.MstrChaining
 
 	// Ambil chaining + details (urut berdasarkan sequence)
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Preload("Events").
 		Preload("Details", func(db *gorm.DB) *gorm.DB {
 			return db.Order("sequence ASC")
@@ -193,13 +217,13 @@ func GetChainingByID(c *gin.Context) {
 	// Loop isi details, inject data questionnaire / inspection
 	for i, d := range chaining.Details {
 		if d.ItemType == "inspection" {
-			var ins models.MstrInspection
-			if err := config.DB.Preload("Details.Questions.Options").First(&ins, d.ItemID).Error; err == nil {
-				chaining.Details[i].Inspection = &ins
+			// Tablet hanya menerima isi versi published, bukan draft
+			if ins, err := publishedInspectionView(utils.TenantDB(c), d.ItemID); err == nil {
+				chaining.Details[i].Inspection = ins
 			}
 		} else if d.ItemType == "questionnaire" {
 			var q models.Questionnaire
-			if err := config.DB.Preload("Questions.Options").First(&q, d.ItemID).Error; err == nil {
+			if err := utils.TenantDB(c).Preload("Questions.Options").First(&q, d.ItemID).Error; err == nil {
 				chaining.Details[i].Questionnaire = &q
 			}
 		}
@@ -268,7 +292,8 @@ func GetChainingByDeviceNew(c *gin.Context) {
 	}
 
 	var chainings []ChainingWithTrigger
-	if err := config.DB.Raw(query, deviceID).Scan(&chainings).Error; err != nil {
+	query, args := scopeDeviceQuery(c, query, deviceID)
+	if err := config.DB.Raw(query, args...).Scan(&chainings).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -410,6 +435,23 @@ func GetChainingByDeviceNew(c *gin.Context) {
 	utils.JSONSuccess(c, "Active chaining fetched successfully", activeChains)
 }
 
+// checkChainingItems memastikan inspection/questionnaire di detail chaining milik company user
+func checkChainingItems(c *gin.Context, details []models.MstrChainingDetail) error {
+	for _, d := range details {
+		var err error
+		switch d.ItemType {
+		case "inspection":
+			err = utils.TenantDB(c).Select("id").First(&models.MstrInspection{}, d.ItemID).Error
+		case "questionnaire":
+			err = utils.TenantDB(c).Select("id").First(&models.Questionnaire{}, d.ItemID).Error
+		}
+		if err != nil {
+			return fmt.Errorf("%s %d not found", d.ItemType, d.ItemID)
+		}
+	}
+	return nil
+}
+
 func getCurrentTriggerWindowDevice(triggerLocal time.Time, freqValue int, freqUnit string, nowLocal time.Time) time.Time {
 	if freqValue <= 0 || freqUnit == "" {
 		return triggerLocal
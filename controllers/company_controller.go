@@ -1,12 +1,18 @@
 package controllers
 
 import (
+	"fmt"
 	"go-api/config"
 	"go-api/models"
+	"go-api/secrets"
+	"go-api/storage"
 	"go-api/utils"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func CreateCompany(c *gin.Context) {
@@ -18,8 +24,14 @@ func CreateCompany(c *gin.Context) {
 	company.E2Endpoint = c.PostForm("e2_endpoint")
 	company.E2Region = c.PostForm("e2_region")
 	company.E2BucketName = c.PostForm("e2_bucket_name")
-	company.E2AccessKey = c.PostForm("e2_access_key")
-	company.E2SecretKey = c.PostForm("e2_secret_key")
+	company.E2AccessKey = secrets.String(c.PostForm("e2_access_key"))
+	company.E2SecretKey = secrets.String(c.PostForm("e2_secret_key"))
+	company.StorageBackend = c.DefaultPostForm("storage_backend", storage.DriverS3)
+
+	if !storage.IsValidDriver(company.StorageBackend) {
+		utils.JSONError(c, http.StatusBadRequest, "storage_backend must be s3 or local")
+		return
+	}
 
 	// === Upload logo ke E2 ===
 	fileHeader, err := c.FormFile("image_url")
@@ -31,17 +43,14 @@ func CreateCompany(c *gin.Context) {
 		}
 		defer file.Close()
 
-		override := &E2Config{
-			Endpoint:   company.E2Endpoint,
-			Region:     company.E2Region,
-			BucketName: company.E2BucketName,
-			AccessKey:  company.E2AccessKey,
-			SecretKey:  company.E2SecretKey,
-		}
+		override := companyE2Config(&company)
 
 		fileKey := GenerateE2ObjectKey(c, "Master-Company", fileHeader.Filename)
-		objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+company.CompanyID, override)
+		objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+company.CompanyID, &override)
 		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
 			utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
 			return
 		}
@@ -76,7 +85,7 @@ func UpdateCompany(c *gin.Context) {
 	companyID := c.Param("id")
 
 	var company models.MstrCompany
-	if err := config.DB.WithContext(c.Request.Context()).First(&company, companyID).Error; err != nil {
+	if err := utils.TenantDB(c).First(&company, companyID).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Company not found")
 		return
 	}
@@ -90,6 +99,8 @@ func UpdateCompany(c *gin.Context) {
 	E2BucketName := c.PostForm("e2_bucket_name")
 	E2AccessKey := c.PostForm("e2_access_key")
 	E2SecretKey := c.PostForm("e2_secret_key")
+	allowLegacyAPIKey := c.PostForm("allow_legacy_api_key")
+	storageBackend := c.PostForm("storage_backend")
 
 	if companyName != "" {
 		company.CompanyName = companyName
@@ -116,12 +127,27 @@ func UpdateCompany(c *gin.Context) {
 		company.E2BucketName = E2BucketName
 	}
 
-	if E2AccessKey != "" {
-		company.E2AccessKey = E2AccessKey
+	// nilai mask dari response yang dikirim balik form edit → abaikan
+	if E2AccessKey != "" && !secrets.IsMasked(E2AccessKey) {
+		company.E2AccessKey = secrets.String(E2AccessKey)
+	}
+
+	if E2SecretKey != "" && !secrets.IsMasked(E2SecretKey) {
+		company.E2SecretKey = secrets.String(E2SecretKey)
 	}
 
-	if E2SecretKey != "" {
-		company.E2SecretKey = E2SecretKey
+	// false = tablet wajib pakai credential per device (X-Device-Key)
+	if allowLegacyAPIKey != "" {
+		company.AllowLegacyAPIKey = (allowLegacyAPIKey == "true" || allowLegacyAPIKey == "1")
+	}
+
+	// s3 = bucket S3-compatible (IDrive E2), local = filesystem server (on-prem)
+	if storageBackend != "" {
+		if !storage.IsValidDriver(storageBackend) {
+			utils.JSONError(c, http.StatusBadRequest, "storage_backend must be s3 or local")
+			return
+		}
+		company.StorageBackend = storageBackend
 	}
 
 	// === Cek apakah ada file upload baru untuk logo ===
@@ -134,17 +160,14 @@ func UpdateCompany(c *gin.Context) {
 		}
 		defer file.Close()
 
-		override := &E2Config{
-			Endpoint:   company.E2Endpoint,
-			Region:     company.E2Region,
-			BucketName: company.E2BucketName,
-			AccessKey:  company.E2AccessKey,
-			SecretKey:  company.E2SecretKey,
-		}
+		override := companyE2Config(&company)
 
 		fileKey := GenerateE2ObjectKey(c, "Master-Company", fileHeader.Filename)
-		objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+companyCode, override)
+		objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+companyCode, &override)
 		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
 			utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
 			return
 		}
@@ -179,6 +202,11 @@ func DeleteCompany(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrCompany{}, CompanyID).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Company not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrCompany{}).
 		Where("id = ?", CompanyID).
@@ -212,13 +240,7 @@ func GetFilteredCompanies(c *gin.Context) {
 	//companyID := c.Query("company_id")
 
 	var companies []models.MstrCompany
-	query := config.DB.Model(&models.MstrCompany{})
-
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query := config.DB.Model(&models.MstrCompany{}).Scopes(utils.CompanyScope(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
@@ -237,3 +259,105 @@ func GetFilteredCompanies(c *gin.Context) {
 
 	utils.JSONSuccess(c, "Filtered companies", companies)
 }
+
+// POST /mstr-company/:id/storage/test → cek credential storage company (put/head/delete object kecil), credential tidak dikembalikan
+func TestCompanyStorage(c *gin.Context) {
+	var company models.MstrCompany
+	if err := utils.TenantDB(c).First(&company, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Company not found")
+		return
+	}
+
+	backend, err := newCompanyStorage(&company)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid storage config: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	started := time.Now()
+	key := "Assurance/" + company.CompanyID + "/.connection-test/" + uuid.New().String()
+
+	step := "put"
+	err = backend.Put(ctx, key, strings.NewReader("ok"), 2, "text/plain")
+	if err == nil {
+		step = "head"
+		_, err = backend.Head(ctx, key)
+	}
+	if err == nil {
+		step = "delete"
+		err = backend.Delete(ctx, key)
+	}
+	if err != nil {
+		utils.JSONError(c, http.StatusBadGateway, fmt.Sprintf("Storage connection failed at %s: %v", step, err))
+		return
+	}
+
+	utils.JSONSuccess(c, "Storage connection OK", gin.H{
+		"storage_backend": company.StorageBackend,
+		"bucket":          company.E2BucketName,
+		"access_key":      company.E2AccessKey,
+		"latency_ms":      time.Since(started).Milliseconds(),
+	})
+}
+
+// POST /mstr-company/rotate-secrets → enkripsi ulang credential semua company dengan master key aktif
+func RotateCompanySecretsHandler(c *gin.Context) {
+	rotated, err := RotateCompanySecrets()
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	keyID, _ := secrets.ActiveKeyID()
+	utils.JSONSuccess(c, "Company secrets rotated", gin.H{"rotated": rotated, "key_id": keyID})
+}
+
+// RotateCompanySecrets mengenkripsi credential plaintext lama dan yang masih memakai master key lama.
+// Dipanggil saat startup dan setelah SECRETS_MASTER_KEY diganti (key lama di SECRETS_OLD_MASTER_KEYS).
+func RotateCompanySecrets() (int, error) {
+	activeID, err := secrets.ActiveKeyID()
+	if err != nil {
+		return 0, err
+	}
+
+	// baca nilai mentah (tanpa Scan/decrypt otomatis) supaya key id bisa dicek
+	var rows []struct {
+		Id          uint
+		E2AccessKey string
+		E2SecretKey string
+	}
+	if err := config.DB.Model(&models.MstrCompany{}).Unscoped().
+		Select("id, e2_access_key, e2_secret_key").
+		Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		updates := map[string]interface{}{}
+		for column, stored := range map[string]string{"e2_access_key": row.E2AccessKey, "e2_secret_key": row.E2SecretKey} {
+			if stored == "" || secrets.KeyID(stored) == activeID {
+				continue
+			}
+			plaintext, err := secrets.Decrypt(stored)
+			if err != nil {
+				return rotated, fmt.Errorf("company %d %s: %v", row.Id, column, err)
+			}
+			encrypted, err := secrets.Encrypt(plaintext)
+			if err != nil {
+				return rotated, err
+			}
+			updates[column] = encrypted
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := config.DB.Model(&models.MstrCompany{}).Unscoped().
+			Where("id = ?", row.Id).
+			UpdateColumns(updates).Error; err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Isi bundle yang ikut dihitung ETag-nya. Signed URL & window chaining tidak ikut
+// karena berubah tiap request walaupun kontennya sama.
+type bundleContent struct {
+	IDs            bundleIDs                 `json:"ids"`
+	Inspections    []bundleInspection        `json:"inspections"`
+	Questionnaires []models.Questionnaire    `json:"questionnaires"`
+	Chainings      []models.MstrChaining     `json:"chainings"`
+	EventTriggers  []models.MstrEventTrigger `json:"event_triggers"`
+	TypeTriggers   []models.MstrTypeTrigger  `json:"type_triggers"`
+}
+
+// bundleIDs = semua ID yang di-assign ke device, dipakai tablet untuk menghapus data lokal yang sudah tidak di-assign (mode since)
+type bundleIDs struct {
+	Inspections    []uint `json:"inspections"`
+	Questionnaires []uint `json:"questionnaires"`
+	Chainings      []uint `json:"chainings"`
+	EventTriggers  []uint `json:"event_triggers"`
+	TypeTriggers   []uint `json:"type_triggers"`
+}
+
+// bundleInspection = assurance versi published (dari snapshot, bukan draft)
+type bundleInspection struct {
+	Id             uint           `json:"id"`
+	Version        uint           `json:"version"`
+	VersionID      uint           `json:"version_id"`
+	NameInspection string         `json:"name_inspection"`
+	ImageUrl       string         `json:"image_url"`
+	PublishedAt    time.Time      `json:"published_at"`
+	Snapshot       datatypes.JSON `json:"snapshot"`
+}
+
+// deviceAssignedIDs mengambil ID item (lewat group device) dari tabel pivot group
+func deviceAssignedIDs(deviceID uint, pivot, itemColumn string) ([]uint, error) {
+	var ids []uint
+	err := config.DB.Table(pivot+" AS p").
+		Joins("JOIN mstr_group_device mgd ON mgd.mstr_group_id = p.mstr_group_id").
+		Joins("JOIN mstr_group mg ON mg.id = p.mstr_group_id AND mg.deleted_at IS NULL").
+		Where("mgd.mstr_device_id = ?", deviceID).
+		Distinct().
+		Pluck("p."+itemColumn, &ids).Error
+	return ids, err
+}
+
+// changedParentIDs = parent yang child-nya berubah / dihapus setelah since
+func changedParentIDs(db *gorm.DB, table, parentColumn string, parentIDs []uint, since time.Time) map[uint]bool {
+	result := make(map[uint]bool)
+	if len(parentIDs) == 0 {
+		return result
+	}
+	var ids []uint
+	db.Table(table).
+		Where(parentColumn+" IN ?", parentIDs).
+		Where("(updated_at > ? OR deleted_at > ?)", since, since).
+		Distinct().
+		Pluck(parentColumn, &ids)
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result
+}
+
+func loadDeviceBundle(device models.MstrDevice) (*bundleContent, error) {
+	var content bundleContent
+	var err error
+
+	// === Inspections (hanya yang sudah published) ===
+	inspectionIDs, err := deviceAssignedIDs(device.Id, "mstr_group_inspection", "mstr_inspection_id")
+	if err != nil {
+		return nil, err
+	}
+	var inspections []models.MstrInspection
+	if err := config.DB.
+		Where("id IN ? AND company_id = ? AND published_version_id IS NOT NULL", inspectionIDs, device.CompanyID).
+		Order("id ASC").
+		Find(&inspections).Error; err != nil {
+		return nil, err
+	}
+	versionIDs := make([]uint, 0, len(inspections))
+	for _, i := range inspections {
+		versionIDs = append(versionIDs, *i.PublishedVersionID)
+	}
+	var versions []models.MstrInspectionVersion
+	if err := config.DB.Where("id IN ?", versionIDs).Order("id_mstr_inspection ASC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	content.Inspections = make([]bundleInspection, 0, len(versions))
+	content.IDs.Inspections = make([]uint, 0, len(versions))
+	for _, v := range versions {
+		content.Inspections = append(content.Inspections, bundleInspection{
+			Id:             v.IdMstrInspection,
+			Version:        v.Version,
+			VersionID:      v.Id,
+			NameInspection: v.NameInspection,
+			ImageUrl:       v.ImageUrl,
+			PublishedAt:    v.PublishedAt,
+			Snapshot:       v.Snapshot,
+		})
+		content.IDs.Inspections = append(content.IDs.Inspections, v.IdMstrInspection)
+	}
+
+	// === Questionnaires ===
+	questionnaireIDs, err := deviceAssignedIDs(device.Id, "mstr_group_questionnaire", "questionnaire_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DB.
+		Preload("Questions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Questions.Options", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("id IN ? AND company_id = ? AND is_active = ?", questionnaireIDs, device.CompanyID, true).
+		Order("id ASC").
+		Find(&content.Questionnaires).Error; err != nil {
+		return nil, err
+	}
+	content.IDs.Questionnaires = make([]uint, 0, len(content.Questionnaires))
+	for _, q := range content.Questionnaires {
+		content.IDs.Questionnaires = append(content.IDs.Questionnaires, q.ID)
+	}
+
+	// === Chainings ===
+	chainingIDs, err := deviceAssignedIDs(device.Id, "mstr_group_chaining", "mstr_chaining_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DB.
+		Preload("Details", func(db *gorm.DB) *gorm.DB { return db.Order("sequence ASC") }).
+		Where("id IN ? AND company_id = ? AND is_active = ?", chainingIDs, device.CompanyID, true).
+		Order("id ASC").
+		Find(&content.Chainings).Error; err != nil {
+		return nil, err
+	}
+	content.IDs.Chainings = make([]uint, 0, len(content.Chainings))
+	for _, ch := range content.Chainings {
+		content.IDs.Chainings = append(content.IDs.Chainings, ch.Id)
+	}
+
+	// === Event & type trigger company ===
+	if err := config.DB.Where("company_id = ? AND is_active = ?", device.CompanyID, true).
+		Order("id ASC").Find(&content.EventTriggers).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Where("company_id = ? AND is_active = ?", device.CompanyID, true).
+		Order("id ASC").Find(&content.TypeTriggers).Error; err != nil {
+		return nil, err
+	}
+	content.IDs.EventTriggers = make([]uint, 0, len(content.EventTriggers))
+	for _, e := range content.EventTriggers {
+		content.IDs.EventTriggers = append(content.IDs.EventTriggers, e.Id)
+	}
+	content.IDs.TypeTriggers = make([]uint, 0, len(content.TypeTriggers))
+	for _, t := range content.TypeTriggers {
+		content.IDs.TypeTriggers = append(content.IDs.TypeTriggers, t.Id)
+	}
+
+	return &content, nil
+}
+
+// filterBundleSince hanya menyisakan item yang berubah setelah since (ids tetap lengkap)
+func filterBundleSince(content *bundleContent, since time.Time) {
+	var inspections []bundleInspection
+	for _, i := range content.Inspections {
+		if i.PublishedAt.After(since) {
+			inspections = append(inspections, i)
+		}
+	}
+	content.Inspections = inspections
+
+	// Session agar kondisi tiap query tidak menumpuk di db yang dipakai ulang
+	db := config.DB.Unscoped().Session(&gorm.Session{})
+
+	changedQuestions := changedParentIDs(db, "questions", "questionnaire_id", content.IDs.Questionnaires, since)
+	var optionQuestionnaires []uint
+	if len(content.IDs.Questionnaires) > 0 {
+		db.Table("options o").
+			Joins("JOIN questions q ON q.id = o.question_id").
+			Where("q.questionnaire_id IN ? AND (o.updated_at > ? OR o.deleted_at > ?)", content.IDs.Questionnaires, since, since).
+			Distinct().
+			Pluck("q.questionnaire_id", &optionQuestionnaires)
+	}
+	for _, id := range optionQuestionnaires {
+		changedQuestions[id] = true
+	}
+	var questionnaires []models.Questionnaire
+	for _, q := range content.Questionnaires {
+		if q.UpdatedAt.After(since) || changedQuestions[q.ID] {
+			questionnaires = append(questionnaires, q)
+		}
+	}
+	content.Questionnaires = questionnaires
+
+	changedDetails := changedParentIDs(db, "mstr_chaining_details", "id_chaining", content.IDs.Chainings, since)
+	var chainings []models.MstrChaining
+	for _, ch := range content.Chainings {
+		if ch.UpdatedAt.After(since) || changedDetails[ch.Id] {
+			chainings = append(chainings, ch)
+		}
+	}
+	content.Chainings = chainings
+
+	var events []models.MstrEventTrigger
+	for _, e := range content.EventTriggers {
+		if e.UpdatedAt.After(since) {
+			events = append(events, e)
+		}
+	}
+	content.EventTriggers = events
+
+	var types []models.MstrTypeTrigger
+	for _, t := range content.TypeTriggers {
+		if t.UpdatedAt.After(since) {
+			types = append(types, t)
+		}
+	}
+	content.TypeTriggers = types
+}
+
+// GET /devices/:deviceID/bundle → semua data yang di-assign ke device untuk kerja offline.
+// Mendukung If-None-Match (ETag) dan ?since=RFC3339 (hanya item yang berubah).
+func GetDeviceBundle(c *gin.Context) {
+	// Device pemilik credential sudah dicek di middleware.APIKeyAuth
+	deviceID := c.Param("deviceID")
+
+	var since *time.Time
+	if s := c.Query("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			utils.JSONError(c, http.StatusBadRequest, "since must be RFC3339 timestamp")
+			return
+		}
+		since = &t
+	}
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).Where("device_id = ? AND is_active = ?", deviceID, true).First(&device).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found or inactive")
+		return
+	}
+
+	generatedAt := time.Now().UTC()
+	content, err := loadDeviceBundle(device)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// since ikut di-hash: response delta beda isi dengan response penuh untuk konten yang sama
+	h := sha256.New()
+	h.Write(raw)
+	if since != nil {
+		h.Write([]byte("since=" + since.UTC().Format(time.RFC3339Nano)))
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// === Window chaining saat ini (zona waktu device) ===
+	tz := c.GetHeader("X-Timezone")
+	if tz == "" {
+		tz = "UTC"
+	}
+	userLoc, err := time.LoadLocation(tz)
+	if err != nil {
+		userLoc = time.UTC
+	}
+	nowLocal := generatedAt.In(userLoc)
+	windows := make(map[uint]gin.H)
+	for _, ch := range content.Chainings {
+		if ch.TriggerDatetime.IsZero() {
+			continue
+		}
+		triggerLocal := ch.TriggerDatetime.UTC().In(userLoc)
+		if ch.FrequencyValue == nil || ch.FrequencyUnit == nil || *ch.FrequencyValue == 0 || *ch.FrequencyUnit == "" {
+			windows[ch.Id] = gin.H{"start": triggerLocal, "end": nil}
+			continue
+		}
+		start := getCurrentTriggerWindowDevice(triggerLocal, int(*ch.FrequencyValue), *ch.FrequencyUnit, nowLocal)
+		windows[ch.Id] = gin.H{
+			"start": start,
+			"end":   start.Add(calcDuration(*ch.FrequencyValue, *ch.FrequencyUnit)),
+		}
+	}
+
+	if since != nil {
+		filterBundleSince(content, *since)
+	}
+
+	// === Signed URL untuk gambar assurance & gambar halaman ===
+	signedURLs := make(map[string]string)
+	sign := func(objectKey string) {
+		if objectKey == "" || signedURLs[objectKey] != "" {
+			return
+		}
+		url, err := GeneratePresignedURLWithCompanyID(c, device.CompanyID, objectKey)
+		if err != nil {
+			log.Printf("[WARN] Failed to sign %s for bundle: %v", objectKey, err)
+			return
+		}
+		signedURLs[objectKey] = url
+	}
+	for _, i := range content.Inspections {
+		sign(i.ImageUrl)
+		// Gambar halaman hanya ada di snapshot versi published
+		var snapshot models.InspectionSnapshot
+		if err := json.Unmarshal(i.Snapshot, &snapshot); err != nil {
+			log.Printf("[WARN] Invalid snapshot for assurance %d v%d: %v", i.Id, i.Version, err)
+			continue
+		}
+		for _, p := range snapshot.Pages {
+			sign(p.ImageUrl)
+		}
+	}
+
+	utils.JSONSuccess(c, "Device bundle fetched successfully", gin.H{
+		"etag":                   etag,
+		"generated_at":           generatedAt,
+		"since":                  since,
+		"device":                 device,
+		"timezone":               userLoc.String(),
+		"ids":                    content.IDs,
+		"inspections":            content.Inspections,
+		"questionnaires":         content.Questionnaires,
+		"chainings":              content.Chainings,
+		"chaining_windows":       windows,
+		"event_triggers":         content.EventTriggers,
+		"type_triggers":          content.TypeTriggers,
+		"signed_urls":            signedURLs,
+		"signed_urls_expires_at": generatedAt.Add(15 * time.Minute),
+	})
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-api/models"
+	"go-api/storage"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Delta bundle: ETag beda per since dan option yang dihapus ikut menandai questionnaire berubah
+func TestDeviceBundleSince(t *testing.T) {
+	db := newTestDB(t)
+
+	past := time.Now().Add(-time.Hour)
+	questionnaire := models.Questionnaire{Title: "Pre", Type: "Pre-Inspection", IsActive: true, CompanyID: "COMP-A", CreatedAt: past, UpdatedAt: past}
+	mustCreate(t, db, &questionnaire)
+	question := models.Question{QuestionnaireID: questionnaire.ID, Text: "Kondisi", Type: "multiple", CreatedAt: past, UpdatedAt: past}
+	mustCreate(t, db, &question)
+	for _, label := range []string{"A", "B"} {
+		mustCreate(t, db, &models.Option{QuestionID: question.ID, Label: label, Text: label, CreatedAt: past, UpdatedAt: past})
+	}
+	device := models.MstrDevice{DeviceName: "Tablet", DeviceID: "TAB-1", CompanyID: "COMP-A", IsActive: true}
+	mustCreate(t, db, &device)
+	mustCreate(t, db, &models.MstrGroup{
+		GroupName:      "Shift",
+		CompanyID:      "COMP-A",
+		Devices:        []models.MstrDevice{device},
+		Questionnaires: []models.Questionnaire{questionnaire},
+	})
+
+	r := adminRouter("COMP-A")
+	r.GET("/devices/:deviceID/bundle", GetDeviceBundle)
+
+	get := func(since string) (string, []models.Questionnaire) {
+		t.Helper()
+		path := "/devices/TAB-1/bundle"
+		if since != "" {
+			path += "?since=" + url.QueryEscape(since)
+		}
+		w := doJSON(r, http.MethodGet, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d (body: %s)", path, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				Questionnaires []models.Questionnaire `json:"questionnaires"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Header().Get("ETag"), resp.Data.Questionnaires
+	}
+
+	since := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	fullTag, full := get("")
+	deltaTag, delta := get(since)
+	if len(full) != 1 || len(delta) != 0 {
+		t.Fatalf("questionnaires full = %d, delta = %d, want 1 and 0", len(full), len(delta))
+	}
+	if fullTag == deltaTag {
+		t.Errorf("full and delta responses share ETag %s", fullTag)
+	}
+
+	if err := deleteQuestionOptions(db, question.ID, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	_, delta = get(since)
+	if len(delta) != 1 {
+		t.Fatalf("delta after option delete has %d questionnaires, want 1", len(delta))
+	}
+	if n := len(delta[0].Questions[0].Options); n != 0 {
+		t.Errorf("deleted options still in bundle: %d", n)
+	}
+}
+
+// Gambar halaman dari snapshot versi published ikut di-sign supaya bisa diunduh untuk offline
+func TestDeviceBundleSignsPageImages(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", IsActive: true, StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+
+	inspection := models.MstrInspection{NameInspection: "Gudang", ImageUrl: "img/main.jpg", CompanyID: "COMP-A"}
+	mustCreate(t, db, &inspection)
+	mustCreate(t, db, &models.MstrInspectionPage{IdMstrInspection: inspection.Id, Title: "Lantai 2", ImageUrl: "img/page-2.jpg"})
+	if _, err := publishInspection(db, inspection.Id, "tester", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Halaman yang ditambah setelah publish (draft) tidak ikut bundle
+	mustCreate(t, db, &models.MstrInspectionPage{IdMstrInspection: inspection.Id, Title: "Draft", ImageUrl: "img/draft.jpg"})
+
+	device := models.MstrDevice{DeviceName: "Tablet", DeviceID: "TAB-1", CompanyID: "COMP-A", IsActive: true}
+	mustCreate(t, db, &device)
+	var published models.MstrInspection
+	db.First(&published, inspection.Id)
+	mustCreate(t, db, &models.MstrGroup{
+		GroupName:   "Shift",
+		CompanyID:   "COMP-A",
+		Devices:     []models.MstrDevice{device},
+		Inspections: []models.MstrInspection{published},
+	})
+
+	r := adminRouter("COMP-A")
+	r.GET("/devices/:deviceID/bundle", GetDeviceBundle)
+	w := doJSON(r, http.MethodGet, "/devices/TAB-1/bundle", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (body: %s)", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			SignedURLs map[string]string `json:"signed_urls"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"img/main.jpg", "img/page-2.jpg"} {
+		if resp.Data.SignedURLs[key] == "" {
+			t.Errorf("%s not signed: %v", key, resp.Data.SignedURLs)
+		}
+	}
+	if _, ok := resp.Data.SignedURLs["img/draft.jpg"]; ok {
+		t.Errorf("draft page image signed: %v", resp.Data.SignedURLs)
+	}
+}
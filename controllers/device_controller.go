@@ -5,8 +5,12 @@ import (
 	"go-api/models"
 	"go-api/utils"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func CreateDevice(c *gin.Context) {
@@ -24,17 +28,43 @@ func CreateDevice(c *gin.Context) {
 		return
 	}
 
-	// Jika tidak ada, lanjutkan simpan
+	// Hanya boleh request ke company yang ada dan aktif
+	var company models.MstrCompany
+	if err := config.DB.Where("company_id = ? AND is_active = ?", device.CompanyID, true).First(&company).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Company not found")
+		return
+	}
+
+	// Jika tidak ada, masuk antrian pending (approve/reject oleh admin)
+	enrolmentToken, err := utils.NewEnrolmentToken()
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
 	device.CreatedBy = "system"
 	device.UpdatedBy = "system"
 	device.IsActive = false
-
-	if err := config.DB.Create(&device).Error; err != nil {
+	device.EnrolmentStatus = models.EnrolmentPending
+	device.EnrolledVia = "request"
+	device.EnrolmentTokenHash = utils.HashToken(enrolmentToken)
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&device).Error; err != nil {
+			return err
+		}
+		return logEnrolment(tx, device, "requested", "device:"+device.DeviceID, "", c.ClientIP(), nil)
+	})
+	if err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.JSONSuccess(c, "Device created", device)
+	// Bentuk response tetap device seperti sebelumnya, ditambah enrolment_token
+	// yang dipakai tablet untuk polling /device-enrolment/status
+	utils.JSONSuccess(c, "Device created", struct {
+		models.MstrDevice
+		EnrolmentToken string `json:"enrolment_token"`
+	}{device, enrolmentToken})
 }
 
 // Update Device
@@ -43,7 +73,7 @@ func UpdateDeviceByID(c *gin.Context) {
 
 	// Cek apakah device ada untuk company tersebut
 	var device models.MstrDevice
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Where("id = ?", deviceID).
 		First(&device).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Device not found")
@@ -60,6 +90,14 @@ func UpdateDeviceByID(c *gin.Context) {
 		return
 	}
 
+	wasActive := device.IsActive
+	if !wasActive && input.IsActive {
+		if err := checkDeviceQuota(config.DB, device.CompanyID); err != nil {
+			respondQuotaError(c, err)
+			return
+		}
+	}
+
 	// Update field
 	device.DeviceName = input.DeviceName
 	device.IsActive = input.IsActive
@@ -68,11 +106,54 @@ func UpdateDeviceByID(c *gin.Context) {
 	username, _ := c.Get("username")
 	device.UpdatedBy = username.(string)
 
-	if err := config.DB.Save(&device).Error; err != nil {
+	// Device baru diaktifkan → terbitkan credential (secret hanya dikirim sekali di response ini)
+	var deviceKey string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&device).Error; err != nil {
+			return err
+		}
+		if !device.IsActive {
+			return utils.RevokeDeviceCredentials(tx, device.Id, device.UpdatedBy)
+		}
+		// Aktivasi manual device yang masih pending = approve
+		if device.IsActive && device.EnrolmentStatus == models.EnrolmentPending {
+			device.EnrolmentStatus = models.EnrolmentApproved
+			if err := tx.Model(&device).UpdateColumn("enrolment_status", device.EnrolmentStatus).Error; err != nil {
+				return err
+			}
+			if err := logEnrolment(tx, device, "approved", device.UpdatedBy, "", c.ClientIP(), nil); err != nil {
+				return err
+			}
+		}
+		// Tablet yang masih polling dengan enrolment token menerima credential-nya sendiri
+		// (lihat GetEnrolmentStatus), jadi di sini tidak diterbitkan supaya hanya ada satu credential
+		if !wasActive && device.EnrolmentTokenHash == "" && !utils.HasActiveDeviceCredential(device.Id) {
+			var err error
+			_, deviceKey, err = utils.IssueDeviceCredential(tx, device, device.UpdatedBy)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// Device dinonaktifkan → sesi tablet yang login dengan device ini langsung tidak berlaku
+	if !device.IsActive {
+		if err := utils.RevokeDeviceSessions(device.CompanyID, device.DeviceID, utils.RevokeDeviceDisabled); err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to revoke device sessions")
+			return
+		}
+	}
+
+	if deviceKey != "" {
+		utils.JSONSuccess(c, "Device updated", gin.H{
+			"device":     device,
+			"device_key": deviceKey,
+		})
+		return
+	}
 	utils.JSONSuccess(c, "Device updated", device)
 }
 
@@ -81,6 +162,12 @@ func DeleteDeviceByID(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrDevice{}).
 		Where("id = ?", id).
@@ -93,25 +180,105 @@ func DeleteDeviceByID(c *gin.Context) {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+
+	if err := utils.RevokeDeviceSessions(device.CompanyID, device.DeviceID, utils.RevokeDeviceDeleted); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to revoke device sessions")
+		return
+	}
+	if err := utils.RevokeDeviceCredentials(config.DB, device.Id, deletedBy); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to revoke device credentials")
+		return
+	}
 	utils.JSONSuccess(c, "Device deleted", nil)
 }
 
+// GET /mstr-device/:id/credentials → daftar credential device (tanpa secret)
+func GetDeviceCredentials(c *gin.Context) {
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+
+	var creds []models.MstrDeviceCredential
+	if err := config.DB.Where("device_id = ?", device.Id).Order("id DESC").Find(&creds).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device credentials", creds)
+}
+
+// POST /mstr-device/:id/credentials → rotate: revoke credential lama dan terbitkan yang baru
+func RotateDeviceCredential(c *gin.Context) {
+	username := c.GetString("username")
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+	if !device.IsActive {
+		utils.JSONError(c, http.StatusBadRequest, "Device is not active")
+		return
+	}
+
+	var cred *models.MstrDeviceCredential
+	var deviceKey string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.RevokeDeviceCredentials(tx, device.Id, username); err != nil {
+			return err
+		}
+		var err error
+		cred, deviceKey, err = utils.IssueDeviceCredential(tx, device, username)
+		return err
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to issue device credential: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device credential issued", gin.H{
+		"credential": cred,
+		"device_key": deviceKey,
+	})
+}
+
+// DELETE /mstr-device/:id/credentials → revoke semua credential device
+func RevokeDeviceCredentials(c *gin.Context) {
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+
+	if err := utils.RevokeDeviceCredentials(config.DB, device.Id, c.GetString("username")); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device credentials revoked", nil)
+}
+
 // Get Filtered Devices
 func GetFilteredDevices(c *gin.Context) {
 	createdBy := c.Query("created_by")
 	updatedBy := c.Query("updated_by")
 	deviceName := c.Query("device_name")
 	deviceID := c.Query("device_id")
+	notSeenDays := c.Query("not_seen_days")
+	appVersion := c.Query("app_version")
+	outdated := c.Query("outdated") == "true"
+	minAppVersion := c.DefaultQuery("min_app_version", os.Getenv("MIN_APP_VERSION"))
 
-	var devices []models.MstrDevice
-	query := config.DB.Model(&models.MstrDevice{})
-
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
+	if outdated && minAppVersion == "" {
+		utils.JSONError(c, http.StatusBadRequest, "min_app_version is required for outdated filter")
+		return
 	}
 
+	var devices []models.MstrDevice
+	query := config.DB.Model(&models.MstrDevice{}).Scopes(utils.CompanyScope(c))
+
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
 	}
@@ -124,31 +291,70 @@ func GetFilteredDevices(c *gin.Context) {
 	if deviceID != "" {
 		query = query.Where("device_id = ?", deviceID)
 	}
+	if appVersion != "" {
+		query = query.Where("app_version = ?", appVersion)
+	}
+
+	// Device yang tidak heartbeat N hari terakhir (termasuk yang belum pernah)
+	if notSeenDays != "" {
+		days, err := strconv.Atoi(notSeenDays)
+		if err != nil || days < 0 {
+			utils.JSONError(c, http.StatusBadRequest, "Invalid not_seen_days")
+			return
+		}
+		query = query.Where("(last_seen_at IS NULL OR last_seen_at < ?)", time.Now().AddDate(0, 0, -days))
+	}
 
 	if err := query.Order("id DESC").Find(&devices).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// Versi dibandingkan per segmen angka (1.10.0 > 1.9.0), jadi difilter di sini bukan di SQL.
+	// Device yang belum pernah kirim versi dianggap outdated.
+	if outdated {
+		filtered := make([]models.MstrDevice, 0, len(devices))
+		for _, d := range devices {
+			if d.AppVersion == "" || utils.CompareVersions(d.AppVersion, minAppVersion) < 0 {
+				filtered = append(filtered, d)
+			}
+		}
+		devices = filtered
+	}
+
 	utils.JSONSuccess(c, "Filtered devices", devices)
 }
 
+// scopeDeviceQuery menambahkan filter md.company_id pada raw query endpoint tablet (kecuali platform admin).
+// Query harus diakhiri klausa WHERE yang memakai alias md untuk mstr_device.
+func scopeDeviceQuery(c *gin.Context, query string, args ...interface{}) (string, []interface{}) {
+	if companyID, restricted := utils.TenantCompanyID(c); restricted {
+		query += " AND md.company_id = ?"
+		args = append(args, companyID)
+	}
+	return query, args
+}
+
 func GetDeviceInspection(c *gin.Context) {
 	deviceID := c.Param("deviceID")
 
+	// Hanya versi published yang dikirim ke tablet (nama & image diambil dari versi, bukan draft)
 	query := `
-        SELECT mi.id, mi.name_inspection, mi.image_url, mi.created_at, 
-               mi.updated_at, mi.created_by, mi.updated_by, mi.company_id
+        SELECT mi.id, miv.name_inspection, miv.image_url, mi.created_at, 
+               miv.published_at AS updated_at, mi.created_by, miv.published_by AS updated_by, mi.company_id,
+               mi.status, mi.published_version, mi.published_version_id
         FROM mstr_device md
         JOIN mstr_group_device mgd ON mgd.mstr_device_id = md.id
         JOIN mstr_group mg ON mg.id = mgd.mstr_group_id
         JOIN mstr_group_inspection mgi ON mgi.mstr_group_id = mg.id
         JOIN mstr_inspection mi ON mi.id = mgi.mstr_inspection_id
+        JOIN mstr_inspection_version miv ON miv.id = mi.published_version_id
         WHERE md.device_id = ?
     `
 
 	var inspections []models.MstrInspection
-	if err := config.DB.Raw(query, deviceID).Scan(&inspections).Error; err != nil {
+	query, args := scopeDeviceQuery(c, query, deviceID)
+	if err := config.DB.Raw(query, args...).Scan(&inspections).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -158,6 +364,13 @@ func GetDeviceInspection(c *gin.Context) {
 		return
 	}
 
+	// Halaman tambahan dari versi published
+	for i := range inspections {
+		if _, snapshot, err := loadInspectionVersion(config.DB, inspections[i].Id, 0); err == nil {
+			inspections[i].Pages = snapshot.Pages
+		}
+	}
+
 	utils.JSONSuccess(c, "Device inspections fetched successfully", inspections)
 }
 
@@ -176,7 +389,8 @@ func GetDevicePreInspection(c *gin.Context) {
     `
 
 	var questionnaires []models.Questionnaire
-	if err := config.DB.Raw(query, deviceID).Scan(&questionnaires).Error; err != nil {
+	query, args := scopeDeviceQuery(c, query, deviceID)
+	if err := config.DB.Raw(query, args...).Scan(&questionnaires).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -204,7 +418,8 @@ func GetDevicePostInspection(c *gin.Context) {
     `
 
 	var questionnaires []models.Questionnaire
-	if err := config.DB.Raw(query, deviceID).Scan(&questionnaires).Error; err != nil {
+	query, args := scopeDeviceQuery(c, query, deviceID)
+	if err := config.DB.Raw(query, args...).Scan(&questionnaires).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -232,7 +447,8 @@ func GetChainingByDevice(c *gin.Context) {
     `
 
 	var chaining []models.MstrChaining
-	if err := config.DB.Raw(query, deviceID).Scan(&chaining).Error; err != nil {
+	query, args := scopeDeviceQuery(c, query, deviceID)
+	if err := config.DB.Raw(query, args...).Scan(&chaining).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -0,0 +1,495 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPairingTTL = 15 * time.Minute
+	maxPairingTTL     = 24 * time.Hour
+)
+
+var (
+	errPairingCodeInvalid = errors.New("Pairing code is invalid or expired")
+	errDeviceOtherCompany = errors.New("Device ID is already registered to another company")
+	errCompanyInactive    = errors.New("Company is not active")
+	errDeviceEnrolled     = errors.New("Device ID is already enrolled, ask an admin for a re-enrolment pairing code")
+	errPairingOtherDevice = errors.New("Pairing code is for re-enrolling another device")
+)
+
+// logEnrolment mencatat audit trail enrolment device
+func logEnrolment(tx *gorm.DB, device models.MstrDevice, action, actor, note, ip string, codeID *uint) error {
+	return tx.Create(&models.TrxDeviceEnrolmentLog{
+		DeviceID:      device.Id,
+		CompanyID:     device.CompanyID,
+		Action:        action,
+		PairingCodeID: codeID,
+		Actor:         actor,
+		Note:          note,
+		IPAddress:     ip,
+	}).Error
+}
+
+// loadCompanyGroup memastikan group milik company
+func loadCompanyGroup(tx *gorm.DB, companyID string, groupID uint) (*models.MstrGroup, error) {
+	var group models.MstrGroup
+	if err := tx.Where("company_id = ?", companyID).First(&group, groupID).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ===================== ADMIN: PAIRING CODE =====================
+
+// POST /device-pairing-codes → admin membuat kode pairing untuk company (opsional langsung ke group)
+func CreatePairingCode(c *gin.Context) {
+	var req struct {
+		GroupID    *uint `json:"group_id"`
+		TTLMinutes int   `json:"ttl_minutes"`
+		MaxUses    int   `json:"max_uses"`
+
+		// Opsional: kode untuk re-enrol device yang sudah aktif (mstr_device.id)
+		ReenrolDeviceID *uint `json:"reenrol_device_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	companyID := c.GetString("company_id")
+	username := c.GetString("username")
+
+	ttl := defaultPairingTTL
+	if req.TTLMinutes > 0 {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+	if ttl > maxPairingTTL {
+		utils.JSONError(c, http.StatusBadRequest, "ttl_minutes must not exceed 1440")
+		return
+	}
+	if req.MaxUses <= 0 {
+		req.MaxUses = 1
+	}
+
+	if req.GroupID != nil {
+		if _, err := loadCompanyGroup(config.DB, companyID, *req.GroupID); err != nil {
+			utils.JSONError(c, http.StatusNotFound, "Group not found")
+			return
+		}
+	}
+	if req.ReenrolDeviceID != nil {
+		if err := config.DB.Where("company_id = ?", companyID).Select("id").First(&models.MstrDevice{}, *req.ReenrolDeviceID).Error; err != nil {
+			utils.JSONError(c, http.StatusNotFound, "Device not found")
+			return
+		}
+		// re-enrolment selalu untuk satu tablet
+		req.MaxUses = 1
+	}
+
+	code, err := utils.NewPairingCode()
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	pairing := models.MstrDevicePairingCode{
+		CompanyID: companyID,
+		GroupID:   req.GroupID,
+		Code:      code,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: username,
+
+		ReenrolDeviceID: req.ReenrolDeviceID,
+	}
+	if err := config.DB.Create(&pairing).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to create pairing code")
+		return
+	}
+
+	// payload yang di-encode jadi QR oleh frontend
+	qr, _ := json.Marshal(gin.H{"company_id": companyID, "code": code})
+
+	utils.JSONCreated(c, "Pairing code created", gin.H{
+		"pairing_code": pairing,
+		"qr_payload":   string(qr),
+	})
+}
+
+// GET /device-pairing-codes → kode yang masih aktif (?all=true termasuk yang expired/revoked)
+func GetPairingCodes(c *gin.Context) {
+	query := config.DB.Preload("Group").Scopes(utils.CompanyScope(c)).Order("id DESC")
+	if c.Query("all") != "true" {
+		query = query.Where("revoked_at IS NULL AND expires_at > ? AND used_count < max_uses", time.Now())
+	}
+
+	var codes []models.MstrDevicePairingCode
+	if err := query.Find(&codes).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Pairing codes", codes)
+}
+
+// DELETE /device-pairing-codes/:id → revoke kode pairing
+func RevokePairingCode(c *gin.Context) {
+	var pairing models.MstrDevicePairingCode
+	if err := utils.TenantDB(c).First(&pairing, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Pairing code not found")
+		return
+	}
+
+	if err := config.DB.Model(&pairing).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_by": c.GetString("username"),
+	}).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Pairing code revoked", nil)
+}
+
+// ===================== TABLET (PUBLIC) =====================
+
+// POST /device-enrolment/redeem → tablet menukar kode pairing, device langsung aktif + credential
+func RedeemPairingCode(c *gin.Context) {
+	var req struct {
+		Code       string `json:"code" binding:"required"`
+		DeviceID   string `json:"device_id" binding:"required"`
+		DeviceName string `json:"device_name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+
+	var device models.MstrDevice
+	var deviceKey string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var pairing models.MstrDevicePairingCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code = ? AND revoked_at IS NULL AND expires_at > ?", code, time.Now()).
+			First(&pairing).Error; err != nil {
+			return errPairingCodeInvalid
+		}
+		if pairing.UsedCount >= pairing.MaxUses {
+			return errPairingCodeInvalid
+		}
+
+		var company models.MstrCompany
+		if err := tx.Where("company_id = ? AND is_active = ?", pairing.CompanyID, true).First(&company).Error; err != nil {
+			return errCompanyInactive
+		}
+
+		// device_id unik global (termasuk yang sudah di-soft delete)
+		err := tx.Unscoped().Where("device_id = ?", req.DeviceID).First(&device).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found := err == nil
+		reenrol := found && pairing.ReenrolDeviceID != nil && *pairing.ReenrolDeviceID == device.Id
+		switch {
+		case found && device.CompanyID != pairing.CompanyID:
+			return errDeviceOtherCompany
+		case pairing.ReenrolDeviceID != nil && !reenrol:
+			return errPairingOtherDevice
+		case found && device.IsActive && !device.DeletedAt.Valid && !reenrol:
+			// Device aktif tidak bisa diambil alih tablet lain tanpa kode re-enrolment dari admin
+			return errDeviceEnrolled
+		case found:
+			device.DeletedAt = gorm.DeletedAt{}
+			device.DeletedBy = ""
+		default:
+			device = models.MstrDevice{
+				DeviceID:  req.DeviceID,
+				CompanyID: pairing.CompanyID,
+				CreatedBy: "pairing:" + pairing.Code,
+			}
+		}
+
+		if !device.IsActive {
+			if err := checkDeviceQuota(tx, pairing.CompanyID); err != nil {
+				return err
+			}
+		}
+
+		if req.DeviceName != "" {
+			device.DeviceName = req.DeviceName
+		}
+		device.IsActive = true
+		device.EnrolmentStatus = models.EnrolmentApproved
+		device.EnrolledVia = "pairing_code"
+		device.EnrolmentTokenHash = ""
+		device.UpdatedBy = "pairing:" + pairing.Code
+		if err := tx.Unscoped().Save(&device).Error; err != nil {
+			return err
+		}
+
+		if pairing.GroupID != nil {
+			group, err := loadCompanyGroup(tx, pairing.CompanyID, *pairing.GroupID)
+			if err == nil {
+				if err := tx.Model(group).Association("Devices").Append(&device); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Model(&pairing).UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+			return err
+		}
+
+		if err := utils.RevokeDeviceCredentials(tx, device.Id, "system"); err != nil {
+			return err
+		}
+		if _, deviceKey, err = utils.IssueDeviceCredential(tx, device, "pairing:"+pairing.Code); err != nil {
+			return err
+		}
+
+		action := "redeemed"
+		if reenrol {
+			action = "reenrolled"
+		}
+		return logEnrolment(tx, device, action, "device:"+device.DeviceID, "", c.ClientIP(), &pairing.Id)
+	})
+	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, errPairingCodeInvalid):
+			utils.JSONError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, errDeviceOtherCompany), errors.Is(err, errDeviceEnrolled), errors.Is(err, errPairingOtherDevice):
+			utils.JSONError(c, http.StatusConflict, err.Error())
+		case errors.Is(err, errCompanyInactive):
+			utils.JSONError(c, http.StatusForbidden, err.Error())
+		default:
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to enrol device: "+err.Error())
+		}
+		return
+	}
+
+	utils.JSONSuccess(c, "Device enrolled", gin.H{
+		"device":     device,
+		"device_key": deviceKey,
+	})
+}
+
+// POST /device-enrolment/status → tablet mengecek status request enrolment-nya.
+// Setelah di-approve, credential dikirim sekali dan enrolment token tidak berlaku lagi.
+func GetEnrolmentStatus(c *gin.Context) {
+	var req struct {
+		DeviceID       string `json:"device_id" binding:"required"`
+		EnrolmentToken string `json:"enrolment_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	var device models.MstrDevice
+	if err := config.DB.
+		Where("device_id = ? AND enrolment_token_hash = ?", req.DeviceID, utils.HashToken(req.EnrolmentToken)).
+		First(&device).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Enrolment not found")
+		return
+	}
+
+	if device.EnrolmentStatus != models.EnrolmentApproved || !device.IsActive {
+		utils.JSONSuccess(c, "Enrolment status", gin.H{
+			"enrolment_status": device.EnrolmentStatus,
+		})
+		return
+	}
+
+	var deviceKey string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&device).UpdateColumn("enrolment_token_hash", "").Error; err != nil {
+			return err
+		}
+		if err := utils.RevokeDeviceCredentials(tx, device.Id, "system"); err != nil {
+			return err
+		}
+		var err error
+		if _, deviceKey, err = utils.IssueDeviceCredential(tx, device, "enrolment"); err != nil {
+			return err
+		}
+		return logEnrolment(tx, device, "credential_delivered", "device:"+device.DeviceID, "", c.ClientIP(), nil)
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to issue device credential: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Enrolment status", gin.H{
+		"enrolment_status": device.EnrolmentStatus,
+		"device":           device,
+		"device_key":       deviceKey,
+	})
+}
+
+// ===================== ADMIN: PENDING QUEUE =====================
+
+// GET /device-enrolments?status=pending
+func GetDeviceEnrolments(c *gin.Context) {
+	status := c.DefaultQuery("status", models.EnrolmentPending)
+
+	query := config.DB.Scopes(utils.CompanyScope(c)).
+		Where("enrolment_status = ?", status).
+		Order("created_at DESC")
+	if companyID := c.Query("company_id"); companyID != "" && utils.HasPermission(c, utils.PermPlatformAdmin) {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	var devices []models.MstrDevice
+	if err := query.Find(&devices).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device enrolments", devices)
+}
+
+// POST /device-enrolments/:id/approve → aktifkan device (opsional langsung masuk group)
+func ApproveDeviceEnrolment(c *gin.Context) {
+	username := c.GetString("username")
+
+	var req struct {
+		GroupID *uint  `json:"group_id"`
+		Note    string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+	if device.EnrolmentStatus != models.EnrolmentPending {
+		utils.JSONError(c, http.StatusBadRequest, "Device enrolment is not pending")
+		return
+	}
+
+	var group *models.MstrGroup
+	if req.GroupID != nil {
+		var err error
+		if group, err = loadCompanyGroup(config.DB, device.CompanyID, *req.GroupID); err != nil {
+			utils.JSONError(c, http.StatusNotFound, "Group not found")
+			return
+		}
+	}
+
+	if err := checkDeviceQuota(config.DB, device.CompanyID); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
+	// Tablet tanpa enrolment token (device lama) tidak bisa polling → credential dikirim ke admin
+	var deviceKey string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		device.IsActive = true
+		device.EnrolmentStatus = models.EnrolmentApproved
+		device.UpdatedBy = username
+		if err := tx.Save(&device).Error; err != nil {
+			return err
+		}
+		if group != nil {
+			if err := tx.Model(group).Association("Devices").Append(&device); err != nil {
+				return err
+			}
+		}
+		if device.EnrolmentTokenHash == "" && !utils.HasActiveDeviceCredential(device.Id) {
+			var err error
+			if _, deviceKey, err = utils.IssueDeviceCredential(tx, device, username); err != nil {
+				return err
+			}
+		}
+		return logEnrolment(tx, device, "approved", username, req.Note, c.ClientIP(), nil)
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to approve device: "+err.Error())
+		return
+	}
+
+	data := gin.H{"device": device}
+	if deviceKey != "" {
+		data["device_key"] = deviceKey
+	}
+	utils.JSONSuccess(c, "Device approved", data)
+}
+
+// POST /device-enrolments/:id/reject
+func RejectDeviceEnrolment(c *gin.Context) {
+	username := c.GetString("username")
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+	if device.EnrolmentStatus != models.EnrolmentPending {
+		utils.JSONError(c, http.StatusBadRequest, "Device enrolment is not pending")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		device.IsActive = false
+		device.EnrolmentStatus = models.EnrolmentRejected
+		device.UpdatedBy = username
+		if err := tx.Save(&device).Error; err != nil {
+			return err
+		}
+		if err := utils.RevokeDeviceCredentials(tx, device.Id, username); err != nil {
+			return err
+		}
+		return logEnrolment(tx, device, "rejected", username, req.Note, c.ClientIP(), nil)
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to reject device: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device rejected", device)
+}
+
+// GET /mstr-device/:id/enrolment-logs → audit trail enrolment device
+func GetDeviceEnrolmentLogs(c *gin.Context) {
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).Unscoped().First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+
+	var logs []models.TrxDeviceEnrolmentLog
+	if err := config.DB.Where("device_id = ?", device.Id).Order("id DESC").Find(&logs).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device enrolment logs", logs)
+}
+
+// MigrateLegacyDeviceEnrolment mengisi enrolment_status device lama berdasarkan is_active
+func MigrateLegacyDeviceEnrolment() {
+	config.DB.Model(&models.MstrDevice{}).
+		Where("enrolment_status IS NULL OR enrolment_status = ''").
+		Update("enrolment_status", gorm.Expr("CASE WHEN is_active THEN ? ELSE ? END", models.EnrolmentApproved, models.EnrolmentPending))
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type enrolmentResponse struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func decodeData(t *testing.T, body []byte, out interface{}) {
+	t.Helper()
+	var resp enrolmentResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode response: %v (%s)", err, body)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		t.Fatalf("decode data: %v (%s)", err, resp.Data)
+	}
+}
+
+func seedEnrolmentCompany(t *testing.T, db *gorm.DB, companyID string) {
+	t.Helper()
+	mustCreate(t, db, &models.MstrCompany{CompanyName: companyID, CompanyID: companyID, IsActive: true})
+}
+
+func activeCredentials(t *testing.T, db *gorm.DB, deviceID uint) (active, total int64) {
+	t.Helper()
+	db.Model(&models.MstrDeviceCredential{}).Where("device_id = ? AND revoked_at IS NULL", deviceID).Count(&active)
+	db.Model(&models.MstrDeviceCredential{}).Where("device_id = ?", deviceID).Count(&total)
+	return active, total
+}
+
+// Response CreateDevice tetap berbentuk device (client lama), enrolment_token hanya field tambahan
+func TestCreateDeviceKeepsDeviceResponse(t *testing.T) {
+	db := newTestDB(t)
+	seedEnrolmentCompany(t, db, "COMP-A")
+
+	r := adminRouter("COMP-A")
+	r.POST("/mstr-device", CreateDevice)
+	w := doJSON(r, http.MethodPost, "/mstr-device", gin.H{"device_id": "TAB-1", "device_name": "Tablet 1", "company_id": "COMP-A"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body.String())
+	}
+
+	var data map[string]interface{}
+	decodeData(t, w.Body.Bytes(), &data)
+	if data["device_id"] != "TAB-1" || data["company_id"] != "COMP-A" || data["enrolment_status"] != models.EnrolmentPending {
+		t.Errorf("data is not the device object: %v", data)
+	}
+	if token, _ := data["enrolment_token"].(string); token == "" {
+		t.Errorf("enrolment_token missing: %v", data)
+	}
+	if _, nested := data["device"]; nested {
+		t.Errorf("device must not be nested: %v", data)
+	}
+}
+
+func TestRedeemPairingCodeExistingDevice(t *testing.T) {
+	db := newTestDB(t)
+	seedEnrolmentCompany(t, db, "COMP-A")
+
+	active := models.MstrDevice{DeviceID: "TAB-1", CompanyID: "COMP-A", IsActive: true, EnrolmentStatus: models.EnrolmentApproved}
+	mustCreate(t, db, &active)
+	inactive := models.MstrDevice{DeviceID: "TAB-2", CompanyID: "COMP-A", EnrolmentStatus: models.EnrolmentApproved}
+	mustCreate(t, db, &inactive)
+	db.Model(&inactive).Update("is_active", false)
+	other := models.MstrDevice{DeviceID: "TAB-3", CompanyID: "COMP-A", IsActive: true, EnrolmentStatus: models.EnrolmentApproved}
+	mustCreate(t, db, &other)
+
+	r := adminRouter("COMP-A")
+	r.POST("/device-pairing-codes", CreatePairingCode)
+	r.POST("/device-enrolment/redeem", RedeemPairingCode)
+	r.POST("/mstr-device/:id/credentials", RotateDeviceCredential)
+
+	// credential tablet lama
+	if w := doJSON(r, http.MethodPost, idPath("/mstr-device/%d/credentials", active.Id), nil); w.Code != http.StatusOK {
+		t.Fatalf("rotate: %d %s", w.Code, w.Body.String())
+	}
+
+	newCode := func(body gin.H) string {
+		t.Helper()
+		w := doJSON(r, http.MethodPost, "/device-pairing-codes", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create pairing code: %d %s", w.Code, w.Body.String())
+		}
+		var data struct {
+			PairingCode models.MstrDevicePairingCode `json:"pairing_code"`
+		}
+		decodeData(t, w.Body.Bytes(), &data)
+		return data.PairingCode.Code
+	}
+
+	tests := []struct {
+		name       string
+		code       gin.H
+		deviceID   string
+		want       int
+		wantAction string
+	}{
+		{"plain code cannot take over active device", gin.H{"max_uses": 5}, "TAB-1", http.StatusConflict, ""},
+		{"re-enrolment code for another device", gin.H{"reenrol_device_id": other.Id}, "TAB-1", http.StatusConflict, ""},
+		{"plain code re-activates inactive device", gin.H{}, "TAB-2", http.StatusOK, "redeemed"},
+		{"plain code enrols new device", gin.H{}, "TAB-9", http.StatusOK, "redeemed"},
+		{"re-enrolment code for this device", gin.H{"reenrol_device_id": active.Id}, "TAB-1", http.StatusOK, "reenrolled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := newCode(tt.code)
+			w := doJSON(r, http.MethodPost, "/device-enrolment/redeem", gin.H{"code": code, "device_id": tt.deviceID})
+			if w.Code != tt.want {
+				t.Fatalf("redeem: status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.wantAction == "" {
+				return
+			}
+			var device models.MstrDevice
+			db.Where("device_id = ?", tt.deviceID).First(&device)
+			var log models.TrxDeviceEnrolmentLog
+			db.Where("device_id = ?", device.Id).Order("id DESC").First(&log)
+			if log.Action != tt.wantAction {
+				t.Errorf("enrolment log action = %q, want %q", log.Action, tt.wantAction)
+			}
+			if n, _ := activeCredentials(t, db, device.Id); n != 1 {
+				t.Errorf("active credentials = %d, want 1", n)
+			}
+		})
+	}
+
+	// Re-enrolment mengganti credential lama: total 2, yang aktif tetap 1
+	if n, total := activeCredentials(t, db, active.Id); n != 1 || total != 2 {
+		t.Errorf("after re-enrolment: active = %d, total = %d; want 1, 2", n, total)
+	}
+}
+
+// Aktivasi device hasil request (pending + enrolment token) hanya menerbitkan satu credential
+func TestActivationIssuesSingleCredential(t *testing.T) {
+	db := newTestDB(t)
+	seedEnrolmentCompany(t, db, "COMP-A")
+
+	r := adminRouter("COMP-A")
+	r.POST("/mstr-device", CreateDevice)
+	r.PUT("/mstr-device/:id", UpdateDeviceByID)
+	r.POST("/device-enrolment/status", GetEnrolmentStatus)
+
+	w := doJSON(r, http.MethodPost, "/mstr-device", gin.H{"device_id": "TAB-1", "company_id": "COMP-A"})
+	var created struct {
+		Id             uint   `json:"id"`
+		EnrolmentToken string `json:"enrolment_token"`
+	}
+	decodeData(t, w.Body.Bytes(), &created)
+
+	w = doJSON(r, http.MethodPut, idPath("/mstr-device/%d", created.Id), gin.H{"device_name": "Tablet 1", "is_active": true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("activate: %d %s", w.Code, w.Body.String())
+	}
+	var updated map[string]interface{}
+	decodeData(t, w.Body.Bytes(), &updated)
+	if _, ok := updated["device_key"]; ok {
+		t.Errorf("activation must not issue a credential while the tablet is polling: %v", updated)
+	}
+
+	w = doJSON(r, http.MethodPost, "/device-enrolment/status", gin.H{"device_id": "TAB-1", "enrolment_token": created.EnrolmentToken})
+	var status struct {
+		DeviceKey string `json:"device_key"`
+	}
+	decodeData(t, w.Body.Bytes(), &status)
+	if status.DeviceKey == "" {
+		t.Fatalf("poll did not deliver the credential: %s", w.Body.String())
+	}
+
+	if n, total := activeCredentials(t, db, created.Id); n != 1 || total != 1 {
+		t.Errorf("credentials after activation: active = %d, total = %d; want 1, 1", n, total)
+	}
+
+	// Enrolment token hanya sekali pakai
+	w = doJSON(r, http.MethodPost, "/device-enrolment/status", gin.H{"device_id": "TAB-1", "enrolment_token": created.EnrolmentToken})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second poll: status = %d, want 404", w.Code)
+	}
+}
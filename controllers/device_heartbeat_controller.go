@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HeartbeatReq struct {
+	AppVersion     string     `json:"app_version" binding:"required"`
+	OSName         string     `json:"os_name"`
+	OSVersion      string     `json:"os_version"`
+	BatteryLevel   *int       `json:"battery_level"`
+	IsCharging     *bool      `json:"is_charging"`
+	StorageFreeMB  *int64     `json:"storage_free_mb"`
+	StorageTotalMB *int64     `json:"storage_total_mb"`
+	LastSyncAt     *time.Time `json:"last_sync_at"`
+}
+
+// POST /devices/:deviceID/heartbeat → dipanggil tablet secara periodik
+func PostDeviceHeartbeat(c *gin.Context) {
+	// Device pemilik credential sudah dicek di middleware.APIKeyAuth
+	deviceID := c.Param("deviceID")
+
+	var req HeartbeatReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.BatteryLevel != nil && (*req.BatteryLevel < 0 || *req.BatteryLevel > 100) {
+		utils.JSONError(c, http.StatusBadRequest, "battery_level must be between 0 and 100")
+		return
+	}
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).Where("device_id = ? AND is_active = ?", deviceID, true).First(&device).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found or inactive")
+		return
+	}
+
+	now := time.Now()
+	heartbeat := models.TrxDeviceHeartbeat{
+		DeviceID:       device.Id,
+		CompanyID:      device.CompanyID,
+		AppVersion:     req.AppVersion,
+		OSName:         req.OSName,
+		OSVersion:      req.OSVersion,
+		BatteryLevel:   req.BatteryLevel,
+		IsCharging:     req.IsCharging,
+		StorageFreeMB:  req.StorageFreeMB,
+		StorageTotalMB: req.StorageTotalMB,
+		LastSyncAt:     req.LastSyncAt,
+		IPAddress:      c.ClientIP(),
+	}
+
+	latest := map[string]interface{}{
+		"last_seen_at":     now,
+		"app_version":      req.AppVersion,
+		"os_name":          req.OSName,
+		"os_version":       req.OSVersion,
+		"battery_level":    req.BatteryLevel,
+		"is_charging":      req.IsCharging,
+		"storage_free_mb":  req.StorageFreeMB,
+		"storage_total_mb": req.StorageTotalMB,
+	}
+	if req.LastSyncAt != nil {
+		latest["last_sync_at"] = req.LastSyncAt
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&heartbeat).Error; err != nil {
+			return err
+		}
+		// UpdateColumns supaya updated_by/updated_at master tidak berubah tiap heartbeat
+		return tx.Model(&models.MstrDevice{}).Where("id = ?", device.Id).UpdateColumns(latest).Error
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to save heartbeat: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Heartbeat received", gin.H{
+		"server_time": now,
+	})
+}
+
+// GET /mstr-device/:id/heartbeats?days=7 → riwayat heartbeat device
+func GetDeviceHeartbeats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid days")
+		return
+	}
+
+	var device models.MstrDevice
+	if err := utils.TenantDB(c).First(&device, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Device not found")
+		return
+	}
+
+	var heartbeats []models.TrxDeviceHeartbeat
+	if err := config.DB.
+		Where("device_id = ? AND created_at >= ?", device.Id, time.Now().AddDate(0, 0, -days)).
+		Order("created_at DESC").
+		Limit(1000).
+		Find(&heartbeats).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Device heartbeats", heartbeats)
+}
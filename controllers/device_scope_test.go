@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Submit dengan X-Device-Key hanya boleh atas nama device pemilik credential
+func TestDeviceScopedSubmitRejectsOtherDevice(t *testing.T) {
+	newTestDB(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		handler gin.HandlerFunc
+		form    url.Values
+	}{
+		{"trx inspection", "/trx-inspections", CreateTRXInspection, url.Values{
+			"id_inspection": {"1"}, "id_user": {"1"}, "device_id": {"TAB-2"}, "details": {"[]"},
+		}},
+		{"questionnaire answers", "/questions/answers-all", SubmitAllAnswersWithMaster, url.Values{
+			"questionnaire_id": {"1"}, "user_id": {"1"}, "device_id": {"TAB-2"}, "answers": {"[]"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := adminRouter("COMP-A")
+			r.POST(tt.path, func(c *gin.Context) { c.Set("auth_device_id", "TAB-1") }, tt.handler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403 (body: %s)", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -36,7 +36,7 @@ func UpdateEventByID(c *gin.Context) {
 	eventID := c.Param("id")
 
 	var event models.MstrEventTrigger
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Where("id = ?", eventID).
 		First(&event).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Event not found")
@@ -76,6 +76,11 @@ func DeleteEventByID(c *gin.Context) {
 	eventID := c.Param("id")
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrEventTrigger{}, eventID).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Event not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrEventTrigger{}).
 		Where("id = ?", eventID).
@@ -98,13 +103,7 @@ func GetFilteredEvents(c *gin.Context) {
 	eventID := c.Query("id")
 
 	var events []models.MstrEventTrigger
-	query := config.DB.Model(&models.MstrEventTrigger{})
-
-	role := c.GetString("role")
-	userCompanyID := c.GetString("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query := config.DB.Model(&models.MstrEventTrigger{}).Scopes(utils.CompanyScope(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"fmt"
+	"go-api/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tipe file evidence attachment
+const (
+	evidencePhoto = "Photo"
+	evidenceVideo = "Video"
+	evidenceAudio = "Audio"
+)
+
+// attachmentPayload = satu evidence tambahan di payload submit assurance (per SAM point atau per jawaban)
+type attachmentPayload struct {
+	FileKey   string `json:"file_key"`  // nama field multipart / upload_id / object key presigned upload
+	FileType  string `json:"file_type"` // Photo|Video|Audio, kosong = dari content type file
+	Caption   string `json:"caption"`
+	SortOrder *int   `json:"sort_order"` // kosong = urutan di payload
+}
+
+// checkAttachments mengecek payload attachment sebelum ada file yang di-upload
+func checkAttachments(attachments []attachmentPayload) []string {
+	var errs []string
+	for i, a := range attachments {
+		if strings.TrimSpace(a.FileKey) == "" {
+			errs = append(errs, fmt.Sprintf("attachment #%d: file_key is required", i+1))
+		}
+		if a.FileType != "" && normalizeEvidenceType(a.FileType) == "" {
+			errs = append(errs, fmt.Sprintf("attachment #%d: file_type must be Photo, Video or Audio", i+1))
+		}
+	}
+	return errs
+}
+
+// checkEvidenceCount: count = capture_url (jika ada) + attachments SAM point
+func checkEvidenceCount(sam models.MstrInspectionDetail, count int) []string {
+	if sam.MinEvidence > 0 && count < sam.MinEvidence {
+		return []string{fmt.Sprintf("at least %d evidence file(s) required, got %d", sam.MinEvidence, count)}
+	}
+	if sam.MaxEvidence > 0 && count > sam.MaxEvidence {
+		return []string{fmt.Sprintf("at most %d evidence file(s) allowed, got %d", sam.MaxEvidence, count)}
+	}
+	return nil
+}
+
+func normalizeEvidenceType(fileType string) string {
+	switch strings.ToLower(strings.TrimSpace(fileType)) {
+	case "photo", "image":
+		return evidencePhoto
+	case "video":
+		return evidenceVideo
+	case "audio":
+		return evidenceAudio
+	}
+	return ""
+}
+
+// evidenceTypeFromContentType dipakai jika client tidak mengirim file_type
+func evidenceTypeFromContentType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return evidencePhoto
+	case strings.HasPrefix(contentType, "video/"):
+		return evidenceVideo
+	case strings.HasPrefix(contentType, "audio/"):
+		return evidenceAudio
+	}
+	return ""
+}
+
+// uploadEvidenceAttachments meng-upload (atau memakai evidence yang sudah diterima) dan menyiapkan
+// baris TrxEvidenceAttachment. base berisi foreign key, company dan created_by; baris belum disimpan.
+func uploadEvidenceAttachments(c *gin.Context, submissionID string, base models.TrxEvidenceAttachment, attachments []attachmentPayload) ([]models.TrxEvidenceAttachment, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	rows := make([]models.TrxEvidenceAttachment, 0, len(attachments))
+	for i, a := range attachments {
+		evidence, err := storeSubmissionFile(c, submissionID, a.FileKey, moduleTrnAssurance)
+		if err != nil {
+			return nil, err
+		}
+
+		fileType := normalizeEvidenceType(a.FileType)
+		if fileType == "" {
+			fileType = evidenceTypeFromContentType(evidence.ContentType)
+		}
+		if fileType == "" {
+			return nil, fmt.Errorf("attachment %s: file must be a photo, video or audio", a.FileKey)
+		}
+
+		row := base
+		row.FileType = fileType
+		row.ObjectKey = evidence.ObjectKey
+		row.ContentType = evidence.ContentType
+		row.Size = evidence.Size
+		row.Caption = a.Caption
+		row.SortOrder = i
+		if a.SortOrder != nil {
+			row.SortOrder = *a.SortOrder
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// attachmentResponse = attachment di response submit dan raw_payload
+type attachmentResponse struct {
+	FileType  string `json:"file_type"`
+	ObjectKey string `json:"object_key"`
+	Caption   string `json:"caption,omitempty"`
+	SortOrder int    `json:"sort_order"`
+}
+
+func attachmentResponses(rows []models.TrxEvidenceAttachment) []attachmentResponse {
+	if len(rows) == 0 {
+		return nil
+	}
+	resp := make([]attachmentResponse, 0, len(rows))
+	for _, r := range rows {
+		resp = append(resp, attachmentResponse{FileType: r.FileType, ObjectKey: r.ObjectKey, Caption: r.Caption, SortOrder: r.SortOrder})
+	}
+	return resp
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"hash"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const evidenceHeadSize = 128 << 10 // cukup untuk sniff MIME & segmen EXIF JPEG
+
+// evidenceHasher menghitung sha256 & size sambil menyimpan awal file (untuk sniff/EXIF)
+type evidenceHasher struct {
+	h    hash.Hash
+	size int64
+	head []byte
+}
+
+func newEvidenceHasher() *evidenceHasher {
+	return &evidenceHasher{h: sha256.New()}
+}
+
+func (e *evidenceHasher) Write(p []byte) (int, error) {
+	e.h.Write(p)
+	e.size += int64(len(p))
+	if room := evidenceHeadSize - len(e.head); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		e.head = append(e.head, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func (e *evidenceHasher) Sum() string {
+	return hex.EncodeToString(e.h.Sum(nil))
+}
+
+// hashEvidenceFile membaca file multipart untuk hash lalu kembali ke awal supaya bisa di-upload
+func hashEvidenceFile(f multipart.File) (*evidenceHasher, error) {
+	hr := newEvidenceHasher()
+	if _, err := io.Copy(hr, f); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return hr, nil
+}
+
+// mimeFamily: audio & video dianggap satu keluarga (m4a sering ter-sniff sebagai video/mp4)
+func mimeFamily(contentType string) string {
+	major, _, _ := strings.Cut(strings.ToLower(contentType), "/")
+	if major == "audio" || major == "video" {
+		return "media"
+	}
+	return major
+}
+
+func contentTypeMismatch(declared, sniffed string) bool {
+	declared = strings.TrimSpace(strings.Split(declared, ";")[0])
+	sniffed = strings.Split(sniffed, ";")[0]
+	if declared == "" || declared == "application/octet-stream" {
+		return false
+	}
+	// sniff tidak yakin → tidak bisa dibandingkan
+	if sniffed == "application/octet-stream" || sniffed == "text/plain" {
+		return false
+	}
+	return mimeFamily(declared) != mimeFamily(sniffed)
+}
+
+func flagEvidence(meta *models.TrxEvidenceMetadata, reason, note string) {
+	meta.Status = models.EvidenceStatusFlagged
+	meta.FlagReason = reason
+	meta.FlagNote = note
+}
+
+// recordEvidenceMetadata mencatat hash, MIME & EXIF evidence. Upload ulang ke key yang sama dengan isi berbeda
+// atau isi yang sama persis dengan evidence lain di-flag. Error hanya di-log, upload tidak digagalkan.
+func recordEvidenceMetadata(companyID, objectKey, declaredType, createdBy string, hr *evidenceHasher) *models.TrxEvidenceMetadata {
+	sum := hr.Sum()
+
+	var existing models.TrxEvidenceMetadata
+	if err := config.DB.Where("company_id = ? AND object_key = ?", companyID, objectKey).First(&existing).Error; err == nil {
+		existing.UploadCount++
+		if existing.SHA256 != sum {
+			// hash asli tetap disimpan sebagai acuan, isi baru dicatat di note
+			flagEvidence(&existing, models.EvidenceFlagReuploaded, fmt.Sprintf("re-uploaded with sha256 %s (%d bytes)", sum, hr.size))
+		}
+		if err := config.DB.Save(&existing).Error; err != nil {
+			log.Printf("evidence metadata %s: %v", objectKey, err)
+		}
+		return &existing
+	}
+
+	meta := models.TrxEvidenceMetadata{
+		CompanyID:           companyID,
+		ObjectKey:           objectKey,
+		SHA256:              sum,
+		Size:                hr.size,
+		MimeType:            http.DetectContentType(hr.head),
+		DeclaredContentType: declaredType,
+		Status:              models.EvidenceStatusOK,
+		UploadCount:         1,
+		CreatedBy:           createdBy,
+	}
+	if exif, err := utils.ParseEXIF(hr.head); err == nil {
+		meta.CaptureTime = exif.CaptureTime
+		meta.GPSLatitude = exif.Latitude
+		meta.GPSLongitude = exif.Longitude
+		meta.CameraMake = exif.Make
+		meta.CameraModel = exif.Model
+	}
+
+	if contentTypeMismatch(declaredType, meta.MimeType) {
+		flagEvidence(&meta, models.EvidenceFlagContentType, fmt.Sprintf("declared %s, content is %s", declaredType, meta.MimeType))
+	}
+
+	var duplicate models.TrxEvidenceMetadata
+	if err := config.DB.Where("company_id = ? AND sha256 = ? AND object_key <> ?", companyID, sum, objectKey).
+		First(&duplicate).Error; err == nil {
+		flagEvidence(&meta, models.EvidenceFlagDuplicate, "same content as "+duplicate.ObjectKey)
+	}
+
+	if err := config.DB.Create(&meta).Error; err != nil {
+		log.Printf("evidence metadata %s: %v", objectKey, err)
+	}
+	return &meta
+}
+
+// verifyEvidenceObject hitung ulang sha256 object di storage dan bandingkan dengan hash tercatat
+func verifyEvidenceObject(c *gin.Context, meta *models.TrxEvidenceMetadata) (bool, error) {
+	backend, _, err := companyStorage(meta.CompanyID)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	meta.LastVerifiedAt = &now
+
+	body, _, err := backend.Get(c.Request.Context(), meta.ObjectKey)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			return false, err
+		}
+		flagEvidence(meta, models.EvidenceFlagMissing, "object not found in storage")
+		return false, config.DB.Save(meta).Error
+	}
+	defer body.Close()
+
+	hr := newEvidenceHasher()
+	if _, err := io.Copy(hr, body); err != nil {
+		return false, err
+	}
+
+	match := hr.Sum() == meta.SHA256
+	if !match {
+		flagEvidence(meta, models.EvidenceFlagHashMismatch, fmt.Sprintf("storage has sha256 %s (%d bytes)", hr.Sum(), hr.size))
+	}
+	return match, config.DB.Save(meta).Error
+}
+
+// GET /evidence-metadata?object_key=&status=&flag_reason=
+func GetEvidenceMetadata(c *gin.Context) {
+	query := config.DB.Model(&models.TrxEvidenceMetadata{}).Scopes(utils.CompanyScope(c))
+
+	if objectKey := c.Query("object_key"); objectKey != "" {
+		query = query.Where("object_key = ?", objectKey)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if reason := c.Query("flag_reason"); reason != "" {
+		query = query.Where("flag_reason = ?", reason)
+	}
+
+	var list []models.TrxEvidenceMetadata
+	if err := query.Order("id DESC").Limit(500).Find(&list).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Evidence metadata", list)
+}
+
+// POST /evidence-metadata/:id/verify → cek object di storage masih sama dengan hash saat diterima
+func VerifyEvidenceMetadata(c *gin.Context) {
+	var meta models.TrxEvidenceMetadata
+	if err := utils.TenantDB(c).First(&meta, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Evidence metadata not found")
+		return
+	}
+
+	match, err := verifyEvidenceObject(c, &meta)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Evidence verified", gin.H{
+		"match":    match,
+		"metadata": meta,
+	})
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	fileURLDefaultTTL = 15 * time.Minute
+	fileURLMaxTTL     = 24 * time.Hour // embed Superset butuh URL yang hidup selama dashboard dibuka
+	fileProxyMaxAge   = 5 * time.Minute
+)
+
+var errFileRange = errors.New("invalid range")
+
+// signFileToken = HMAC atas company, object key dan expiry (URL proxy /e2-signed-company)
+func signFileToken(companyID, objectKey string, exp int64) string {
+	mac := hmac.New(sha256.New, storageSigningKey())
+	fmt.Fprintf(mac, "file\n%s\n%s\n%d", companyID, objectKey, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyFileToken(companyID, objectKey string, exp int64, sig string) bool {
+	if time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signFileToken(companyID, objectKey, exp)), []byte(sig))
+}
+
+// SignedFileURL membuat URL proxy bertanda tangan untuk satu object milik company
+func SignedFileURL(companyID, objectKey string, ttl time.Duration) (string, time.Time) {
+	expiresAt := time.Now().Add(ttl)
+	exp := expiresAt.Unix()
+
+	parts := strings.Split(objectKey, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", signFileToken(companyID, objectKey, exp))
+
+	base := strings.TrimRight(os.Getenv("STORAGE_PUBLIC_URL"), "/")
+	return base + "/api/e2-signed-company/" + url.PathEscape(companyID) + "/" + strings.Join(parts, "/") + "?" + q.Encode(), expiresAt
+}
+
+// objectBelongsToCompany: object baru selalu di bawah Assurance/<company_id>/, object lama dicek dari tabel yang mereferensikannya
+func objectBelongsToCompany(companyID, objectKey string) bool {
+	if strings.HasPrefix(objectKey, "Assurance/"+companyID+"/") {
+		return true
+	}
+
+	var found bool
+	config.DB.Raw(`SELECT
+		EXISTS (SELECT 1 FROM mstr_company WHERE company_id = @company AND image_url = @key) OR
+		EXISTS (SELECT 1 FROM mstr_inspection WHERE company_id = @company AND image_url = @key) OR
+		EXISTS (SELECT 1 FROM trx_inspection WHERE company_id = @company AND image_url = @key) OR
+		EXISTS (SELECT 1 FROM mstr_answer_detail d JOIN mstr_answer a ON a.id = d.master_answer_id
+			WHERE a.company_id = @company AND d.answer_file = @key)`,
+		map[string]interface{}{"company": companyID, "key": objectKey}).
+		Scan(&found)
+	return found
+}
+
+// GET /file-url/*objectKey?ttl=<menit> → URL proxy bertanda tangan (dipakai frontend & embed Superset)
+func CreateFileURL(c *gin.Context) {
+	objectKey := strings.TrimPrefix(c.Param("objectKey"), "/")
+	if objectKey == "" {
+		utils.JSONError(c, http.StatusBadRequest, "objectKey is required")
+		return
+	}
+
+	companyID, ok := utils.TenantCompanyID(c)
+	if !ok {
+		// platform admin: company dipilih lewat query (default company sendiri)
+		companyID = c.DefaultQuery("company_id", c.GetString("company_id"))
+	}
+	if companyID == "" {
+		utils.JSONError(c, http.StatusBadRequest, "company_id is required")
+		return
+	}
+	if !objectBelongsToCompany(companyID, objectKey) {
+		utils.JSONError(c, http.StatusNotFound, "File not found")
+		return
+	}
+
+	ttl := fileURLDefaultTTL
+	if v := c.Query("ttl"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			utils.JSONError(c, http.StatusBadRequest, "ttl must be a positive number of minutes")
+			return
+		}
+		ttl = time.Duration(minutes) * time.Minute
+		if ttl > fileURLMaxTTL {
+			ttl = fileURLMaxTTL
+		}
+	}
+
+	signedURL, expiresAt := SignedFileURL(companyID, objectKey, ttl)
+	utils.JSONSuccess(c, "Signed file URL", gin.H{
+		"url":        signedURL,
+		"expires_at": expiresAt,
+	})
+}
+
+// parseByteRange mendukung satu range (bytes=a-b, bytes=a-, bytes=-n). ok=false → kirim file penuh.
+func parseByteRange(header string, size int64) (start, length int64, ok bool, err error) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, 0, false, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	if strings.Contains(spec, ",") {
+		return 0, 0, false, nil // multi-range tidak didukung, kirim penuh (boleh menurut RFC 7233)
+	}
+	dash := strings.Index(spec, "-")
+	if dash < 0 {
+		return 0, 0, false, errFileRange
+	}
+	from, to := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+	if from == "" {
+		n, perr := strconv.ParseInt(to, 10, 64)
+		if perr != nil || n <= 0 {
+			return 0, 0, false, errFileRange
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true, nil
+	}
+
+	start, perr := strconv.ParseInt(from, 10, 64)
+	if perr != nil || start < 0 || start >= size {
+		return 0, 0, false, errFileRange
+	}
+	end := size - 1
+	if to != "" {
+		e, perr := strconv.ParseInt(to, 10, 64)
+		if perr != nil || e < start {
+			return 0, 0, false, errFileRange
+		}
+		if e < end {
+			end = e
+		}
+	}
+	return start, end - start + 1, true, nil
+}
+
+// notModified cek If-None-Match / If-Modified-Since
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveStorageObject stream object dari backend company ke response, dengan Range, ETag/Last-Modified dan cache header.
+// ?size=thumb|preview mengirim versi kecil gambar jika sudah tersedia.
+func serveStorageObject(c *gin.Context, company *models.MstrCompany, objectKey string, maxAge time.Duration) {
+	backend, err := newCompanyStorage(company)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to open storage: %s", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	if size := c.Query("size"); size != "" {
+		if _, ok := utils.ImageVariantSizes[size]; !ok {
+			c.String(http.StatusBadRequest, "size must be thumb or preview")
+			return
+		}
+		objectKey = imageVariantObject(ctx, backend, company.CompanyID, objectKey, size)
+	}
+
+	info, err := backend.Head(ctx, objectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.String(http.StatusNotFound, "File not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Failed to fetch file from storage: %s", err.Error())
+		return
+	}
+
+	etag := `"` + info.ETag + `"`
+	// object key selalu unik per upload, jadi isi tidak berubah → aman di-cache selama URL berlaku
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	c.Header("Accept-Ranges", "bytes")
+	if info.ETag != "" {
+		c.Header("ETag", etag)
+	}
+	if !info.LastModified.IsZero() {
+		c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c, etag, info.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+
+	start, length, partial, err := parseByteRange(c.GetHeader("Range"), info.Size)
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != etag {
+		partial, err = false, nil // object berubah sejak range sebelumnya → kirim penuh
+	}
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.Status(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	status := http.StatusOK
+	if partial {
+		status = http.StatusPartialContent
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, info.Size))
+	} else {
+		start, length = 0, info.Size
+	}
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+
+	var body io.ReadCloser
+	if partial {
+		body, err = backend.GetRange(ctx, objectKey, start, length)
+	} else {
+		body, _, err = backend.Get(ctx, objectKey)
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to fetch file from storage: %s", err.Error())
+		return
+	}
+	defer body.Close()
+
+	c.Status(status)
+	io.Copy(c.Writer, body)
+}
@@ -37,7 +37,7 @@ func UpdateGroupByID(c *gin.Context) {
 
 	// Cek apakah group ada untuk company tersebut
 	var group models.MstrGroup
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Where("id = ?", DeviceID).
 		First(&group).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Group not found")
@@ -72,6 +72,11 @@ func DeleteGroupByID(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrGroup{}, GroupID).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrGroup{}).
 		Where("id = ?", GroupID).
@@ -106,13 +111,7 @@ func GetFilteredGroups(c *gin.Context) {
 	//CompanyID := c.Query("company_id")
 
 	var groups []models.MstrGroup
-	query := config.DB.Model(&models.MstrGroup{})
-
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query := config.DB.Model(&models.MstrGroup{}).Scopes(utils.CompanyScope(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
@@ -1,21 +1,18 @@
 package controllers
 
 import (
-	"bytes"
 	"fmt"
 	"go-api/config"
 	"go-api/models"
+	"go-api/storage"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -26,6 +23,8 @@ type E2Config struct {
 	BucketName string
 	AccessKey  string
 	SecretKey  string
+	Driver     string
+	CompanyID  string
 }
 
 func GetCompanyE2Config(c *gin.Context) (*models.MstrCompany, error) {
@@ -43,72 +42,57 @@ func GetCompanyE2Config(c *gin.Context) (*models.MstrCompany, error) {
 		return nil, fmt.Errorf("company not found")
 	}
 
-	fmt.Println("[DEBUG] E2 Company Config |",
-		"CompanyID="+company.CompanyID,
-		"Name="+company.CompanyName,
-		"Endpoint="+company.E2Endpoint,
-		"Region="+company.E2Region,
-		"Bucket="+company.E2BucketName,
-		"AccessKey="+company.E2AccessKey,
-		"SecretKey="+company.E2SecretKey,
-	)
-
 	return &company, nil
 }
 
-// UploadFileToE2 uploads the file to E2 private bucket and returns the object key
+// UploadFileToE2 uploads the file to the company storage backend (E2 private bucket / local) and returns the object key
 func UploadFileToE2(c *gin.Context, file multipart.File, fileName string, contentType string, folder string, override *E2Config) (string, error) {
 
-	var cfg E2Config
+	var backend storage.Backend
+	var companyID string
 
 	// Jika super-admin memberikan config manual → pakai config itu
 	if override != nil {
-		cfg = *override
+		b, err := storage.New(override.storageConfig())
+		if err != nil {
+			return "", err
+		}
+		backend = b
+		companyID = override.CompanyID
 	} else {
 		// Default: ambil dari company_id
 		company, err := GetCompanyE2Config(c)
 		if err != nil {
 			return "", err
 		}
-
-		cfg = E2Config{
-			Endpoint:   company.E2Endpoint,
-			Region:     company.E2Region,
-			BucketName: company.E2BucketName,
-			AccessKey:  company.E2AccessKey,
-			SecretKey:  company.E2SecretKey,
+		b, err := newCompanyStorage(company)
+		if err != nil {
+			return "", err
 		}
+		backend = b
+		companyID = company.CompanyID
 	}
 
-	sess, err := session.NewSession(&aws.Config{
-		Region:           aws.String(cfg.Region),
-		Credentials:      credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
-		Endpoint:         aws.String(cfg.Endpoint),
-		S3ForcePathStyle: aws.Bool(true),
-	})
+	// ukuran file untuk quota & usage (multipart.File selalu bisa di-seek)
+	size, err := file.Seek(0, io.SeekEnd)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AWS session: %v", err)
+		return "", err
 	}
-
-	s3Client := s3.New(sess)
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(file); err != nil {
-		return "", fmt.Errorf("failed to read file: %v", err)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := checkStorageQuota(companyID, size); err != nil {
+		return "", err
 	}
 
 	objectKey := fmt.Sprintf("%s/%s", folder, fileName)
 
-	_, err = s3Client.PutObject(&s3.PutObjectInput{
-		Bucket:      aws.String(cfg.BucketName),
-		Key:         aws.String(objectKey),
-		Body:        bytes.NewReader(buf.Bytes()),
-		ContentType: aws.String(contentType),
-		ACL:         aws.String("private"),
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload to E2: %v", err)
+	// multipart.File di-stream langsung, tidak di-buffer ke memory
+	if err := backend.Put(c.Request.Context(), objectKey, file, size, contentType); err != nil {
+		return "", fmt.Errorf("failed to upload to storage: %v", err)
 	}
+	recordStorageUsage(companyID, usageModuleFromKey(objectKey), size, 1)
+	queueImageVariants(backend, companyID, objectKey, contentType)
 
 	return objectKey, nil
 }
@@ -121,29 +105,12 @@ func GeneratePresignedURL(c *gin.Context, objectKey string) (string, error) {
 		return "", err
 	}
 
-	sess, err := session.NewSession(&aws.Config{
-		Region:           aws.String(company.E2Region),
-		Credentials:      credentials.NewStaticCredentials(company.E2AccessKey, company.E2SecretKey, ""),
-		Endpoint:         aws.String(company.E2Endpoint),
-		S3ForcePathStyle: aws.Bool(true),
-	})
+	backend, err := newCompanyStorage(company)
 	if err != nil {
 		return "", err
 	}
 
-	s3Client := s3.New(sess)
-
-	req, _ := s3Client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket: aws.String(company.E2BucketName),
-		Key:    aws.String(objectKey),
-	})
-
-	presignedURL, err := req.Presign(15 * time.Minute) // URL berlaku 15 menit
-	if err != nil {
-		return "", err
-	}
-
-	return presignedURL, nil
+	return backend.PresignGet(c.Request.Context(), objectKey, 15*time.Minute) // URL berlaku 15 menit
 }
 
 // Endpoint untuk Superset / frontend mengakses E2 IDrive
@@ -157,27 +124,16 @@ func GetSignedFileURL(c *gin.Context) {
 		return
 	}
 
-	// Generate signed URL
-	signedURL, err := GeneratePresignedURL(c, objectKey)
+	company, err := GetCompanyE2Config(c)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to generate signed URL: %s", err.Error())
+		c.String(http.StatusInternalServerError, "Failed to load storage config: %s", err.Error())
 		return
 	}
-
-	// Get file from S3 / E2
-	resp, err := http.Get(signedURL)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to fetch file from E2: %s", err.Error())
+	if !objectBelongsToCompany(company.CompanyID, objectKey) {
+		c.String(http.StatusNotFound, "File not found")
 		return
 	}
-	defer resp.Body.Close()
-
-	// Forward original content type (image/png, image/jpeg, etc)
-	c.Header("Content-Type", resp.Header.Get("Content-Type"))
-	c.Header("Cache-Control", "no-cache")
-
-	// Stream the file bytes
-	io.Copy(c.Writer, resp.Body)
+	serveStorageObject(c, company, objectKey, fileProxyMaxAge)
 }
 
 func GenerateE2ObjectKey(c *gin.Context, module, originalFilename string) string {
@@ -208,31 +164,11 @@ func GenerateE2ObjectKey(c *gin.Context, module, originalFilename string) string
 
 func GeneratePresignedURLWithCompanyID(c *gin.Context, companyID, objectKey string) (string, error) {
 	// Ambil credential per-company
-	var company models.MstrCompany
-	err := config.DB.WithContext(c.Request.Context()).
-		Where("company_id = ?", companyID).
-		First(&company).Error
-	if err != nil {
-		return "", fmt.Errorf("company not found")
-	}
-
-	sess, err := session.NewSession(&aws.Config{
-		Region:           aws.String(company.E2Region),
-		Credentials:      credentials.NewStaticCredentials(company.E2AccessKey, company.E2SecretKey, ""),
-		Endpoint:         aws.String(company.E2Endpoint),
-		S3ForcePathStyle: aws.Bool(true),
-	})
+	backend, _, err := companyStorage(companyID)
 	if err != nil {
 		return "", err
 	}
-
-	s3Client := s3.New(sess)
-	req, _ := s3Client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket: aws.String(company.E2BucketName),
-		Key:    aws.String(objectKey),
-	})
-
-	return req.Presign(15 * time.Minute)
+	return backend.PresignGet(c.Request.Context(), objectKey, 15*time.Minute)
 }
 
 func GetSignedFileURLWithCompany(c *gin.Context) {
@@ -249,22 +185,17 @@ func GetSignedFileURLWithCompany(c *gin.Context) {
 		return
 	}
 
-	// Generate signed URL
-	signedURL, err := GeneratePresignedURLWithCompanyID(c, companyID, objectKey)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to generate signed URL: %s", err.Error())
+	// URL wajib ditandatangani (lihat CreateFileURL), token terikat ke company + object
+	exp, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || !verifyFileToken(companyID, objectKey, exp, c.Query("sig")) {
+		c.String(http.StatusForbidden, "Invalid or expired file token")
 		return
 	}
 
-	// Fetch file dari E2/S3
-	resp, err := http.Get(signedURL)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to fetch file from E2: %s", err.Error())
+	var company models.MstrCompany
+	if err := config.DB.WithContext(c.Request.Context()).Where("company_id = ?", companyID).First(&company).Error; err != nil {
+		c.String(http.StatusNotFound, "File not found")
 		return
 	}
-	defer resp.Body.Close()
-
-	c.Header("Content-Type", resp.Header.Get("Content-Type"))
-	c.Header("Cache-Control", "no-cache")
-	io.Copy(c.Writer, resp.Body)
+	serveStorageObject(c, &company, objectKey, time.Until(time.Unix(exp, 0)))
 }
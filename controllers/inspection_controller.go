@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // SAM UPDATE
@@ -31,6 +33,17 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 		utils.JSONError(c, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
+	if err := checkInspectionDetailRules(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// cek apakah detail ada (dan milik company user)
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		Select("id").First(&models.MstrInspectionDetail{}, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Detail not found")
+		return
+	}
 
 	tx := config.DB.Begin()
 	if tx.Error != nil {
@@ -38,7 +51,6 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 		return
 	}
 
-	// cek apakah detail ada
 	var detail models.MstrInspectionDetail
 	if err := tx.First(&detail, id).Error; err != nil {
 		tx.Rollback()
@@ -54,6 +66,15 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 	detail.RequiredCoordinate = req.RequiredCoordinate
 	detail.SendNow = req.SendNow
 	detail.TypeTriggerID = req.TypeTriggerID
+	detail.MinEvidence = req.MinEvidence
+	detail.MaxEvidence = req.MaxEvidence
+	detail.Geometry = req.Geometry
+	if err := checkInspectionPage(tx, detail.IdMstrInspection, req.IdPage); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	detail.IdPage = req.IdPage
 	detail.UpdatedBy = username.(string)
 	if err := tx.Save(&detail).Error; err != nil {
 		tx.Rollback()
@@ -67,10 +88,14 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 		var q models.MstrInspectionQuestion
 
 		if qd.ID != 0 {
-			// update existing
-			if err := tx.First(&q, qd.ID).Error; err == nil {
+			// update existing (hanya question milik detail ini)
+			if err := tx.Where("inspection_detail_id = ?", detail.Id).First(&q, qd.ID).Error; err == nil {
 				q.Text = qd.Text
 				q.Type = qd.Type
+				q.ValidationRules = qd.ValidationRules
+				q.DisplayCondition = qd.DisplayCondition
+				q.Weight = qd.Weight
+				q.IsCritical = qd.IsCritical
 				q.UpdatedBy = username.(string)
 				tx.Save(&q)
 			}
@@ -80,6 +105,10 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 				InspectionDetailID: detail.Id,
 				Text:               qd.Text,
 				Type:               qd.Type,
+				ValidationRules:    qd.ValidationRules,
+				DisplayCondition:   qd.DisplayCondition,
+				Weight:             qd.Weight,
+				IsCritical:         qd.IsCritical,
 				CreatedBy:          username.(string),
 				UpdatedBy:          username.(string),
 			}
@@ -94,10 +123,11 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 			var o models.MstrInspectionQuestionOption
 
 			if od.ID != 0 {
-				if err := tx.First(&o, od.ID).Error; err == nil {
+				if err := tx.Where("inspection_question_id = ?", q.ID).First(&o, od.ID).Error; err == nil {
 					o.Label = od.Label
 					o.Text = od.Text
 					o.IsCorrect = od.IsCorrect
+					o.Score = od.Score
 					o.UpdatedBy = username.(string)
 					tx.Save(&o)
 				}
@@ -107,6 +137,7 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 					Label:                od.Label,
 					Text:                 od.Text,
 					IsCorrect:            od.IsCorrect,
+					Score:                od.Score,
 					CreatedBy:            username.(string),
 					UpdatedBy:            username.(string),
 				}
@@ -135,6 +166,13 @@ func UpdateMstrInspectionDetailByID(c *gin.Context) {
 			Delete(&models.MstrInspectionQuestion{})
 	}
 
+	// perubahan masuk ke draft, versi published tidak tersentuh
+	if err := markInspectionDraft(tx, detail.IdMstrInspection, username.(string)); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, "Failed to commit transaction: "+err.Error())
 		return
@@ -161,6 +199,10 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 		utils.JSONError(c, http.StatusBadRequest, "Invalid JSON: "+err.Error())
 		return
 	}
+	if err := checkInspectionDetailRules(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Pastikan ada inspection_id (parent)
 	if req.IdMstrInspection == 0 {
@@ -168,6 +210,15 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 		return
 	}
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrInspection{}, req.IdMstrInspection).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+	if err := checkInspectionPage(config.DB, req.IdMstrInspection, req.IdPage); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	tx := config.DB.Begin()
 	if tx.Error != nil {
 		utils.JSONError(c, http.StatusInternalServerError, "Failed to start transaction")
@@ -189,6 +240,10 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 		RequiredCoordinate: req.RequiredCoordinate,
 		SendNow:            req.SendNow,
 		TypeTriggerID:      req.TypeTriggerID,
+		MinEvidence:        req.MinEvidence,
+		MaxEvidence:        req.MaxEvidence,
+		Geometry:           req.Geometry,
+		IdPage:             req.IdPage,
 		CreatedBy:          username.(string),
 		UpdatedBy:          username.(string),
 	}
@@ -200,11 +255,17 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 	}
 
 	// ===== COPY QUESTIONS & OPTIONS =====
+	questionIDs := make(map[uint]uint, len(req.Questions)) // ID lama → ID baru untuk display_condition
+	newQuestions := make([]models.MstrInspectionQuestion, 0, len(req.Questions))
 	for _, qd := range req.Questions {
 		newQ := models.MstrInspectionQuestion{
 			InspectionDetailID: newDetail.Id,
 			Text:               qd.Text,
 			Type:               qd.Type,
+			ValidationRules:    qd.ValidationRules,
+			DisplayCondition:   qd.DisplayCondition,
+			Weight:             qd.Weight,
+			IsCritical:         qd.IsCritical,
 			CreatedBy:          username.(string),
 			UpdatedBy:          username.(string),
 		}
@@ -213,6 +274,10 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 			utils.JSONError(c, http.StatusInternalServerError, "Failed to copy question: "+err.Error())
 			return
 		}
+		if qd.ID != 0 {
+			questionIDs[qd.ID] = newQ.ID
+		}
+		newQuestions = append(newQuestions, newQ)
 
 		for _, od := range qd.Options {
 			newO := models.MstrInspectionQuestionOption{
@@ -220,6 +285,7 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 				Label:                od.Label,
 				Text:                 od.Text,
 				IsCorrect:            od.IsCorrect,
+				Score:                od.Score,
 				CreatedBy:            username.(string),
 				UpdatedBy:            username.(string),
 			}
@@ -230,6 +296,17 @@ func CreateMstrInspectionDetail(c *gin.Context) {
 			}
 		}
 	}
+	if err := remapInspectionConditions(tx, newQuestions, questionIDs); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to copy display conditions: "+err.Error())
+		return
+	}
+
+	if err := markInspectionDraft(tx, newDetail.IdMstrInspection, username.(string)); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, "Failed to commit transaction: "+err.Error())
@@ -251,25 +328,94 @@ func UpdateInspectionPosition(c *gin.Context) {
 		return
 	}
 
+	var detail models.MstrInspectionDetail
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		Select("id", "geometry").First(&detail, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Detail not found")
+		return
+	}
+
+	// Hanya untuk SAM titik; area (rect / polygon) diubah lewat endpoint geometry.
+	// Geometry point lama dibuang supaya X/Y kembali jadi posisi titik.
+	geometry, err := utils.ParseSamGeometry(detail.Geometry)
+	if err == nil && geometry != nil && geometry.Type != utils.GeometryPoint {
+		utils.JSONError(c, http.StatusConflict, "SAM has "+geometry.Type+" geometry, use the geometry endpoint to move it")
+		return
+	}
+
 	if err := config.DB.Model(&models.MstrInspectionDetail{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"x": req.X,
-			"y": req.Y,
+			"x":        req.X,
+			"y":        req.Y,
+			"geometry": nil,
 		}).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, "Failed to update coordinates: "+err.Error())
 		return
 	}
 
+	if err := markInspectionDraftByDetail(config.DB, id, c.GetString("username")); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+
 	utils.JSONSuccess(c, "Coordinate updated successfully", nil)
 }
 
+// PATCH /mstr-inspection-geometry/:id → ubah area SAM: {"geometry": {"type": "point|rect|polygon", "points": [{"x","y"}]}}
+// Koordinat ternormalisasi 0..1, geometry null = kembali ke titik X/Y.
+func UpdateInspectionGeometry(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		Geometry datatypes.JSON `json:"geometry"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	geometry, err := utils.NormalizeSamGeometry(req.Geometry)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		Select("id").First(&models.MstrInspectionDetail{}, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Detail not found")
+		return
+	}
+
+	if err := config.DB.Model(&models.MstrInspectionDetail{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"geometry":   datatypes.JSON(geometry), // kosong → NULL
+			"updated_by": c.GetString("username"),
+		}).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update geometry: "+err.Error())
+		return
+	}
+
+	if err := markInspectionDraftByDetail(config.DB, id, c.GetString("username")); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Geometry updated successfully", gin.H{"geometry": datatypes.JSON(geometry)})
+}
+
 // SAM DELETE
 func DeleteMstrInspectionDetailByID(c *gin.Context) {
 	id := c.Param("id")
 
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		Select("id").First(&models.MstrInspectionDetail{}, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "SAM not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrInspectionDetail{}).
 		Where("id = ?", id).
@@ -282,6 +428,12 @@ func DeleteMstrInspectionDetailByID(c *gin.Context) {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+
+	// SAM tetap ada di versi published sampai draft di-publish ulang
+	if err := markInspectionDraftByDetail(config.DB, id, deletedBy); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
 	utils.JSONSuccess(c, "SAM deleted", nil)
 }
 
@@ -289,11 +441,36 @@ func DeleteMstrInspectionDetailByID(c *gin.Context) {
 func CreateMstrInspection(c *gin.Context) {
 	name := c.PostForm("name_inspection")
 	detailJSON := c.PostForm("details")
+	publishNow := c.PostForm("publish") == "true" || c.PostForm("publish") == "1"
+
+	var passThreshold float64
+	if v := c.PostForm("pass_threshold"); v != "" {
+		var err error
+		if passThreshold, err = strconv.ParseFloat(v, 64); err != nil || !checkPassThreshold(passThreshold) {
+			utils.JSONError(c, http.StatusBadRequest, "pass_threshold must be a number between 0 and 100")
+			return
+		}
+	}
 
 	// Ambil info user
 	userCompanyID := c.GetString("company_id")
 	username := c.GetString("username")
 
+	// === Validasi details sebelum gambar di-upload (payload salah tidak meninggalkan file yatim) ===
+	var details []models.MstrInspectionDetail
+	if detailJSON != "" {
+		if err := json.Unmarshal([]byte(detailJSON), &details); err != nil {
+			utils.JSONError(c, http.StatusBadRequest, "Invalid details format: "+err.Error())
+			return
+		}
+		for i := range details {
+			if err := checkInspectionDetailRules(&details[i]); err != nil {
+				utils.JSONError(c, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+
 	// === Upload file ===
 	fileHeader, err := c.FormFile("image")
 	if err != nil {
@@ -311,6 +488,9 @@ func CreateMstrInspection(c *gin.Context) {
 	fileKey := GenerateE2ObjectKey(c, "Master-Assurance", fileHeader.Filename)
 	objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+userCompanyID, nil)
 	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
 		utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
 		return
 	}
@@ -332,11 +512,14 @@ func CreateMstrInspection(c *gin.Context) {
 
 	// === Simpan master inspection ===
 	inspection := models.MstrInspection{
-		NameInspection: name,
-		ImageUrl:       objectKey,
-		CompanyID:      userCompanyID,
-		CreatedBy:      username,
-		UpdatedBy:      username,
+		NameInspection:  name,
+		ImageUrl:        objectKey,
+		CompanyID:       userCompanyID,
+		Status:          models.InspectionStatusDraft,
+		HasDraftChanges: true,
+		PassThreshold:   passThreshold,
+		CreatedBy:       username,
+		UpdatedBy:       username,
 	}
 
 	if err := tx.Create(&inspection).Error; err != nil {
@@ -346,71 +529,91 @@ func CreateMstrInspection(c *gin.Context) {
 	}
 
 	// === Proses details + questions + options ===
-	if detailJSON != "" {
-		var details []models.MstrInspectionDetail
-		if err := json.Unmarshal([]byte(detailJSON), &details); err != nil {
+	for i := range details {
+		// reset ID detail
+		detail := models.MstrInspectionDetail{
+			IdMstrInspection:   inspection.Id,
+			NameCoordinate:     details[i].NameCoordinate,
+			X:                  details[i].X,
+			Y:                  details[i].Y,
+			TutorialCoordinate: details[i].TutorialCoordinate,
+			RequiredCoordinate: details[i].RequiredCoordinate,
+			SendNow:            details[i].SendNow,
+			TypeTriggerID:      details[i].TypeTriggerID,
+			MinEvidence:        details[i].MinEvidence,
+			MaxEvidence:        details[i].MaxEvidence,
+			Geometry:           details[i].Geometry,
+			CreatedBy:          username,
+			UpdatedBy:          username,
+		}
+
+		// Simpan detail
+		if err := tx.Create(&detail).Error; err != nil {
 			tx.Rollback()
-			utils.JSONError(c, http.StatusBadRequest, "Invalid details format: "+err.Error())
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to save detail: "+err.Error())
 			return
 		}
 
-		for i := range details {
-			// reset ID detail
-			detail := models.MstrInspectionDetail{
-				IdMstrInspection:   inspection.Id,
-				NameCoordinate:     details[i].NameCoordinate,
-				X:                  details[i].X,
-				Y:                  details[i].Y,
-				TutorialCoordinate: details[i].TutorialCoordinate,
-				RequiredCoordinate: details[i].RequiredCoordinate,
-				SendNow:            details[i].SendNow,
-				TypeTriggerID:      details[i].TypeTriggerID,
+		// === Simpan questions ===
+		// id question di payload = ID sementara dari client, dipakai display_condition
+		questionIDs := make(map[uint]uint, len(details[i].Questions))
+		newQuestions := make([]models.MstrInspectionQuestion, 0, len(details[i].Questions))
+		for j := range details[i].Questions {
+			q := models.MstrInspectionQuestion{
+				InspectionDetailID: detail.Id,
+				Text:               details[i].Questions[j].Text,
+				Type:               details[i].Questions[j].Type,
+				ValidationRules:    details[i].Questions[j].ValidationRules,
+				DisplayCondition:   details[i].Questions[j].DisplayCondition,
+				Weight:             details[i].Questions[j].Weight,
+				IsCritical:         details[i].Questions[j].IsCritical,
 				CreatedBy:          username,
 				UpdatedBy:          username,
 			}
 
-			// Simpan detail
-			if err := tx.Create(&detail).Error; err != nil {
+			if err := tx.Create(&q).Error; err != nil {
 				tx.Rollback()
-				utils.JSONError(c, http.StatusInternalServerError, "Failed to save detail: "+err.Error())
+				utils.JSONError(c, http.StatusInternalServerError, "Failed to save question: "+err.Error())
 				return
 			}
+			if details[i].Questions[j].ID != 0 {
+				questionIDs[details[i].Questions[j].ID] = q.ID
+			}
+			newQuestions = append(newQuestions, q)
 
-			// === Simpan questions ===
-			for j := range details[i].Questions {
-				q := models.MstrInspectionQuestion{
-					InspectionDetailID: detail.Id,
-					Text:               details[i].Questions[j].Text,
-					Type:               details[i].Questions[j].Type,
-					CreatedBy:          username,
-					UpdatedBy:          username,
+			// === Simpan options ===
+			for k := range details[i].Questions[j].Options {
+				o := models.MstrInspectionQuestionOption{
+					InspectionQuestionID: q.ID,
+					Label:                details[i].Questions[j].Options[k].Label,
+					Text:                 details[i].Questions[j].Options[k].Text,
+					IsCorrect:            details[i].Questions[j].Options[k].IsCorrect,
+					Score:                details[i].Questions[j].Options[k].Score,
+					CreatedBy:            username,
+					UpdatedBy:            username,
 				}
 
-				if err := tx.Create(&q).Error; err != nil {
+				if err := tx.Create(&o).Error; err != nil {
 					tx.Rollback()
-					utils.JSONError(c, http.StatusInternalServerError, "Failed to save question: "+err.Error())
+					utils.JSONError(c, http.StatusInternalServerError, "Failed to save option: "+err.Error())
 					return
 				}
-
-				// === Simpan options ===
-				for k := range details[i].Questions[j].Options {
-					o := models.MstrInspectionQuestionOption{
-						InspectionQuestionID: q.ID,
-						Label:                details[i].Questions[j].Options[k].Label,
-						Text:                 details[i].Questions[j].Options[k].Text,
-						IsCorrect:            details[i].Questions[j].Options[k].IsCorrect,
-						CreatedBy:            username,
-						UpdatedBy:            username,
-					}
-
-					if err := tx.Create(&o).Error; err != nil {
-						tx.Rollback()
-						utils.JSONError(c, http.StatusInternalServerError, "Failed to save option: "+err.Error())
-						return
-					}
-				}
 			}
 		}
+		if err := remapInspectionConditions(tx, newQuestions, questionIDs); err != nil {
+			tx.Rollback()
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to save display conditions: "+err.Error())
+			return
+		}
+	}
+
+	// === Publish langsung jika diminta ===
+	if publishNow {
+		if _, err := publishInspection(tx, inspection.Id, username, ""); err != nil {
+			tx.Rollback()
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to publish assurance: "+err.Error())
+			return
+		}
 	}
 
 	// === Commit transaksi ===
@@ -437,6 +640,11 @@ func DeleteMstrInspectionByID(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrInspection{}, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrInspection{}).
 		Where("id = ?", id).
@@ -460,13 +668,9 @@ func GetFilteredInspections(c *gin.Context) {
 	idInspection := c.Query("id_inspection")
 
 	var inspections []models.MstrInspection
-	query := config.DB.Preload("Details.Questions.Options")
-
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query := config.DB.Preload("Details.Questions.Options").
+		Preload("Pages", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC, id ASC") }).
+		Scopes(utils.CompanyScope(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
@@ -499,7 +703,7 @@ func UpdateMstrInspectionByID_X(c *gin.Context) {
 	id := c.Param("id")
 
 	var inspection models.MstrInspection
-	if err := config.DB.Preload("Details.Questions.Options").
+	if err := utils.TenantDB(c).Preload("Details.Questions.Options").
 		First(&inspection, id).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Inspection not found")
 		return
@@ -675,11 +879,12 @@ func UpdateMstrInspectionByID(c *gin.Context) {
 	username := c.GetString("username")
 
 	// Update langsung tanpa preload
-	result := config.DB.Model(&models.MstrInspection{}).
+	result := utils.TenantDB(c).Model(&models.MstrInspection{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"name_inspection": payload.NameInspection,
-			"updated_by":      username,
+			"name_inspection":   payload.NameInspection,
+			"has_draft_changes": true,
+			"updated_by":        username,
 			//"updated_at":      time.Now(),
 		})
 
@@ -696,6 +901,41 @@ func UpdateMstrInspectionByID(c *gin.Context) {
 	utils.JSONSuccess(c, "Assurance name updated successfully", nil)
 }
 
+// ASSURANCE MASTER PASS THRESHOLD (berlaku untuk submit setelah publish berikutnya)
+func UpdateMstrInspectionPassThreshold(c *gin.Context) {
+	id := c.Param("id")
+
+	var payload struct {
+		PassThreshold float64 `json:"pass_threshold"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid payload: "+err.Error())
+		return
+	}
+	if !checkPassThreshold(payload.PassThreshold) {
+		utils.JSONError(c, http.StatusBadRequest, "pass_threshold must be between 0 and 100")
+		return
+	}
+
+	result := utils.TenantDB(c).Model(&models.MstrInspection{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"pass_threshold":    payload.PassThreshold,
+			"has_draft_changes": true,
+			"updated_by":        c.GetString("username"),
+		})
+	if result.Error != nil {
+		utils.JSONError(c, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	utils.JSONSuccess(c, "Assurance pass threshold updated successfully", nil)
+}
+
 // COPY ASSURANCE MASTER
 func CopyMstrInspectionByID(c *gin.Context) {
 	id := c.Param("id")
@@ -706,8 +946,9 @@ func CopyMstrInspectionByID(c *gin.Context) {
 
 	// === Ambil data inspection asli lengkap dengan relasi ===
 	var original models.MstrInspection
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Preload("Details.Questions.Options").
+		Preload("Pages").
 		First(&original, "id = ?", id).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Original inspection not found")
 		return
@@ -722,11 +963,14 @@ func CopyMstrInspectionByID(c *gin.Context) {
 
 	// === Buat record baru dengan nama baru ===
 	newInspection := models.MstrInspection{
-		NameInspection: fmt.Sprintf("%s (Copy)", original.NameInspection),
-		ImageUrl:       original.ImageUrl, // pakai image lama
-		CompanyID:      userCompanyID.(string),
-		CreatedBy:      username.(string),
-		UpdatedBy:      username.(string),
+		NameInspection:  fmt.Sprintf("%s (Copy)", original.NameInspection),
+		ImageUrl:        original.ImageUrl, // pakai image lama
+		CompanyID:       userCompanyID.(string),
+		Status:          models.InspectionStatusDraft, // hasil copy selalu mulai sebagai draft
+		HasDraftChanges: true,
+		PassThreshold:   original.PassThreshold,
+		CreatedBy:       username.(string),
+		UpdatedBy:       username.(string),
 	}
 
 	if err := tx.Create(&newInspection).Error; err != nil {
@@ -735,6 +979,25 @@ func CopyMstrInspectionByID(c *gin.Context) {
 		return
 	}
 
+	// === Salin halaman (gambar dipakai bersama seperti image utama) ===
+	pageIDs := make(map[uint]uint, len(original.Pages))
+	for _, p := range original.Pages {
+		newPage := models.MstrInspectionPage{
+			IdMstrInspection: newInspection.Id,
+			Title:            p.Title,
+			ImageUrl:         p.ImageUrl,
+			SortOrder:        p.SortOrder,
+			CreatedBy:        username.(string),
+			UpdatedBy:        username.(string),
+		}
+		if err := tx.Create(&newPage).Error; err != nil {
+			tx.Rollback()
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to copy page: "+err.Error())
+			return
+		}
+		pageIDs[p.Id] = newPage.Id
+	}
+
 	// === Salin semua detail, question, option ===
 	for _, d := range original.Details {
 		newDetail := models.MstrInspectionDetail{
@@ -746,21 +1009,36 @@ func CopyMstrInspectionByID(c *gin.Context) {
 			RequiredCoordinate: d.RequiredCoordinate,
 			SendNow:            d.SendNow,
 			TypeTriggerID:      d.TypeTriggerID,
+			MinEvidence:        d.MinEvidence,
+			MaxEvidence:        d.MaxEvidence,
+			Geometry:           d.Geometry,
 			CreatedBy:          username.(string),
 			UpdatedBy:          username.(string),
 		}
 
+		if d.IdPage != nil {
+			if newPageID, ok := pageIDs[*d.IdPage]; ok {
+				newDetail.IdPage = &newPageID
+			}
+		}
+
 		if err := tx.Create(&newDetail).Error; err != nil {
 			tx.Rollback()
 			utils.JSONError(c, http.StatusInternalServerError, "Failed to copy detail: "+err.Error())
 			return
 		}
 
+		questionIDs := make(map[uint]uint, len(d.Questions))
+		newQuestions := make([]models.MstrInspectionQuestion, 0, len(d.Questions))
 		for _, q := range d.Questions {
 			newQ := models.MstrInspectionQuestion{
 				InspectionDetailID: newDetail.Id,
 				Text:               q.Text,
 				Type:               q.Type,
+				ValidationRules:    q.ValidationRules,
+				DisplayCondition:   q.DisplayCondition,
+				Weight:             q.Weight,
+				IsCritical:         q.IsCritical,
 				CreatedBy:          username.(string),
 				UpdatedBy:          username.(string),
 			}
@@ -770,6 +1048,8 @@ func CopyMstrInspectionByID(c *gin.Context) {
 				utils.JSONError(c, http.StatusInternalServerError, "Failed to copy question: "+err.Error())
 				return
 			}
+			questionIDs[q.ID] = newQ.ID
+			newQuestions = append(newQuestions, newQ)
 
 			for _, o := range q.Options {
 				newO := models.MstrInspectionQuestionOption{
@@ -777,6 +1057,7 @@ func CopyMstrInspectionByID(c *gin.Context) {
 					Label:                o.Label,
 					Text:                 o.Text,
 					IsCorrect:            o.IsCorrect,
+					Score:                o.Score,
 					CreatedBy:            username.(string),
 					UpdatedBy:            username.(string),
 				}
@@ -788,6 +1069,13 @@ func CopyMstrInspectionByID(c *gin.Context) {
 				}
 			}
 		}
+
+		// display_condition masih menunjuk ID question asli
+		if err := remapInspectionConditions(tx, newQuestions, questionIDs); err != nil {
+			tx.Rollback()
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to copy display conditions: "+err.Error())
+			return
+		}
 	}
 
 	// === Commit ===
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errPageNotInInspection = errors.New("id_page does not belong to this assurance")
+
+// inspectionPages = halaman assurance urut sort_order
+func inspectionPages(db *gorm.DB, inspectionID uint) ([]models.MstrInspectionPage, error) {
+	var pages []models.MstrInspectionPage
+	err := db.Where("id_mstr_inspection = ?", inspectionID).
+		Order("sort_order ASC, id ASC").
+		Find(&pages).Error
+	return pages, err
+}
+
+// checkInspectionPage memastikan halaman SAM milik assurance yang sama (nil = gambar utama)
+func checkInspectionPage(db *gorm.DB, inspectionID uint, pageID *uint) error {
+	if pageID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.MstrInspectionPage{}).
+		Where("id = ? AND id_mstr_inspection = ?", *pageID, inspectionID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errPageNotInInspection
+	}
+	return nil
+}
+
+// uploadPageImage meng-upload gambar halaman lewat alur E2 yang sama dengan gambar utama assurance
+func uploadPageImage(c *gin.Context) (string, error) {
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return "", err
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	fileKey := GenerateE2ObjectKey(c, "Master-Assurance", fileHeader.Filename)
+	return UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+c.GetString("company_id"), nil)
+}
+
+// GET /mstr-inspections/:id/pages
+func GetMstrInspectionPages(c *gin.Context) {
+	var inspection models.MstrInspection
+	if err := utils.TenantDB(c).Select("id").First(&inspection, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	pages, err := inspectionPages(config.DB, inspection.Id)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.JSONSuccess(c, "Assurance pages fetched successfully", pages)
+}
+
+// POST /mstr-inspections/:id/pages (multipart: image, title, sort_order) → tambah halaman di akhir jika sort_order kosong
+func CreateMstrInspectionPage(c *gin.Context) {
+	var inspection models.MstrInspection
+	if err := utils.TenantDB(c).Select("id").First(&inspection, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	username := c.GetString("username")
+	page := models.MstrInspectionPage{
+		IdMstrInspection: inspection.Id,
+		Title:            c.PostForm("title"),
+		CreatedBy:        username,
+		UpdatedBy:        username,
+	}
+
+	if v := c.PostForm("sort_order"); v != "" {
+		order, err := strconv.Atoi(v)
+		if err != nil {
+			utils.JSONError(c, http.StatusBadRequest, "sort_order must be a number")
+			return
+		}
+		page.SortOrder = order
+	} else {
+		var last struct{ Max *int }
+		config.DB.Model(&models.MstrInspectionPage{}).
+			Select("MAX(sort_order) AS max").
+			Where("id_mstr_inspection = ?", inspection.Id).
+			Scan(&last)
+		if last.Max != nil {
+			page.SortOrder = *last.Max + 1
+		}
+	}
+
+	objectKey, err := uploadPageImage(c)
+	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
+		utils.JSONError(c, http.StatusBadRequest, "Image upload required: "+err.Error())
+		return
+	}
+	page.ImageUrl = objectKey
+
+	tx := config.DB.Begin()
+	if err := tx.Create(&page).Error; err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := markInspectionDraft(tx, inspection.Id, username); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to commit transaction: "+err.Error())
+		return
+	}
+
+	utils.JSONCreated(c, "Assurance page created successfully", page)
+}
+
+// PUT /mstr-inspection-pages/:id (multipart: title, sort_order, image opsional)
+func UpdateMstrInspectionPage(c *gin.Context) {
+	var page models.MstrInspectionPage
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		First(&page, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Page not found")
+		return
+	}
+
+	if v, ok := c.GetPostForm("title"); ok {
+		page.Title = v
+	}
+	if v := c.PostForm("sort_order"); v != "" {
+		order, err := strconv.Atoi(v)
+		if err != nil {
+			utils.JSONError(c, http.StatusBadRequest, "sort_order must be a number")
+			return
+		}
+		page.SortOrder = order
+	}
+
+	// Gambar baru hanya jika dikirim
+	if _, err := c.FormFile("image"); err == nil {
+		objectKey, err := uploadPageImage(c)
+		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
+			utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
+			return
+		}
+		page.ImageUrl = objectKey
+	}
+
+	username := c.GetString("username")
+	page.UpdatedBy = username
+
+	tx := config.DB.Begin()
+	if err := tx.Save(&page).Error; err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := markInspectionDraft(tx, page.IdMstrInspection, username); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to commit transaction: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Assurance page updated successfully", page)
+}
+
+// DELETE /mstr-inspection-pages/:id → ditolak selama masih ada SAM di halaman ini
+func DeleteMstrInspectionPage(c *gin.Context) {
+	var page models.MstrInspectionPage
+	if err := utils.TenantChildDB(c, "id_mstr_inspection", "mstr_inspection").
+		Select("id", "id_mstr_inspection").First(&page, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Page not found")
+		return
+	}
+
+	var samCount int64
+	if err := config.DB.Model(&models.MstrInspectionDetail{}).
+		Where("id_page = ?", page.Id).Count(&samCount).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if samCount > 0 {
+		utils.JSONError(c, http.StatusConflict, "Page still has SAM points, move or delete them first")
+		return
+	}
+
+	username := c.GetString("username")
+	tx := config.DB.Begin()
+	if err := tx.Model(&page).Update("deleted_by", username).Error; err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Delete(&page).Error; err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Halaman tetap ada di versi published sampai draft di-publish ulang
+	if err := markInspectionDraft(tx, page.IdMstrInspection, username); err != nil {
+		tx.Rollback()
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update draft: "+err.Error())
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to commit transaction: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Assurance page deleted", nil)
+}
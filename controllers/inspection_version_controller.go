@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errNoPublishedVersion = errors.New("assurance has no published version")
+
+// buildInspectionSnapshot mengambil draft (baris live) lengkap dengan relasi untuk disimpan sebagai versi
+func buildInspectionSnapshot(tx *gorm.DB, inspectionID uint) (models.InspectionSnapshot, error) {
+	var details []models.MstrInspectionDetail
+	err := tx.
+		Preload("Types").
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("Questions.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Where("id_mstr_inspection = ?", inspectionID).
+		Order("id ASC").
+		Find(&details).Error
+	if err != nil {
+		return models.InspectionSnapshot{}, err
+	}
+
+	pages, err := inspectionPages(tx, inspectionID)
+	return models.InspectionSnapshot{Details: details, Pages: pages}, err
+}
+
+// publishInspection membuat versi baru dari draft saat ini. Harus dipanggil di dalam transaksi.
+func publishInspection(tx *gorm.DB, inspectionID uint, username, notes string) (*models.MstrInspectionVersion, error) {
+	// Lock row master supaya nomor versi tidak bentrok saat publish bersamaan
+	var inspection models.MstrInspection
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&inspection, inspectionID).Error; err != nil {
+		return nil, err
+	}
+
+	snapshot, err := buildInspectionSnapshot(tx, inspection.Id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PassThreshold = inspection.PassThreshold
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	version := models.MstrInspectionVersion{
+		IdMstrInspection: inspection.Id,
+		Version:          inspection.PublishedVersion + 1,
+		NameInspection:   inspection.NameInspection,
+		ImageUrl:         inspection.ImageUrl,
+		CompanyID:        inspection.CompanyID,
+		Snapshot:         datatypes.JSON(snapshotJSON),
+		Notes:            notes,
+		PublishedBy:      username,
+		PublishedAt:      time.Now(),
+	}
+
+	if err := tx.Create(&version).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.MstrInspection{}).
+		Where("id = ?", inspection.Id).
+		Updates(map[string]interface{}{
+			"status":               models.InspectionStatusPublished,
+			"published_version":    version.Version,
+			"published_version_id": version.Id,
+			"has_draft_changes":    false,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// markInspectionDraft menandai bahwa draft sudah berbeda dari versi published
+func markInspectionDraft(tx *gorm.DB, inspectionID uint, username string) error {
+	return tx.Model(&models.MstrInspection{}).
+		Where("id = ?", inspectionID).
+		Updates(map[string]interface{}{
+			"has_draft_changes": true,
+			"updated_by":        username,
+		}).Error
+}
+
+// markInspectionDraftByDetail sama seperti markInspectionDraft tapi dari ID SAM
+func markInspectionDraftByDetail(tx *gorm.DB, detailID interface{}, username string) error {
+	var detail models.MstrInspectionDetail
+	if err := tx.Unscoped().Select("id", "id_mstr_inspection").First(&detail, detailID).Error; err != nil {
+		return err
+	}
+	return markInspectionDraft(tx, detail.IdMstrInspection, username)
+}
+
+// loadInspectionVersion mengambil versi tertentu, atau versi published terakhir jika version = 0
+func loadInspectionVersion(db *gorm.DB, inspectionID uint, version uint) (*models.MstrInspectionVersion, *models.InspectionSnapshot, error) {
+	var v models.MstrInspectionVersion
+	query := db.Where("id_mstr_inspection = ?", inspectionID)
+
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	} else {
+		var inspection models.MstrInspection
+		if err := db.Select("id", "published_version_id").First(&inspection, inspectionID).Error; err != nil {
+			return nil, nil, err
+		}
+		if inspection.PublishedVersionID == nil {
+			return nil, nil, errNoPublishedVersion
+		}
+		query = query.Where("id = ?", *inspection.PublishedVersionID)
+	}
+
+	if err := query.First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) && version == 0 {
+			return nil, nil, errNoPublishedVersion
+		}
+		return nil, nil, err
+	}
+
+	var snapshot models.InspectionSnapshot
+	if err := json.Unmarshal(v.Snapshot, &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot for version %d: %v", v.Version, err)
+	}
+
+	return &v, &snapshot, nil
+}
+
+// publishedInspectionView menyusun MstrInspection dari versi published (untuk payload tablet)
+func publishedInspectionView(db *gorm.DB, inspectionID uint) (*models.MstrInspection, error) {
+	var inspection models.MstrInspection
+	if err := db.First(&inspection, inspectionID).Error; err != nil {
+		return nil, err
+	}
+
+	v, snapshot, err := loadInspectionVersion(db, inspectionID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection.NameInspection = v.NameInspection
+	inspection.ImageUrl = v.ImageUrl
+	inspection.UpdatedAt = v.PublishedAt
+	inspection.UpdatedBy = v.PublishedBy
+	inspection.Details = snapshot.Details
+	inspection.Pages = snapshot.Pages
+	return &inspection, nil
+}
+
+// MigrateLegacyInspectionVersions mem-publish versi 1 untuk assurance master lama yang belum punya versi,
+// supaya tablet tetap menerima assurance yang sudah dipakai sebelum fitur versioning.
+func MigrateLegacyInspectionVersions() {
+	var ids []uint
+	if err := config.DB.Model(&models.MstrInspection{}).
+		Where("status IS NULL OR status = ''").
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("[ERROR] Failed to load legacy inspections: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := publishInspection(tx, id, "system", "Initial version (migrated)")
+			return err
+		})
+		if err != nil {
+			log.Printf("[ERROR] Failed to publish legacy inspection %d: %v", id, err)
+		}
+	}
+}
+
+// PUBLISH ASSURANCE MASTER
+func PublishMstrInspection(c *gin.Context) {
+	id := parseUint(c.Param("id"))
+	username := c.GetString("username")
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	// body opsional
+	_ = c.ShouldBindJSON(&req)
+
+	var inspection models.MstrInspection
+	if err := utils.TenantDB(c).First(&inspection, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	if inspection.Status == models.InspectionStatusPublished && !inspection.HasDraftChanges {
+		utils.JSONError(c, http.StatusConflict, "No draft changes to publish")
+		return
+	}
+
+	var version *models.MstrInspectionVersion
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = publishInspection(tx, inspection.Id, username, req.Notes)
+		return err
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to publish assurance: "+err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Assurance published", version)
+}
+
+// LIST VERSION ASSURANCE MASTER
+func GetMstrInspectionVersions(c *gin.Context) {
+	id := parseUint(c.Param("id"))
+
+	var inspection models.MstrInspection
+	if err := utils.TenantDB(c).First(&inspection, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	// Snapshot tidak ikut di list supaya ringan
+	var versions []models.MstrInspectionVersion
+	if err := config.DB.
+		Omit("snapshot").
+		Where("id_mstr_inspection = ?", inspection.Id).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Assurance versions", versions)
+}
+
+// DETAIL VERSION ASSURANCE MASTER
+func GetMstrInspectionVersion(c *gin.Context) {
+	id := parseUint(c.Param("id"))
+	versionNo := parseUint(c.Param("version"))
+
+	if versionNo == 0 {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	v, snapshot, err := loadInspectionVersion(utils.TenantDB(c), id, versionNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(c, http.StatusNotFound, "Version not found")
+			return
+		}
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	v.Snapshot = nil
+	utils.JSONSuccess(c, "Assurance version", gin.H{
+		"version":        v,
+		"details":        snapshot.Details,
+		"pages":          snapshot.Pages,
+		"pass_threshold": snapshot.PassThreshold,
+	})
+}
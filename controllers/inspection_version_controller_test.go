@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-api/config"
+	"go-api/models"
+	"net/http"
+	"testing"
+)
+
+// Detail versi berisi isi snapshot lengkap (SAM, halaman, threshold), bukan draft saat ini
+func TestGetMstrInspectionVersionSnapshot(t *testing.T) {
+	db := newTestDB(t)
+	inspection := models.MstrInspection{NameInspection: "Gudang", ImageUrl: "img/main.jpg", CompanyID: "COMP-A", PassThreshold: 75}
+	mustCreate(t, db, &inspection)
+	mustCreate(t, db, &models.MstrInspectionPage{IdMstrInspection: inspection.Id, Title: "Lantai 2", ImageUrl: "img/page-2.jpg"})
+	mustCreate(t, db, &models.MstrInspectionDetail{IdMstrInspection: inspection.Id, NameCoordinate: "Pintu"})
+	if _, err := publishInspection(config.DB, inspection.Id, "tester", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Perubahan draft setelah publish tidak terlihat di versi 1
+	db.Model(&inspection).Update("pass_threshold", 90)
+
+	r := adminRouter("COMP-A")
+	r.GET("/mstr-inspections/:id/versions/:version", GetMstrInspectionVersion)
+	w := doJSON(r, http.MethodGet, idPath("/mstr-inspections/%d/versions/1", inspection.Id), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (body: %s)", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Details       []models.MstrInspectionDetail `json:"details"`
+			Pages         []models.MstrInspectionPage   `json:"pages"`
+			PassThreshold float64                       `json:"pass_threshold"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Details) != 1 || len(resp.Data.Pages) != 1 || resp.Data.Pages[0].ImageUrl != "img/page-2.jpg" || resp.Data.PassThreshold != 75 {
+		t.Errorf("version = %+v, want 1 SAM, page img/page-2.jpg and pass_threshold 75", resp.Data)
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxJobReportErrors = 50
+
+var errJobAlreadyRunning = errors.New("job is already running")
+
+// jobReport = ringkasan job yang disimpan ke TrxJobRun.Report
+type jobReport struct {
+	Companies map[string]map[string]int `json:"companies,omitempty"`
+	Errors    []string                  `json:"errors,omitempty"`
+	Extra     map[string]interface{}    `json:"extra,omitempty"`
+}
+
+// count menambah counter per company (mis. "generated", "skipped")
+func (r *jobReport) count(companyID, key string, n int) {
+	if r.Companies == nil {
+		r.Companies = make(map[string]map[string]int)
+	}
+	if r.Companies[companyID] == nil {
+		r.Companies[companyID] = make(map[string]int)
+	}
+	r.Companies[companyID][key] += n
+}
+
+func (r *jobReport) addError(format string, args ...interface{}) {
+	if len(r.Errors) < maxJobReportErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// startJobRun mencatat run baru (JobName, CompanyID, DryRun, CreatedBy dari caller) lalu menjalankan fn di background.
+// Satu job hanya boleh jalan sekali dalam satu waktu.
+func startJobRun(run models.TrxJobRun, fn func(run *models.TrxJobRun, report *jobReport) error) (*models.TrxJobRun, error) {
+	var running int64
+	config.DB.Model(&models.TrxJobRun{}).Where("job_name = ? AND status = ?", run.JobName, models.JobStatusRunning).Count(&running)
+	if running > 0 {
+		return nil, errJobAlreadyRunning
+	}
+
+	run.Status = models.JobStatusRunning
+	run.StartedAt = time.Now()
+	if err := config.DB.Create(&run).Error; err != nil {
+		return nil, err
+	}
+
+	go func(run models.TrxJobRun) {
+		report := &jobReport{}
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			finishJobRun(&run, report, err)
+		}()
+		err = fn(&run, report)
+	}(run)
+
+	return &run, nil
+}
+
+func finishJobRun(run *models.TrxJobRun, report *jobReport, err error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.JobStatusSucceeded
+	if err != nil {
+		run.Status = models.JobStatusFailed
+		run.Error = err.Error()
+		log.Printf("job %s #%d failed: %v", run.JobName, run.Id, err)
+	}
+	if raw, merr := json.Marshal(report); merr == nil {
+		run.Report = raw
+	}
+	config.DB.Save(run)
+}
+
+// MarkInterruptedJobRuns: run yang masih "running" saat startup berarti proses sebelumnya mati di tengah jalan
+func MarkInterruptedJobRuns() {
+	config.DB.Model(&models.TrxJobRun{}).
+		Where("status = ?", models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusFailed,
+			"error":       "interrupted by server restart",
+			"finished_at": time.Now(),
+		})
+}
+
+// GET /jobs?job_name= → riwayat job batch
+func GetJobRuns(c *gin.Context) {
+	query := config.DB.Model(&models.TrxJobRun{})
+	if name := c.Query("job_name"); name != "" {
+		query = query.Where("job_name = ?", name)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.TrxJobRun
+	if err := query.Order("id DESC").Limit(100).Find(&runs).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Job runs", runs)
+}
+
+// GET /jobs/:id
+func GetJobRunByID(c *gin.Context) {
+	var run models.TrxJobRun
+	if err := config.DB.First(&run, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Job run not found")
+		return
+	}
+
+	utils.JSONSuccess(c, "Job run", run)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"go-api/config"
 	"go-api/models"
+	"go-api/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -23,7 +24,7 @@ func ManageGroupChainingBulk(c *gin.Context) {
 
 	// Ambil group beserta relasi chainings
 	var group models.MstrGroup
-	if err := config.DB.Preload("Chainings").First(&group, groupID).Error; err != nil {
+	if err := utils.TenantDB(c).Preload("Chainings").First(&group, groupID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Group not found"})
 		return
 	}
@@ -31,10 +32,15 @@ func ManageGroupChainingBulk(c *gin.Context) {
 	// Ambil semua chaining yang akan di-assign
 	var chainings []models.MstrChaining
 	if len(req.ChainingIDs) > 0 {
-		if err := config.DB.Where("id IN ?", req.ChainingIDs).Find(&chainings).Error; err != nil {
+		// hanya chaining milik company yang sama dengan group
+		if err := config.DB.Where("id IN ? AND company_id = ?", req.ChainingIDs, group.CompanyID).Find(&chainings).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
+		if len(chainings) != len(uniqueUints(req.ChainingIDs)) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Some chainings not found"})
+			return
+		}
 	}
 
 	// Hapus semua chaining dari group
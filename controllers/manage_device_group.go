@@ -3,6 +3,7 @@ package controllers
 import (
 	"go-api/config"
 	"go-api/models"
+	"go-api/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -22,7 +23,7 @@ func ManageGroupDeviceBulk(c *gin.Context) {
 
 	// Ambil group beserta relasi devices
 	var group models.MstrGroup
-	if err := config.DB.Preload("Devices").First(&group, groupID).Error; err != nil {
+	if err := utils.TenantDB(c).Preload("Devices").First(&group, groupID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Group not found"})
 		return
 	}
@@ -30,10 +31,15 @@ func ManageGroupDeviceBulk(c *gin.Context) {
 	// Ambil semua devices yang akan di-assign
 	var devices []models.MstrDevice
 	if len(req.DeviceIDs) > 0 {
-		if err := config.DB.Where("id IN ?", req.DeviceIDs).Find(&devices).Error; err != nil {
+		// hanya device milik company yang sama dengan group
+		if err := config.DB.Where("id IN ? AND company_id = ?", req.DeviceIDs, group.CompanyID).Find(&devices).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
+		if len(devices) != len(uniqueUints(req.DeviceIDs)) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Some devices not found"})
+			return
+		}
 	}
 
 	// Hapus semua device dari group
@@ -94,3 +100,16 @@ func GetGroupDevices(c *gin.Context) {
 		"assigned_device_ids": assignedIDs,
 	})
 }
+
+// uniqueUints menghapus ID duplikat dari request bulk (untuk cek semua ID ditemukan)
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
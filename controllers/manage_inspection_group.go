@@ -3,6 +3,7 @@ package controllers
 import (
 	"go-api/config"
 	"go-api/models"
+	"go-api/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -22,7 +23,7 @@ func ManageGroupInspectionBulk(c *gin.Context) {
 
 	// Ambil group beserta relasi inspections
 	var group models.MstrGroup
-	if err := config.DB.Preload("Inspections").First(&group, groupID).Error; err != nil {
+	if err := utils.TenantDB(c).Preload("Inspections").First(&group, groupID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Group not found"})
 		return
 	}
@@ -30,10 +31,15 @@ func ManageGroupInspectionBulk(c *gin.Context) {
 	// Ambil semua inspections yang akan di-assign
 	var inspections []models.MstrInspection
 	if len(req.InspectionIDs) > 0 {
-		if err := config.DB.Where("id IN ?", req.InspectionIDs).Find(&inspections).Error; err != nil {
+		// hanya inspection milik company yang sama dengan group
+		if err := config.DB.Where("id IN ? AND company_id = ?", req.InspectionIDs, group.CompanyID).Find(&inspections).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
+		if len(inspections) != len(uniqueUints(req.InspectionIDs)) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Some inspections not found"})
+			return
+		}
 	}
 
 	// Hapus semua inspection dari group
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go-api/config"
 	"go-api/models"
+	"go-api/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -24,11 +25,24 @@ func ManageGroupQuestionnaireBulk(c *gin.Context) {
 
 	// Ambil group
 	var group models.MstrGroup
-	if err := config.DB.First(&group, groupID).Error; err != nil {
+	if err := utils.TenantDB(c).First(&group, groupID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Group not found"})
 		return
 	}
 
+	// Ambil questionnaires baru (hanya sesuai type & company yang sama dengan group)
+	var questionnaires []models.Questionnaire
+	if len(req.QuestionnaireIDs) > 0 {
+		if err := config.DB.Where("id IN ? AND type = ? AND company_id = ?", req.QuestionnaireIDs, req.Type, group.CompanyID).Find(&questionnaires).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		if len(questionnaires) != len(uniqueUints(req.QuestionnaireIDs)) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Some questionnaires not found"})
+			return
+		}
+	}
+
 	// Hapus hanya questionnaire dari group berdasarkan type
 	if err := config.DB.Exec(`
 		DELETE FROM mstr_group_questionnaire 
@@ -41,15 +55,6 @@ func ManageGroupQuestionnaireBulk(c *gin.Context) {
 		return
 	}
 
-	// Ambil questionnaires baru (hanya sesuai type)
-	var questionnaires []models.Questionnaire
-	if len(req.QuestionnaireIDs) > 0 {
-		if err := config.DB.Where("id IN ? AND type = ?", req.QuestionnaireIDs, req.Type).Find(&questionnaires).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
-			return
-		}
-	}
-
 	// Assign kembali questionnaire baru
 	if len(questionnaires) > 0 {
 		if err := config.DB.Model(&group).Association("Questionnaires").Append(&questionnaires); err != nil {
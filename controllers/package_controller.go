@@ -0,0 +1,879 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// === Paket import/export ===
+// ZIP berisi manifest.json (format di bawah) dan gambar di images/. ID di manifest hanya
+// dipakai sebagai ref (display_condition, page_ref); saat import semua ID dibuat baru dan
+// type trigger dicocokkan berdasarkan nama di company tujuan.
+
+const (
+	packageFormatVersion     = 1
+	packageManifestName      = "manifest.json"
+	packageMaxSize           = 200 << 20 // ukuran ZIP yang di-upload
+	packageMaxEntrySize      = 50 << 20  // ukuran satu file di dalam ZIP (setelah extract)
+	packageMaxImageBytes     = 500 << 20 // total gambar yang di-upload dari satu paket (setelah extract)
+	packageMaxPages          = 200
+	packageKindAssurance     = "assurance"
+	packageKindQuestionnaire = "questionnaire"
+)
+
+type packageManifest struct {
+	FormatVersion int       `json:"format_version"`
+	Kind          string    `json:"kind"` // assurance | questionnaire
+	ExportedAt    time.Time `json:"exported_at"`
+	ExportedBy    string    `json:"exported_by"`
+	SourceCompany string    `json:"source_company"`
+
+	Assurance     *packageAssurance     `json:"assurance,omitempty"`
+	Questionnaire *packageQuestionnaire `json:"questionnaire,omitempty"`
+}
+
+type packageAssurance struct {
+	Name          string          `json:"name"`
+	Image         string          `json:"image"` // path gambar utama di ZIP
+	PassThreshold float64         `json:"pass_threshold"`
+	Pages         []packagePage   `json:"pages,omitempty"`
+	Details       []packageDetail `json:"details"`
+}
+
+type packagePage struct {
+	Ref       uint   `json:"ref"`
+	Title     string `json:"title"`
+	Image     string `json:"image"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type packageDetail struct {
+	Ref         uint              `json:"ref"`
+	Name        string            `json:"name"`
+	X           float64           `json:"x"`
+	Y           float64           `json:"y"`
+	Tutorial    string            `json:"tutorial"`
+	Required    bool              `json:"required"`
+	SendNow     bool              `json:"send_now"`
+	TypeTrigger string            `json:"type_trigger,omitempty"` // nama type trigger
+	MinEvidence int               `json:"min_evidence,omitempty"`
+	MaxEvidence int               `json:"max_evidence,omitempty"`
+	Geometry    datatypes.JSON    `json:"geometry,omitempty"`
+	PageRef     *uint             `json:"page_ref,omitempty"`
+	Questions   []packageQuestion `json:"questions"`
+}
+
+type packageQuestion struct {
+	Ref              uint            `json:"ref"`
+	Text             string          `json:"text"`
+	Type             string          `json:"type"`
+	ValidationRules  datatypes.JSON  `json:"validation_rules,omitempty"`
+	DisplayCondition datatypes.JSON  `json:"display_condition,omitempty"` // question_id = ref question lain
+	Weight           float64         `json:"weight"`
+	IsCritical       bool            `json:"is_critical,omitempty"`
+	Options          []packageOption `json:"options,omitempty"`
+}
+
+type packageOption struct {
+	Label     string   `json:"label"`
+	Text      string   `json:"text"`
+	IsCorrect bool     `json:"is_correct,omitempty"`
+	Score     *float64 `json:"score,omitempty"`
+}
+
+type packageQuestionnaire struct {
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Type          string            `json:"type"`
+	IsActive      bool              `json:"is_active"`
+	PassThreshold float64           `json:"pass_threshold"`
+	Questions     []packageQuestion `json:"questions"`
+}
+
+// packageImportResult = ringkasan import (dry-run maupun commit)
+type packageImportResult struct {
+	DryRun              bool     `json:"dry_run"`
+	Kind                string   `json:"kind"`
+	CompanyID           string   `json:"company_id"`
+	Name                string   `json:"name"`
+	ID                  uint     `json:"id,omitempty"` // record baru, kosong saat dry-run
+	Published           bool     `json:"published,omitempty"`
+	Pages               int      `json:"pages"`
+	Details             int      `json:"details"`
+	Questions           int      `json:"questions"`
+	Options             int      `json:"options"`
+	TypeTriggersMatched []string `json:"type_triggers_matched"`
+	TypeTriggersCreated []string `json:"type_triggers_created"` // saat dry-run: yang akan dibuat
+}
+
+// bytesFile membungkus isi file dari ZIP supaya bisa di-upload lewat UploadFileToE2 (multipart.File)
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (bytesFile) Close() error { return nil }
+
+func exportQuestions(questions []models.MstrInspectionQuestion) []packageQuestion {
+	result := make([]packageQuestion, 0, len(questions))
+	for _, q := range questions {
+		pq := packageQuestion{
+			Ref:              q.ID,
+			Text:             q.Text,
+			Type:             q.Type,
+			ValidationRules:  q.ValidationRules,
+			DisplayCondition: q.DisplayCondition,
+			Weight:           q.Weight,
+			IsCritical:       q.IsCritical,
+		}
+		for _, o := range q.Options {
+			pq.Options = append(pq.Options, packageOption{Label: o.Label, Text: o.Text, IsCorrect: o.IsCorrect, Score: o.Score})
+		}
+		result = append(result, pq)
+	}
+	return result
+}
+
+// inspectionQuestions mengubah question paket ke model (ID = ref) untuk validasi & simpan
+func inspectionQuestions(questions []packageQuestion) []models.MstrInspectionQuestion {
+	result := make([]models.MstrInspectionQuestion, 0, len(questions))
+	for _, pq := range questions {
+		q := models.MstrInspectionQuestion{
+			ID:               pq.Ref,
+			Text:             pq.Text,
+			Type:             strings.ToLower(pq.Type),
+			ValidationRules:  pq.ValidationRules,
+			DisplayCondition: pq.DisplayCondition,
+			Weight:           pq.Weight,
+			IsCritical:       pq.IsCritical,
+		}
+		for _, o := range pq.Options {
+			q.Options = append(q.Options, models.MstrInspectionQuestionOption{
+				Label:     strings.ToUpper(strings.TrimSpace(o.Label)),
+				Text:      o.Text,
+				IsCorrect: o.IsCorrect,
+				Score:     o.Score,
+			})
+		}
+		result = append(result, q)
+	}
+	return result
+}
+
+// writePackage menulis manifest + file ke ZIP dan mengirimnya sebagai attachment
+func writePackage(c *gin.Context, filename string, manifest packageManifest, files map[string][]byte) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w, err := zw.Create(packageManifestName)
+	if err == nil {
+		_, err = w.Write(manifestJSON)
+	}
+	for name, data := range files {
+		if err != nil {
+			break
+		}
+		if w, err = zw.Create(name); err == nil {
+			_, err = w.Write(data)
+		}
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to build package: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// GET /mstr-inspections/:id/export → ZIP (manifest.json + gambar utama & gambar halaman) dari draft saat ini
+func ExportMstrInspection(c *gin.Context) {
+	var inspection models.MstrInspection
+	if err := utils.TenantDB(c).
+		Preload("Details", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Details.Types").
+		Preload("Details.Questions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Details.Questions.Options", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Pages", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC, id ASC") }).
+		First(&inspection, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Assurance not found")
+		return
+	}
+
+	backend, _, err := companyStorage(inspection.CompanyID)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	files := make(map[string][]byte)
+	addImage := func(name, objectKey string) (string, error) {
+		if objectKey == "" {
+			return "", nil
+		}
+		body, _, err := backend.Get(c.Request.Context(), objectKey)
+		if err != nil {
+			return "", fmt.Errorf("image %s: %v", objectKey, err)
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return "", err
+		}
+		entry := "images/" + name + path.Ext(objectKey)
+		files[entry] = data
+		return entry, nil
+	}
+
+	assurance := &packageAssurance{
+		Name:          inspection.NameInspection,
+		PassThreshold: inspection.PassThreshold,
+	}
+	if assurance.Image, err = addImage("main", inspection.ImageUrl); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, p := range inspection.Pages {
+		image, err := addImage(fmt.Sprintf("page-%d", p.Id), p.ImageUrl)
+		if err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		assurance.Pages = append(assurance.Pages, packagePage{Ref: p.Id, Title: p.Title, Image: image, SortOrder: p.SortOrder})
+	}
+	for _, d := range inspection.Details {
+		pd := packageDetail{
+			Ref:         d.Id,
+			Name:        d.NameCoordinate,
+			X:           d.X,
+			Y:           d.Y,
+			Tutorial:    d.TutorialCoordinate,
+			Required:    d.RequiredCoordinate,
+			SendNow:     d.SendNow,
+			MinEvidence: d.MinEvidence,
+			MaxEvidence: d.MaxEvidence,
+			Geometry:    d.Geometry,
+			PageRef:     d.IdPage,
+			Questions:   exportQuestions(d.Questions),
+		}
+		if d.Types != nil {
+			pd.TypeTrigger = d.Types.TypeName
+		}
+		assurance.Details = append(assurance.Details, pd)
+	}
+
+	manifest := packageManifest{
+		FormatVersion: packageFormatVersion,
+		Kind:          packageKindAssurance,
+		ExportedAt:    time.Now(),
+		ExportedBy:    c.GetString("username"),
+		SourceCompany: inspection.CompanyID,
+		Assurance:     assurance,
+	}
+	writePackage(c, fmt.Sprintf("assurance-%d.zip", inspection.Id), manifest, files)
+}
+
+// GET /questionnaires/:id/export → ZIP berisi manifest.json
+func ExportQuestionnaire(c *gin.Context) {
+	var qn models.Questionnaire
+	if err := utils.TenantDB(c).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Questions.Options", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&qn, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Questionnaire not found")
+		return
+	}
+
+	questionnaire := &packageQuestionnaire{
+		Title:         qn.Title,
+		Description:   qn.Description,
+		Type:          qn.Type,
+		IsActive:      qn.IsActive,
+		PassThreshold: qn.PassThreshold,
+	}
+	for _, q := range qn.Questions {
+		pq := packageQuestion{
+			Ref:              q.ID,
+			Text:             q.Text,
+			Type:             q.Type,
+			ValidationRules:  q.ValidationRules,
+			DisplayCondition: q.DisplayCondition,
+			Weight:           q.Weight,
+			IsCritical:       q.IsCritical,
+		}
+		for _, o := range q.Options {
+			pq.Options = append(pq.Options, packageOption{Label: o.Label, Text: o.Text, IsCorrect: o.IsCorrect, Score: o.Score})
+		}
+		questionnaire.Questions = append(questionnaire.Questions, pq)
+	}
+
+	manifest := packageManifest{
+		FormatVersion: packageFormatVersion,
+		Kind:          packageKindQuestionnaire,
+		ExportedAt:    time.Now(),
+		ExportedBy:    c.GetString("username"),
+		SourceCompany: qn.CompanyID,
+		Questionnaire: questionnaire,
+	}
+	writePackage(c, fmt.Sprintf("questionnaire-%d.zip", qn.ID), manifest, nil)
+}
+
+// readPackage membaca file "package" dari multipart: ZIP, atau manifest JSON saja (hanya untuk
+// questionnaire; files = nil sehingga paket assurance ditolak di checkPackageManifest)
+func readPackage(c *gin.Context) (*packageManifest, map[string]*zip.File, error) {
+	fh, err := c.FormFile("package")
+	if err != nil {
+		return nil, nil, errors.New("package file is required")
+	}
+	if fh.Size > packageMaxSize {
+		return nil, nil, fmt.Errorf("package exceeds %d MB", packageMaxSize>>20)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var manifestJSON []byte
+	var files map[string]*zip.File
+	if zr, err := zip.NewReader(f, fh.Size); err == nil {
+		files = make(map[string]*zip.File, len(zr.File))
+		for _, zf := range zr.File {
+			files[path.Clean(zf.Name)] = zf
+		}
+		mf, ok := files[packageManifestName]
+		if !ok {
+			return nil, nil, errors.New("manifest.json not found in package")
+		}
+		if manifestJSON, err = readPackageFile(mf); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if manifestJSON, err = io.ReadAll(io.LimitReader(f, packageMaxEntrySize)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var manifest packageManifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return &manifest, files, nil
+}
+
+func readPackageFile(zf *zip.File) ([]byte, error) {
+	if zf.UncompressedSize64 > packageMaxEntrySize {
+		return nil, fmt.Errorf("%s exceeds %d MB", zf.Name, packageMaxEntrySize>>20)
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, packageMaxEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > packageMaxEntrySize {
+		return nil, fmt.Errorf("%s exceeds %d MB", zf.Name, packageMaxEntrySize>>20)
+	}
+	return data, nil
+}
+
+// importTarget = company tujuan import: company user, atau form company_id untuk platform admin
+func importTarget(c *gin.Context) (*models.MstrCompany, error) {
+	companyID := c.GetString("company_id")
+	if v := c.PostForm("company_id"); v != "" && utils.HasPermission(c, utils.PermPlatformAdmin) {
+		companyID = v
+	}
+	var company models.MstrCompany
+	if err := config.DB.Where("company_id = ?", companyID).First(&company).Error; err != nil {
+		return nil, errors.New("target company not found")
+	}
+	return &company, nil
+}
+
+// checkPackageManifest validasi versi format, jenis paket dan ref sebelum ada yang disimpan
+func checkPackageManifest(m *packageManifest, kind string, files map[string]*zip.File) error {
+	if m.FormatVersion < 1 || m.FormatVersion > packageFormatVersion {
+		return fmt.Errorf("unsupported package format_version %d (supported: 1..%d)", m.FormatVersion, packageFormatVersion)
+	}
+	if m.Kind != kind {
+		return fmt.Errorf("package kind is %q, expected %q", m.Kind, kind)
+	}
+
+	if kind == packageKindQuestionnaire {
+		qn := m.Questionnaire
+		if qn == nil || strings.TrimSpace(qn.Title) == "" {
+			return errors.New("questionnaire title is required")
+		}
+		if !checkPassThreshold(qn.PassThreshold) {
+			return errors.New("pass_threshold must be between 0 and 100")
+		}
+		if err := checkPackageRefs(qn.Questions); err != nil {
+			return err
+		}
+		return checkInspectionQuestionRules(inspectionQuestions(qn.Questions))
+	}
+
+	a := m.Assurance
+	if a == nil || strings.TrimSpace(a.Name) == "" {
+		return errors.New("assurance name is required")
+	}
+	if !checkPassThreshold(a.PassThreshold) {
+		return errors.New("pass_threshold must be between 0 and 100")
+	}
+	if files == nil {
+		return errors.New("assurance package must be a ZIP containing manifest.json and its images")
+	}
+	if len(a.Pages) > packageMaxPages {
+		return fmt.Errorf("package has %d pages (max %d)", len(a.Pages), packageMaxPages)
+	}
+
+	// Ukuran dihitung per referensi: satu entry bisa dipakai banyak halaman dan di-upload berulang.
+	// UncompressedSize64 bisa dipercaya karena archive/zip menolak data yang melebihi ukuran ini.
+	var imageBytes uint64
+	checkImage := func(what, name string) error {
+		zf, ok := files[path.Clean(name)]
+		if name == "" || !ok {
+			return fmt.Errorf("%s image %q not found in package", what, name)
+		}
+		imageBytes += zf.UncompressedSize64
+		if imageBytes > packageMaxImageBytes {
+			return fmt.Errorf("package images exceed %d MB in total", packageMaxImageBytes>>20)
+		}
+		return nil
+	}
+	if err := checkImage("assurance", a.Image); err != nil {
+		return err
+	}
+	pageRefs := make(map[uint]bool, len(a.Pages))
+	for _, p := range a.Pages {
+		if pageRefs[p.Ref] {
+			return fmt.Errorf("duplicate page ref %d", p.Ref)
+		}
+		pageRefs[p.Ref] = true
+		if err := checkImage("page", p.Image); err != nil {
+			return err
+		}
+	}
+	var all []packageQuestion
+	for _, d := range a.Details {
+		if strings.TrimSpace(d.Name) == "" {
+			return errors.New("SAM name is required")
+		}
+		if d.PageRef != nil && !pageRefs[*d.PageRef] {
+			return fmt.Errorf("SAM %q refers to unknown page %d", d.Name, *d.PageRef)
+		}
+		detail := models.MstrInspectionDetail{
+			NameCoordinate: d.Name,
+			MinEvidence:    d.MinEvidence,
+			MaxEvidence:    d.MaxEvidence,
+			Geometry:       d.Geometry,
+			Questions:      inspectionQuestions(d.Questions),
+		}
+		if err := checkInspectionDetailRules(&detail); err != nil {
+			return err
+		}
+		all = append(all, d.Questions...)
+	}
+	return checkPackageRefs(all)
+}
+
+// checkPackageRefs: ref question harus unik di seluruh paket
+func checkPackageRefs(questions []packageQuestion) error {
+	seen := make(map[uint]bool, len(questions))
+	for _, q := range questions {
+		if q.Ref == 0 {
+			return fmt.Errorf("question %q has no ref", q.Text)
+		}
+		if seen[q.Ref] {
+			return fmt.Errorf("duplicate question ref %d", q.Ref)
+		}
+		seen[q.Ref] = true
+	}
+	return nil
+}
+
+// resolveTypeTriggers mencocokkan nama type trigger (case-insensitive) di company tujuan.
+// Yang belum ada dibuat saat commit; saat dry-run hanya dilaporkan.
+func resolveTypeTriggers(tx *gorm.DB, companyID, username string, details []packageDetail, dryRun bool, result *packageImportResult) (map[string]*uint, error) {
+	ids := make(map[string]*uint)
+	for _, d := range details {
+		name := strings.TrimSpace(d.TypeTrigger)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if _, done := ids[key]; done {
+			continue
+		}
+
+		var tt models.MstrTypeTrigger
+		err := tx.Where("company_id = ? AND LOWER(type_name) = ?", companyID, key).First(&tt).Error
+		switch {
+		case err == nil:
+			result.TypeTriggersMatched = append(result.TypeTriggersMatched, tt.TypeName)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			result.TypeTriggersCreated = append(result.TypeTriggersCreated, name)
+			if !dryRun {
+				tt = models.MstrTypeTrigger{TypeName: name, IsActive: true, CompanyID: companyID, CreatedBy: username, UpdatedBy: username}
+				if err := tx.Create(&tt).Error; err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, err
+		}
+		id := tt.Id
+		ids[key] = &id
+	}
+	return ids, nil
+}
+
+// uploadPackageImage meng-upload gambar dari ZIP ke storage company tujuan
+func uploadPackageImage(c *gin.Context, company *models.MstrCompany, zf *zip.File) (string, error) {
+	data, err := readPackageFile(zf)
+	if err != nil {
+		return "", err
+	}
+	contentType := mime.TypeByExtension(path.Ext(zf.Name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	override := companyE2Config(company)
+	fileKey := GenerateE2ObjectKey(c, "Master-Assurance", path.Base(zf.Name))
+	return UploadFileToE2(c, bytesFile{bytes.NewReader(data)}, fileKey, contentType, "Assurance/"+company.CompanyID, &override)
+}
+
+// discardPackageImages menghapus gambar yang sudah di-upload jika import gagal (best effort)
+func discardPackageImages(ctx context.Context, company *models.MstrCompany, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	backend, err := newCompanyStorage(company)
+	if err != nil {
+		log.Printf("package import %s: cleanup: %v", company.CompanyID, err)
+		return
+	}
+	for _, key := range keys {
+		var size int64
+		if info, err := backend.Head(ctx, key); err == nil {
+			size = info.Size
+		}
+		if err := backend.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("package import %s: cleanup %s: %v", company.CompanyID, key, err)
+			continue
+		}
+		if size > 0 {
+			recordStorageUsage(company.CompanyID, usageModuleFromKey(key), -size, -1)
+		}
+		for variant := range utils.ImageVariantSizes {
+			backend.Delete(ctx, utils.ImageVariantKey(key, variant))
+		}
+	}
+}
+
+// importParams membaca dry_run (default true) dan publish dari form
+func importParams(c *gin.Context) (dryRun, publish bool) {
+	dryRun = true
+	if v, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "true")); err == nil {
+		dryRun = v
+	}
+	publish, _ = strconv.ParseBool(c.PostForm("publish"))
+	return dryRun, publish
+}
+
+// POST /mstr-inspections/import (multipart: package, dry_run=true|false, publish, company_id untuk platform admin)
+func ImportMstrInspection(c *gin.Context) {
+	manifest, files, err := readPackage(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkPackageManifest(manifest, packageKindAssurance, files); err != nil {
+		utils.JSONError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	company, err := importTarget(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	dryRun, publish := importParams(c)
+	username := c.GetString("username")
+	a := manifest.Assurance
+	result := packageImportResult{DryRun: dryRun, Kind: packageKindAssurance, CompanyID: company.CompanyID, Name: a.Name, Pages: len(a.Pages), Details: len(a.Details)}
+	for _, d := range a.Details {
+		result.Questions += len(d.Questions)
+		for _, q := range d.Questions {
+			result.Options += len(q.Options)
+		}
+	}
+
+	if dryRun {
+		if _, err := resolveTypeTriggers(config.DB, company.CompanyID, username, a.Details, true, &result); err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.JSONSuccess(c, "Assurance package is valid (dry run)", result)
+		return
+	}
+
+	// === Upload gambar sebelum transaksi (sama seperti CreateMstrInspection) ===
+	// Jika upload berikutnya atau transaksi gagal, gambar yang sudah ter-upload dihapus lagi
+	var uploaded []string
+	imported := false
+	defer func() {
+		if !imported {
+			discardPackageImages(c.Request.Context(), company, uploaded)
+		}
+	}()
+
+	imageURL, err := uploadPackageImage(c, company, files[path.Clean(a.Image)])
+	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
+		utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
+		return
+	}
+	uploaded = append(uploaded, imageURL)
+	pageImages := make(map[uint]string, len(a.Pages))
+	for _, p := range a.Pages {
+		key, err := uploadPackageImage(c, company, files[path.Clean(p.Image)])
+		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
+			utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
+			return
+		}
+		uploaded = append(uploaded, key)
+		pageImages[p.Ref] = key
+	}
+
+	var inspection models.MstrInspection
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		triggers, err := resolveTypeTriggers(tx, company.CompanyID, username, a.Details, false, &result)
+		if err != nil {
+			return err
+		}
+
+		inspection = models.MstrInspection{
+			NameInspection:  a.Name,
+			ImageUrl:        imageURL,
+			CompanyID:       company.CompanyID,
+			Status:          models.InspectionStatusDraft,
+			HasDraftChanges: true,
+			PassThreshold:   a.PassThreshold,
+			CreatedBy:       username,
+			UpdatedBy:       username,
+		}
+		if err := tx.Create(&inspection).Error; err != nil {
+			return err
+		}
+
+		pageIDs := make(map[uint]uint, len(a.Pages))
+		for _, p := range a.Pages {
+			page := models.MstrInspectionPage{
+				IdMstrInspection: inspection.Id,
+				Title:            p.Title,
+				ImageUrl:         pageImages[p.Ref],
+				SortOrder:        p.SortOrder,
+				CreatedBy:        username,
+				UpdatedBy:        username,
+			}
+			if err := tx.Create(&page).Error; err != nil {
+				return err
+			}
+			pageIDs[p.Ref] = page.Id
+		}
+
+		for _, d := range a.Details {
+			geometry, _ := utils.NormalizeSamGeometry(d.Geometry) // sudah dicek di checkPackageManifest
+			detail := models.MstrInspectionDetail{
+				IdMstrInspection:   inspection.Id,
+				NameCoordinate:     d.Name,
+				X:                  d.X,
+				Y:                  d.Y,
+				TutorialCoordinate: d.Tutorial,
+				RequiredCoordinate: d.Required,
+				SendNow:            d.SendNow,
+				TypeTriggerID:      triggers[strings.ToLower(strings.TrimSpace(d.TypeTrigger))],
+				MinEvidence:        d.MinEvidence,
+				MaxEvidence:        d.MaxEvidence,
+				Geometry:           geometry,
+				CreatedBy:          username,
+				UpdatedBy:          username,
+			}
+			if d.PageRef != nil {
+				pageID := pageIDs[*d.PageRef]
+				detail.IdPage = &pageID
+			}
+			if err := tx.Create(&detail).Error; err != nil {
+				return err
+			}
+
+			questionIDs := make(map[uint]uint, len(d.Questions))
+			newQuestions := make([]models.MstrInspectionQuestion, 0, len(d.Questions))
+			for _, q := range inspectionQuestions(d.Questions) {
+				ref := q.ID
+				q.ID = 0
+				q.InspectionDetailID = detail.Id
+				q.CreatedBy = username
+				q.UpdatedBy = username
+				for i := range q.Options {
+					q.Options[i].CreatedBy = username
+					q.Options[i].UpdatedBy = username
+				}
+				if err := tx.Create(&q).Error; err != nil {
+					return err
+				}
+				questionIDs[ref] = q.ID
+				newQuestions = append(newQuestions, q)
+			}
+			// display_condition masih menunjuk ref dari paket
+			if err := remapInspectionConditions(tx, newQuestions, questionIDs); err != nil {
+				return err
+			}
+		}
+
+		if publish {
+			if _, err := publishInspection(tx, inspection.Id, username, "Imported from package"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to import assurance: "+err.Error())
+		return
+	}
+	imported = true
+
+	result.ID = inspection.Id
+	result.Published = publish
+	utils.JSONCreated(c, "Assurance imported successfully", result)
+}
+
+// POST /questionnaires/import (multipart: package, dry_run=true|false, company_id untuk platform admin)
+func ImportQuestionnaire(c *gin.Context) {
+	manifest, files, err := readPackage(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkPackageManifest(manifest, packageKindQuestionnaire, files); err != nil {
+		utils.JSONError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	company, err := importTarget(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	dryRun, _ := importParams(c)
+	username := c.GetString("username")
+	pq := manifest.Questionnaire
+	result := packageImportResult{DryRun: dryRun, Kind: packageKindQuestionnaire, CompanyID: company.CompanyID, Name: pq.Title, Questions: len(pq.Questions)}
+	for _, q := range pq.Questions {
+		result.Options += len(q.Options)
+	}
+	if dryRun {
+		utils.JSONSuccess(c, "Questionnaire package is valid (dry run)", result)
+		return
+	}
+
+	var qn models.Questionnaire
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		qn = models.Questionnaire{
+			Title:         pq.Title,
+			Description:   pq.Description,
+			Type:          pq.Type,
+			IsActive:      pq.IsActive,
+			PassThreshold: pq.PassThreshold,
+			CompanyID:     company.CompanyID,
+			CreatedBy:     username,
+			UpdatedBy:     username,
+		}
+		if err := tx.Create(&qn).Error; err != nil {
+			return err
+		}
+
+		questionIDs := make(map[uint]uint, len(pq.Questions))
+		newQuestions := make([]models.Question, 0, len(pq.Questions))
+		for _, iq := range inspectionQuestions(pq.Questions) {
+			q := models.Question{
+				QuestionnaireID:  qn.ID,
+				Text:             iq.Text,
+				Type:             iq.Type,
+				ValidationRules:  iq.ValidationRules,
+				DisplayCondition: iq.DisplayCondition,
+				Weight:           iq.Weight,
+				IsCritical:       iq.IsCritical,
+				CreatedBy:        username,
+				UpdatedBy:        username,
+			}
+			for _, o := range iq.Options {
+				q.Options = append(q.Options, models.Option{Label: o.Label, Text: o.Text, IsCorrect: o.IsCorrect, Score: o.Score})
+			}
+			if err := tx.Create(&q).Error; err != nil {
+				return err
+			}
+			questionIDs[iq.ID] = q.ID
+			newQuestions = append(newQuestions, q)
+		}
+
+		// display_condition masih menunjuk ref dari paket
+		for _, q := range newQuestions {
+			if len(q.DisplayCondition) == 0 {
+				continue
+			}
+			remapped, err := utils.RemapDisplayCondition(q.DisplayCondition, questionIDs)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Question{}).Where("id = ?", q.ID).
+				Update("display_condition", datatypes.JSON(remapped)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to import questionnaire: "+err.Error())
+		return
+	}
+
+	result.ID = qn.ID
+	utils.JSONCreated(c, "Questionnaire imported successfully", result)
+}
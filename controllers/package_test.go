@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+// buildPackage menyusun ZIP paket dari manifest dan file gambar
+func buildPackage(t *testing.T, manifest packageManifest, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	raw, _ := json.Marshal(manifest)
+	w, _ := zw.Create(packageManifestName)
+	w.Write(raw)
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// postPackage mengirim paket sebagai multipart field "package" beserta field form lain
+func postPackage(r http.Handler, url string, pkg []byte, fields map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+	part, _ := w.CreateFormFile("package", "package.zip")
+	part.Write(pkg)
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func bucketObjects(t *testing.T, bucket string) []storage.ObjectInfo {
+	t.Helper()
+	objects, err := storage.NewMemory(bucket).List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+// validAssurancePackage = paket minimal yang lolos checkPackageManifest
+func validAssurancePackage() (packageManifest, map[string][]byte) {
+	page := uint(7)
+	return packageManifest{
+		FormatVersion: packageFormatVersion,
+		Kind:          packageKindAssurance,
+		Assurance: &packageAssurance{
+			Name:  "Gudang",
+			Image: "images/main.png",
+			Pages: []packagePage{{Ref: 7, Title: "Lantai 2", Image: "images/page-7.png"}},
+			Details: []packageDetail{{
+				Ref:       1,
+				Name:      "Pintu",
+				PageRef:   &page,
+				Questions: []packageQuestion{{Ref: 11, Text: "Kondisi", Type: "text"}},
+			}},
+		},
+	}, map[string][]byte{
+		"images/main.png":   []byte("\x89PNG\r\n\x1a\nmain"),
+		"images/page-7.png": []byte("\x89PNG\r\n\x1a\npage"),
+	}
+}
+
+func TestPackageRoundTripAssurance(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", StorageBackend: storage.DriverMemory, E2BucketName: t.Name() + "-a"})
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "B", CompanyID: "COMP-B", StorageBackend: storage.DriverMemory, E2BucketName: t.Name() + "-b"})
+
+	// === Master assurance di company A ===
+	src := storage.NewMemory(t.Name() + "-a")
+	src.Put(ctx, "Assurance/COMP-A/main.png", strings.NewReader("main-image"), 10, "image/png")
+	src.Put(ctx, "Assurance/COMP-A/page.png", strings.NewReader("page-image"), 10, "image/png")
+
+	forkliftA := models.MstrTypeTrigger{TypeName: "Forklift", CompanyID: "COMP-A"}
+	craneA := models.MstrTypeTrigger{TypeName: "Crane", CompanyID: "COMP-A"}
+	mustCreate(t, db, &forkliftA)
+	mustCreate(t, db, &craneA)
+	// Company B sudah punya "FORKLIFT" → dicocokkan, "Crane" dibuat baru
+	forkliftB := models.MstrTypeTrigger{TypeName: "FORKLIFT", CompanyID: "COMP-B"}
+	mustCreate(t, db, &forkliftB)
+
+	inspection := models.MstrInspection{NameInspection: "Gudang", ImageUrl: "Assurance/COMP-A/main.png", CompanyID: "COMP-A", PassThreshold: 80}
+	mustCreate(t, db, &inspection)
+	page := models.MstrInspectionPage{IdMstrInspection: inspection.Id, Title: "Lantai 2", ImageUrl: "Assurance/COMP-A/page.png", SortOrder: 1}
+	mustCreate(t, db, &page)
+	onPage := models.MstrInspectionDetail{IdMstrInspection: inspection.Id, NameCoordinate: "Pintu", IdPage: &page.Id, TypeTriggerID: &forkliftA.Id}
+	mustCreate(t, db, &onPage)
+	onMain := models.MstrInspectionDetail{IdMstrInspection: inspection.Id, NameCoordinate: "Atap", TypeTriggerID: &craneA.Id}
+	mustCreate(t, db, &onMain)
+
+	choice := models.MstrInspectionQuestion{InspectionDetailID: onPage.Id, Text: "Rusak?", Type: utils.QuestionMultiple, Options: []models.MstrInspectionQuestionOption{
+		{Label: "A", Text: "Ya"}, {Label: "B", Text: "Tidak", IsCorrect: true},
+	}}
+	mustCreate(t, db, &choice)
+	followUp := models.MstrInspectionQuestion{InspectionDetailID: onPage.Id, Text: "Jelaskan", Type: utils.QuestionText,
+		DisplayCondition: datatypes.JSON(`{"question_id":` + strconv.Itoa(int(choice.ID)) + `,"op":"eq","value":"A"}`)}
+	mustCreate(t, db, &followUp)
+
+	// === Export dari A ===
+	ra := adminRouter("COMP-A")
+	ra.GET("/mstr-inspections/:id/export", ExportMstrInspection)
+	rec := httptest.NewRecorder()
+	ra.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, idPath("/mstr-inspections/%d/export", inspection.Id), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	pkg := rec.Body.Bytes()
+
+	rb := adminRouter("COMP-B")
+	rb.POST("/mstr-inspections/import", ImportMstrInspection)
+
+	// === Dry run: valid, tapi tidak ada yang dibuat ===
+	objects := len(bucketObjects(t, t.Name()+"-b"))
+	rec = postPackage(rb, "/mstr-inspections/import", pkg, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dry run status = %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	var inspections, triggers int64
+	db.Model(&models.MstrInspection{}).Where("company_id = ?", "COMP-B").Count(&inspections)
+	db.Model(&models.MstrTypeTrigger{}).Where("company_id = ?", "COMP-B").Count(&triggers)
+	if uploaded := len(bucketObjects(t, t.Name()+"-b")) - objects; inspections != 0 || triggers != 1 || uploaded != 0 {
+		t.Fatalf("dry run created data: inspections %d, type triggers %d, objects %d", inspections, triggers, uploaded)
+	}
+	if !strings.Contains(rec.Body.String(), `"type_triggers_created":["Crane"]`) {
+		t.Errorf("dry run result = %s", rec.Body.String())
+	}
+
+	// === Import ke B ===
+	rec = postPackage(rb, "/mstr-inspections/import", pkg, map[string]string{"dry_run": "false"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("import status = %d (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	var imported models.MstrInspection
+	if err := db.Preload("Pages").Preload("Details.Questions.Options").Where("company_id = ?", "COMP-B").First(&imported).Error; err != nil {
+		t.Fatal(err)
+	}
+	if imported.Id == inspection.Id || imported.PassThreshold != 80 || imported.Status != models.InspectionStatusDraft {
+		t.Errorf("imported inspection = %+v", imported)
+	}
+	if len(imported.Pages) != 1 || imported.Pages[0].Id == page.Id {
+		t.Fatalf("imported pages = %+v", imported.Pages)
+	}
+	for _, key := range []string{imported.ImageUrl, imported.Pages[0].ImageUrl} {
+		if _, err := storage.NewMemory(t.Name()+"-b").Head(ctx, key); err != nil || !strings.HasPrefix(key, "Assurance/COMP-B/") {
+			t.Errorf("image %q not in company B bucket: %v", key, err)
+		}
+	}
+
+	details := make(map[string]models.MstrInspectionDetail)
+	for _, d := range imported.Details {
+		details[d.NameCoordinate] = d
+	}
+	pintu, atap := details["Pintu"], details["Atap"]
+	if pintu.IdPage == nil || *pintu.IdPage != imported.Pages[0].Id {
+		t.Errorf("Pintu id_page = %v, want new page %d", pintu.IdPage, imported.Pages[0].Id)
+	}
+	if atap.IdPage != nil {
+		t.Errorf("Atap id_page = %v, want main image", *atap.IdPage)
+	}
+
+	var craneB models.MstrTypeTrigger
+	db.Where("company_id = ? AND type_name = ?", "COMP-B", "Crane").First(&craneB)
+	if pintu.TypeTriggerID == nil || *pintu.TypeTriggerID != forkliftB.Id {
+		t.Errorf("Pintu type trigger = %v, want existing %d", pintu.TypeTriggerID, forkliftB.Id)
+	}
+	if craneB.Id == 0 || atap.TypeTriggerID == nil || *atap.TypeTriggerID != craneB.Id {
+		t.Errorf("Atap type trigger = %v, want created Crane %d", atap.TypeTriggerID, craneB.Id)
+	}
+
+	if len(pintu.Questions) != 2 {
+		t.Fatalf("Pintu questions = %+v", pintu.Questions)
+	}
+	newChoice, newFollowUp := pintu.Questions[0], pintu.Questions[1]
+	if newChoice.ID == choice.ID || len(newChoice.Options) != 2 {
+		t.Errorf("question not copied: %+v", newChoice)
+	}
+	cond, err := utils.ParseDisplayCondition(newFollowUp.DisplayCondition)
+	if err != nil || cond == nil || cond.QuestionID != newChoice.ID {
+		t.Errorf("display_condition = %s, want question_id %d", newFollowUp.DisplayCondition, newChoice.ID)
+	}
+}
+
+func TestImportPackageRejected(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+	r := adminRouter("COMP-A")
+	r.POST("/mstr-inspections/import", ImportMstrInspection)
+
+	tests := []struct {
+		name   string
+		change func(m *packageManifest, files map[string][]byte)
+		plain  bool // kirim manifest JSON saja, bukan ZIP
+		want   string
+	}{
+		{name: "format version too new", change: func(m *packageManifest, _ map[string][]byte) { m.FormatVersion = packageFormatVersion + 1 }, want: "format_version"},
+		{name: "format version missing", change: func(m *packageManifest, _ map[string][]byte) { m.FormatVersion = 0 }, want: "format_version"},
+		{name: "questionnaire kind", change: func(m *packageManifest, _ map[string][]byte) { m.Kind = packageKindQuestionnaire }, want: "package kind"},
+		{name: "duplicate question ref", change: func(m *packageManifest, _ map[string][]byte) {
+			d := m.Assurance.Details[0]
+			d.Ref, d.PageRef = 2, nil
+			m.Assurance.Details = append(m.Assurance.Details, d)
+		}, want: "duplicate question ref 11"},
+		{name: "duplicate page ref", change: func(m *packageManifest, _ map[string][]byte) {
+			m.Assurance.Pages = append(m.Assurance.Pages, m.Assurance.Pages[0])
+		}, want: "duplicate page ref 7"},
+		{name: "unknown page ref", change: func(m *packageManifest, _ map[string][]byte) {
+			other := uint(99)
+			m.Assurance.Details[0].PageRef = &other
+		}, want: "unknown page 99"},
+		{name: "main image missing", change: func(_ *packageManifest, files map[string][]byte) { delete(files, "images/main.png") }, want: "assurance image"},
+		{name: "page image missing", change: func(_ *packageManifest, files map[string][]byte) { delete(files, "images/page-7.png") }, want: "page image"},
+		{name: "too many pages", change: func(m *packageManifest, _ map[string][]byte) {
+			for i := 0; i < packageMaxPages; i++ {
+				m.Assurance.Pages = append(m.Assurance.Pages, packagePage{Ref: uint(100 + i), Image: "images/page-7.png"})
+			}
+		}, want: "pages (max"},
+		{name: "plain manifest without images", plain: true, want: "must be a ZIP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, files := validAssurancePackage()
+			if tt.change != nil {
+				tt.change(&manifest, files)
+			}
+			pkg := buildPackage(t, manifest, files)
+			if tt.plain {
+				pkg, _ = json.Marshal(manifest)
+			}
+			rec := postPackage(r, "/mstr-inspections/import", pkg, map[string]string{"dry_run": "false"})
+			if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("status = %d, body = %s, want 422 containing %q", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+
+	var count int64
+	db.Model(&models.MstrInspection{}).Count(&count)
+	if count != 0 || len(bucketObjects(t, t.Name())) != 0 {
+		t.Errorf("rejected packages left %d inspections and %d objects", count, len(bucketObjects(t, t.Name())))
+	}
+
+	// Questionnaire tetap boleh dikirim sebagai manifest JSON saja
+	rq := adminRouter("COMP-A")
+	rq.POST("/questionnaires/import", ImportQuestionnaire)
+	plain, _ := json.Marshal(packageManifest{FormatVersion: packageFormatVersion, Kind: packageKindQuestionnaire, Questionnaire: &packageQuestionnaire{
+		Title: "Survey", Questions: []packageQuestion{{Ref: 1, Text: "Nama", Type: "text"}},
+	}})
+	if rec := postPackage(rq, "/questionnaires/import", plain, nil); rec.Code != http.StatusOK {
+		t.Errorf("plain questionnaire manifest: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+// Satu entry ZIP yang dipakai banyak halaman dihitung per halaman terhadap batas total
+func TestCheckPackageManifestImageBytes(t *testing.T) {
+	manifest, files := validAssurancePackage()
+	pkg := buildPackage(t, manifest, files)
+	zr, err := zip.NewReader(bytes.NewReader(pkg), int64(len(pkg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]*zip.File)
+	for _, zf := range zr.File {
+		entries[zf.Name] = zf
+	}
+	entries["images/page-7.png"].UncompressedSize64 = packageMaxImageBytes / 4
+
+	if err := checkPackageManifest(&manifest, packageKindAssurance, entries); err != nil {
+		t.Fatalf("single page: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		manifest.Assurance.Pages = append(manifest.Assurance.Pages, packagePage{Ref: uint(100 + i), Image: "images/page-7.png"})
+	}
+	if err := checkPackageManifest(&manifest, packageKindAssurance, entries); err == nil || !strings.Contains(err.Error(), "in total") {
+		t.Errorf("err = %v, want total image size error", err)
+	}
+}
+
+// Gambar yang sudah ter-upload dihapus lagi jika import gagal di tengah jalan
+func TestImportPackageCleansUpOnFailure(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+	manifest, files := validAssurancePackage()
+	// Gambar utama masih muat, gambar halaman melewati quota
+	mustCreate(t, db, &models.MstrCompanyQuota{CompanyID: "COMP-A", MaxStorageBytes: int64(len(files["images/main.png"]) + 4)})
+
+	r := adminRouter("COMP-A")
+	r.POST("/mstr-inspections/import", ImportMstrInspection)
+	rec := postPackage(r, "/mstr-inspections/import", buildPackage(t, manifest, files), map[string]string{"dry_run": "false"})
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	if objects := bucketObjects(t, t.Name()); len(objects) != 0 {
+		t.Errorf("uploaded images left behind: %+v", objects)
+	}
+	if used := companyStorageBytes("COMP-A"); used != 0 {
+		t.Errorf("storage usage = %d, want 0 after cleanup", used)
+	}
+	var count int64
+	db.Model(&models.MstrInspection{}).Count(&count)
+	if count != 0 {
+		t.Errorf("inspections = %d, want 0", count)
+	}
+}
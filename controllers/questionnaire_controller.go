@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,6 +14,7 @@ import (
 	"go-api/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -28,6 +27,8 @@ type CreateQuestionnaireReq struct {
 	CompanyID   string `json:"company_id"`
 	IsActive    bool   `json:"is_active"`
 	CreatedBy   string `json:"created_by"`
+
+	PassThreshold float64 `json:"pass_threshold"` // persen, 0 = tanpa threshold
 }
 
 func CreateQuestionnaire(c *gin.Context) {
@@ -37,11 +38,17 @@ func CreateQuestionnaire(c *gin.Context) {
 		return
 	}
 
+	if !checkPassThreshold(req.PassThreshold) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "pass_threshold must be between 0 and 100"})
+		return
+	}
+
 	q := models.Questionnaire{
-		Title:       req.Title,
-		Description: req.Description,
-		Type:        req.Type,
-		IsActive:    req.IsActive,
+		Title:         req.Title,
+		Description:   req.Description,
+		Type:          req.Type,
+		IsActive:      req.IsActive,
+		PassThreshold: req.PassThreshold,
 	}
 
 	userCompanyID, _ := c.Get("company_id")
@@ -58,16 +65,8 @@ func CreateQuestionnaire(c *gin.Context) {
 }
 
 func ListQuestionnaires(c *gin.Context) {
-	role, _ := c.Get("role")
-	userCompanyID, _ := c.Get("company_id")
-
-	// mulai query dari DB
-	query := config.DB.Preload("Questions.Options").Order("id desc")
-
-	// filter jika bukan super-admin
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	// mulai query dari DB, dibatasi company user (kecuali super-admin)
+	query := config.DB.Preload("Questions.Options").Scopes(utils.CompanyScope(c)).Order("id desc")
 
 	var list []models.Questionnaire
 	if err := query.Find(&list).Error; err != nil {
@@ -87,7 +86,7 @@ func ListQuestionnaires(c *gin.Context) {
 func GetQuestionnaire(c *gin.Context) {
 	id := c.Param("id")
 	var q models.Questionnaire
-	if err := config.DB.Preload("Questions.Options").First(&q, id).Error; err != nil {
+	if err := utils.TenantDB(c).Preload("Questions.Options").First(&q, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "questionnaire not found"})
 			return
@@ -105,6 +104,8 @@ type UpdateQuestionnaireReq struct {
 	CompanyID   *string `json:"company_id"`
 	IsActive    *bool   `json:"is_active"`
 	UpdatedBy   *string `json:"updated_by"`
+
+	PassThreshold *float64 `json:"pass_threshold"`
 }
 
 func UpdateQuestionnaire(c *gin.Context) {
@@ -116,7 +117,7 @@ func UpdateQuestionnaire(c *gin.Context) {
 	}
 
 	var q models.Questionnaire
-	if err := config.DB.First(&q, id).Error; err != nil {
+	if err := utils.TenantDB(c).First(&q, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "questionnaire not found"})
 		return
 	}
@@ -133,6 +134,13 @@ func UpdateQuestionnaire(c *gin.Context) {
 	if body.IsActive != nil {
 		q.IsActive = *body.IsActive
 	}
+	if body.PassThreshold != nil {
+		if !checkPassThreshold(*body.PassThreshold) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "pass_threshold must be between 0 and 100"})
+			return
+		}
+		q.PassThreshold = *body.PassThreshold
+	}
 	// Update field
 	userCompanyID, _ := c.Get("company_id")
 	q.CompanyID = userCompanyID.(string)
@@ -152,6 +160,12 @@ func DeleteQuestionnaire(c *gin.Context) {
 
 	deletedBy := c.GetString("username")
 
+	var q models.Questionnaire
+	if err := utils.TenantDB(c).First(&q, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "questionnaire not found"})
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.Questionnaire{}).
 		Where("id = ?", id).
@@ -170,15 +184,30 @@ func DeleteQuestionnaire(c *gin.Context) {
 // ---------- Question CRUD ----------
 
 type OptionReq struct {
-	Label     string `json:"label"`      // A..E
-	Text      string `json:"text"`       // option text
-	IsCorrect bool   `json:"is_correct"` // optional
+	Label     string   `json:"label"`      // A..E
+	Text      string   `json:"text"`       // option text
+	IsCorrect bool     `json:"is_correct"` // optional
+	Score     *float64 `json:"score"`      // optional, 0..1 dari weight question
 }
 
 type CreateQuestionReq struct {
 	Text    string      `json:"text" binding:"required"`
 	Type    string      `json:"type" binding:"required"` // yesno|multiple|essay|image
 	Options []OptionReq `json:"options"`                 // only for multiple
+
+	ValidationRules  datatypes.JSON `json:"validation_rules"`  // optional, lihat utils.ValidationRules
+	DisplayCondition datatypes.JSON `json:"display_condition"` // optional, lihat utils.DisplayCondition
+
+	Weight     float64 `json:"weight"`      // optional, default 1
+	IsCritical bool    `json:"is_critical"` // jawaban salah = submission gagal
+}
+
+func optionReqScoring(options []OptionReq) []utils.ScoreOption {
+	scoring := make([]utils.ScoreOption, 0, len(options))
+	for _, o := range options {
+		scoring = append(scoring, utils.ScoreOption{Label: o.Label, IsCorrect: o.IsCorrect, Score: o.Score})
+	}
+	return scoring
 }
 
 func CreateQuestion(c *gin.Context) {
@@ -191,15 +220,38 @@ func CreateQuestion(c *gin.Context) {
 	}
 
 	qt := strings.ToLower(req.Type)
-	if qt != "yesno" && qt != "multiple" && qt != "text" && qt != "number" && qt != "image" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid question type"})
+	if !utils.ValidQuestionType(qt) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": utils.QuestionTypeError(req.Type).Error()})
+		return
+	}
+	if err := utils.CheckValidationRules(qt, req.ValidationRules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if err := utils.CheckScoring(qt, req.Weight, req.IsCritical, optionReqScoring(req.Options)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	// Questionnaire harus milik company user
+	var qn models.Questionnaire
+	if err := utils.TenantDB(c).Select("id").First(&qn, qnID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "questionnaire not found"})
 		return
 	}
 
 	newQ := models.Question{
-		QuestionnaireID: parseUint(qnID),
-		Text:            req.Text,
-		Type:            qt,
+		QuestionnaireID:  parseUint(qnID),
+		Text:             req.Text,
+		Type:             qt,
+		ValidationRules:  req.ValidationRules,
+		DisplayCondition: req.DisplayCondition,
+		Weight:           req.Weight,
+		IsCritical:       req.IsCritical,
+	}
+	if err := checkQuestionnaireConditions(config.DB, newQ); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
 	}
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
@@ -207,8 +259,8 @@ func CreateQuestion(c *gin.Context) {
 			return err
 		}
 
-		// For multiple choice, save options (A..E)
-		if qt == "multiple" {
+		// For multiple choice / checkbox, save options (A..E)
+		if utils.QuestionTypeHasOptions(qt) {
 			if len(req.Options) < 2 {
 				return errors.New("multiple choice requires at least 2 options")
 			}
@@ -223,6 +275,7 @@ func CreateQuestion(c *gin.Context) {
 					Label:      lbl,
 					Text:       o.Text,
 					IsCorrect:  o.IsCorrect,
+					Score:      o.Score,
 				})
 			}
 			if err := tx.Create(&opts).Error; err != nil {
@@ -244,6 +297,20 @@ type UpdateQuestionReq struct {
 	Text    *string      `json:"text"`
 	Type    *string      `json:"type"`    // if changed to multiple, must also send options
 	Options *[]OptionReq `json:"options"` // replace all options
+
+	ValidationRules  *datatypes.JSON `json:"validation_rules"`  // replace rules ({} = tanpa rules)
+	DisplayCondition *datatypes.JSON `json:"display_condition"` // replace kondisi ({} = selalu tampil)
+
+	Weight     *float64 `json:"weight"`
+	IsCritical *bool    `json:"is_critical"`
+}
+
+// deleteQuestionOptions soft delete option lama, deleted_at dipakai delta bundle device
+func deleteQuestionOptions(tx *gorm.DB, questionID uint, username string) error {
+	if err := tx.Model(&models.Option{}).Where("question_id = ?", questionID).Update("deleted_by", username).Error; err != nil {
+		return err
+	}
+	return tx.Where("question_id = ?", questionID).Delete(&models.Option{}).Error
 }
 
 func UpdateQuestion(c *gin.Context) {
@@ -255,7 +322,7 @@ func UpdateQuestion(c *gin.Context) {
 	}
 
 	var q models.Question
-	if err := config.DB.Preload("Options").First(&q, id).Error; err != nil {
+	if err := utils.TenantChildDB(c, "questionnaire_id", "questionnaires").Preload("Options").First(&q, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "question not found"})
 		return
 	}
@@ -266,25 +333,51 @@ func UpdateQuestion(c *gin.Context) {
 		}
 		if body.Type != nil {
 			t := strings.ToLower(*body.Type)
-			if t != "yesno" && t != "multiple" && t != "text" && t != "number" && t != "image" {
-				return errors.New("invalid question type")
+			if !utils.ValidQuestionType(t) {
+				return utils.QuestionTypeError(*body.Type)
 			}
 			q.Type = t
 		}
+		if body.ValidationRules != nil {
+			q.ValidationRules = *body.ValidationRules
+		}
+		if err := utils.CheckValidationRules(q.Type, q.ValidationRules); err != nil {
+			return err
+		}
+		if body.DisplayCondition != nil {
+			q.DisplayCondition = *body.DisplayCondition
+		}
+		if err := checkQuestionnaireConditions(tx, q); err != nil {
+			return err
+		}
+		if body.Weight != nil {
+			q.Weight = *body.Weight
+		}
+		if body.IsCritical != nil {
+			q.IsCritical = *body.IsCritical
+		}
+		// option yang dinilai = option baru jika dikirim, selain itu option yang tersimpan
+		scoring := optionScoring(q.Options)
+		if body.Options != nil {
+			scoring = optionReqScoring(*body.Options)
+		}
+		if err := utils.CheckScoring(q.Type, q.Weight, q.IsCritical, scoring); err != nil {
+			return err
+		}
 		if err := tx.Save(&q).Error; err != nil {
 			return err
 		}
 
 		// Replace options if provided (for multiple)
 		if body.Options != nil {
-			if q.Type != "multiple" {
+			if !utils.QuestionTypeHasOptions(q.Type) {
 				// if type not multiple, options should be cleared
-				if err := tx.Where("question_id = ?", q.ID).Delete(&models.Option{}).Error; err != nil {
+				if err := deleteQuestionOptions(tx, q.ID, c.GetString("username")); err != nil {
 					return err
 				}
 			} else {
 				// delete old
-				if err := tx.Where("question_id = ?", q.ID).Delete(&models.Option{}).Error; err != nil {
+				if err := deleteQuestionOptions(tx, q.ID, c.GetString("username")); err != nil {
 					return err
 				}
 				// insert new
@@ -295,6 +388,7 @@ func UpdateQuestion(c *gin.Context) {
 						Label:      strings.ToUpper(o.Label),
 						Text:       o.Text,
 						IsCorrect:  o.IsCorrect,
+						Score:      o.Score,
 					})
 				}
 				if len(opts) < 2 {
@@ -320,6 +414,25 @@ func DeleteQuestion(c *gin.Context) {
 	id := c.Param("id")
 
 	deletedBy := c.GetString("username")
+
+	var q models.Question
+	if err := utils.TenantChildDB(c, "questionnaire_id", "questionnaires").First(&q, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "question not found"})
+		return
+	}
+
+	// Question yang dipakai display_condition question lain tidak boleh dihapus
+	var dependents []models.Question
+	config.DB.Select("id", "display_condition").Where("questionnaire_id = ? AND id <> ?", q.QuestionnaireID, q.ID).Find(&dependents)
+	conditional := make([]utils.ConditionalQuestion, 0, len(dependents))
+	for _, d := range dependents {
+		conditional = append(conditional, utils.ConditionalQuestion{ID: d.ID, Condition: d.DisplayCondition})
+	}
+	if err := utils.CheckDisplayConditions(conditional); err != nil {
+		utils.JSONError(c, http.StatusConflict, "question is used by another question's display condition: "+err.Error())
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.Question{}).
 		Where("id = ?", id).
@@ -348,7 +461,7 @@ func SubmitAnswer(c *gin.Context) {
 	questionID := parseUint(c.Param("id"))
 
 	var question models.Question
-	if err := config.DB.Preload("Options").First(&question, questionID).Error; err != nil {
+	if err := utils.TenantChildDB(c, "questionnaire_id", "questionnaires").Preload("Options").First(&question, questionID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "question not found"})
 		return
 	}
@@ -368,13 +481,20 @@ func SubmitAnswer(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "file is required"})
 			return
 		}
-		// only valid for image type
-		if question.Type != "image" {
+		// only valid for image / signature type
+		if !utils.IsFileQuestionType(question.Type) {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "this question does not accept image"})
 			return
 		}
+		in := utils.AnswerInput{HasFile: true, FileSize: file.Size, FileType: answerFileType(file.Header.Get("Content-Type"), sniffMultipartFile(file))}
+		if !validateSingleAnswer(c, question, parseUint(userIDStr), in) {
+			return
+		}
 		path, err := saveUploadedFile(c, file, "uploads")
 		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
@@ -396,44 +516,20 @@ func SubmitAnswer(c *gin.Context) {
 		}
 
 		normalized := strings.TrimSpace(body.AnswerText)
-		switch question.Type {
-		case "yesno":
-			up := strings.ToUpper(normalized)
-			if up != "YES" && up != "NO" {
-				c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "answer must be Yes or No"})
-				return
-			}
-		case "multiple":
-			up := strings.ToUpper(normalized)
-			if up == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "answer must be option label (A..E)"})
-				return
-			}
-			valid := false
-			for _, o := range question.Options {
-				if strings.ToUpper(o.Label) == up {
-					valid = true
-					break
-				}
-			}
-			if !valid {
-				c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid option label"})
-				return
-			}
-		case "essay":
-			if normalized == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "essay cannot be empty"})
-				return
-			}
-		case "image":
-			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "image answer must be multipart/form-data with file"})
+		if utils.IsFileQuestionType(question.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": question.Type + " answer must be multipart/form-data with file"})
+			return
+		}
+		if !validateSingleAnswer(c, question, body.UserID, utils.AnswerInput{Text: normalized}) {
 			return
 		}
 
+		answerText, answerJSON := utils.NormalizeAnswer(question.Type, normalized)
 		ans = models.Answer{
 			QuestionID: question.ID,
 			UserID:     body.UserID,
-			AnswerText: normalized,
+			AnswerText: answerText,
+			AnswerJSON: answerJSON,
 		}
 	}
 
@@ -446,6 +542,13 @@ func SubmitAnswer(c *gin.Context) {
 
 func ListAnswers(c *gin.Context) {
 	qid := parseUint(c.Param("id"))
+
+	var question models.Question
+	if err := utils.TenantChildDB(c, "questionnaire_id", "questionnaires").Select("id").First(&question, qid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "question not found"})
+		return
+	}
+
 	var list []models.Answer
 	if err := config.DB.Where("question_id = ?", qid).Order("id desc").Find(&list).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
@@ -462,23 +565,27 @@ func parseUint(s string) uint {
 	return id
 }
 
+// saveUploadedFile upload ke storage company (bukan disk server) dan return object key
 func saveUploadedFile(c *gin.Context, fileHeader *multipart.FileHeader, baseDir string) (string, error) {
-	_ = os.MkdirAll(baseDir, 0755)
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if ext == "" {
-		ext = ".bin"
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
 	}
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), sanitize(fileHeader.Filename))
-	fullpath := filepath.Join(baseDir, filename)
+	defer file.Close()
 
-	if err := saveFile(c, fileHeader, fullpath); err != nil {
+	hr, err := hashEvidenceFile(file)
+	if err != nil {
 		return "", err
 	}
-	return fullpath, nil
-}
 
-func saveFile(c *gin.Context, fh *multipart.FileHeader, dst string) error {
-	return c.SaveUploadedFile(fh, dst)
+	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), sanitize(fileHeader.Filename))
+	folder := "Assurance/" + c.GetString("company_id") + "/" + baseDir
+	objectKey, err := UploadFileToE2(c, file, filename, fileHeader.Header.Get("Content-Type"), folder, nil)
+	if err != nil {
+		return "", err
+	}
+	recordEvidenceMetadata(c.GetString("company_id"), objectKey, fileHeader.Header.Get("Content-Type"), c.GetString("username"), hr)
+	return objectKey, nil
 }
 
 func sanitize(s string) string {
@@ -504,12 +611,21 @@ type UserAnswerFlat struct {
 	AnswerID     uint   `json:"answer_id,omitempty"`
 	AnswerText   string `json:"answer_text,omitempty"`
 	AnswerFile   string `json:"answer_file,omitempty"`
+
+	AnswerJSON datatypes.JSON `json:"answer_json,omitempty"`
+	Selected   bool           `json:"selected,omitempty"` // option ini dipilih (multiple / checkbox)
 }
 
 func GetUserAnswersFlat(c *gin.Context) {
 	questionnaireID := parseUint(c.Param("id"))
 	userID := parseUint(c.Param("userId"))
 
+	var questionnaire models.Questionnaire
+	if err := utils.TenantDB(c).Select("id").First(&questionnaire, questionnaireID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "questionnaire not found"})
+		return
+	}
+
 	var results []UserAnswerFlat
 
 	err := config.DB.Table("questions").
@@ -521,9 +637,10 @@ func GetUserAnswersFlat(c *gin.Context) {
             options.text as option_text,
             answers.id as answer_id,
             answers.answer_text,
-            answers.answer_file
+            answers.answer_file,
+            answers.answer_json
         `).
-		Joins("LEFT JOIN options ON options.question_id = questions.id").
+		Joins("LEFT JOIN options ON options.question_id = questions.id AND options.deleted_at IS NULL").
 		Joins("LEFT JOIN answers ON answers.question_id = questions.id AND answers.user_id = ?", userID).
 		Where("questions.questionnaire_id = ?", questionnaireID).
 		Order("questions.id, options.label").
@@ -534,6 +651,18 @@ func GetUserAnswersFlat(c *gin.Context) {
 		return
 	}
 
+	for i := range results {
+		r := &results[i]
+		if r.OptionLabel == "" || !utils.QuestionTypeHasOptions(r.QuestionType) {
+			continue
+		}
+		for _, label := range utils.SplitAnswerLabels(r.AnswerText) {
+			if strings.EqualFold(label, r.OptionLabel) {
+				r.Selected = true
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   results,
@@ -545,7 +674,7 @@ func GetUserAnswers(c *gin.Context) {
 	userID := parseUint(c.Param("userId"))
 
 	var questionnaire models.Questionnaire
-	err := config.DB.
+	err := utils.TenantDB(c).
 		Preload("Questions.Options").                        // preload pertanyaan + opsi
 		Preload("Questions.Answers", "user_id = ?", userID). // preload jawaban user tertentu
 		First(&questionnaire, questionnaireID).Error
@@ -587,11 +716,25 @@ func SubmitAllAnswers(c *gin.Context) {
 		return
 	}
 
+	// Validasi semua jawaban dulu (question lintas questionnaire, jadi required tidak dicek)
+	questionIDs := make([]uint, 0, len(payloads))
+	submitted := make([]submittedAnswer, 0, len(payloads))
+	for _, p := range payloads {
+		questionIDs = append(questionIDs, p.QuestionID)
+		submitted = append(submitted, submittedAnswer{QuestionID: p.QuestionID, Text: p.AnswerText, FileKey: p.AnswerFile})
+	}
+	var questions []models.Question
+	utils.TenantChildDB(c, "questionnaire_id", "questionnaires").Preload("Options").Where("id IN ?", questionIDs).Find(&questions)
+	if errs := validateQuestionnaireAnswers(c, c.GetString("company_id"), "", questions, submitted, false); len(errs) > 0 {
+		utils.JSONAnswerErrors(c, errs)
+		return
+	}
+
 	var allAnswers []models.Answer
 
 	for _, p := range payloads {
 		var question models.Question
-		if err := config.DB.Preload("Options").First(&question, p.QuestionID).Error; err != nil {
+		if err := utils.TenantChildDB(c, "questionnaire_id", "questionnaires").Preload("Options").First(&question, p.QuestionID).Error; err != nil {
 			utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("Question %d not found", p.QuestionID))
 			return
 		}
@@ -600,9 +743,9 @@ func SubmitAllAnswers(c *gin.Context) {
 		answer.QuestionID = p.QuestionID
 		answer.UserID = userID
 
-		if question.Type == "image" {
+		if utils.IsFileQuestionType(question.Type) {
 			if p.AnswerFile == "" {
-				utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("answer_file required for image question %d", p.QuestionID))
+				utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("answer_file required for %s question %d", question.Type, p.QuestionID))
 				return
 			}
 
@@ -622,18 +765,16 @@ func SubmitAllAnswers(c *gin.Context) {
 			fileKey := GenerateE2ObjectKey(c, "Trn-Questionnaire", fileHeader.Filename)
 			objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance", nil)
 			if err != nil {
+				if respondQuotaError(c, err) {
+					return
+				}
 				utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
 				return
 			}
 
 			answer.AnswerFile = objectKey
 		} else {
-			normalized := strings.TrimSpace(p.AnswerText)
-			if question.Type == "essay" && normalized == "" {
-				utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("Essay cannot be empty for question %d", p.QuestionID))
-				return
-			}
-			answer.AnswerText = normalized
+			answer.AnswerText, answer.AnswerJSON = utils.NormalizeAnswer(question.Type, p.AnswerText)
 		}
 
 		allAnswers = append(allAnswers, answer)
@@ -661,6 +802,13 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 		return
 	}
 
+	// Dengan credential device, submit hanya atas nama device itu sendiri
+	deviceID, err := utils.AuthDeviceID(c, deviceID)
+	if err != nil {
+		utils.JSONError(c, http.StatusForbidden, "Device key does not match device_id")
+		return
+	}
+
 	userID := parseUint(userIDStr)
 	questionnaireID := parseUint(questionnaireIDStr)
 	chaningID := parseUint(chaningIDStr)
@@ -677,6 +825,44 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 		return
 	}
 
+	// Retry dari tablet dengan submission_uuid yang sama → kembalikan hasil submit pertama
+	submissionID, err := submissionUUID(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if submissionID != "" && replayMstrAnswer(c, submissionID) {
+		return
+	}
+
+	if err := checkSubmissionQuota(userCompanyID); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
+	// Questionnaire harus milik company user
+	var questionnaire models.Questionnaire
+	if err := utils.TenantDB(c).Select("id", "pass_threshold").First(&questionnaire, questionnaireID).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Questionnaire not found")
+		return
+	}
+
+	// Validasi semua jawaban (termasuk question wajib yang tidak dijawab) sebelum ada yang disimpan
+	var questions []models.Question
+	if err := config.DB.Preload("Options").Where("questionnaire_id = ?", questionnaireID).Find(&questions).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	submitted := make([]submittedAnswer, 0, len(payloads))
+	for _, p := range payloads {
+		submitted = append(submitted, submittedAnswer{QuestionID: p.QuestionID, Text: p.AnswerText, FileKey: p.AnswerFile})
+	}
+	if errs := validateQuestionnaireAnswers(c, userCompanyID, submissionID, questions, submitted, true); len(errs) > 0 {
+		utils.JSONAnswerErrors(c, errs)
+		return
+	}
+	score := scoreQuestionnaire(questions, submitted, questionnaire.PassThreshold)
+
 	// Gunakan transaksi
 	tx := config.DB.Begin()
 
@@ -688,9 +874,25 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 		ChainingID:      chaningID,
 		CreatedBy:       username,
 		UpdatedBy:       username,
+
+		Score:          score.Score,
+		MaxScore:       score.MaxScore,
+		ScorePercent:   score.Percent,
+		Passed:         score.Passed,
+		CriticalFailed: len(score.CriticalFailed) > 0,
+	}
+	if submissionID != "" {
+		master.SubmissionUUID = &submissionID
 	}
 	if err := tx.Create(&master).Error; err != nil {
 		tx.Rollback()
+		if submissionID != "" && replayMstrAnswer(c, submissionID) {
+			return
+		}
+		if submissionID != "" {
+			utils.JSONError(c, http.StatusConflict, "submission_uuid already used")
+			return
+		}
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -699,7 +901,7 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 
 	for _, p := range payloads {
 		var question models.Question
-		if err := tx.First(&question, p.QuestionID).Error; err != nil {
+		if err := tx.Where("questionnaire_id = ?", questionnaireID).First(&question, p.QuestionID).Error; err != nil {
 			tx.Rollback()
 			utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("Question %d not found", p.QuestionID))
 			return
@@ -712,27 +914,20 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 			UpdatedBy:      username,
 		}
 
-		if question.Type == "image" {
-			fileHeader, err := c.FormFile(p.AnswerFile)
+		if utils.IsFileQuestionType(question.Type) {
+			evidence, err := storeSubmissionFile(c, submissionID, p.AnswerFile, moduleTrnQuestionnaire)
 			if err != nil {
 				tx.Rollback()
-				utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("File missing: %v", err))
-				return
-			}
-			file, _ := fileHeader.Open()
-			defer file.Close()
-
-			fileKey := GenerateE2ObjectKey(c, "Trn-Questionnaire", fileHeader.Filename)
-			objectKey, err := UploadFileToE2(c, file, fileKey, fileHeader.Header.Get("Content-Type"), "Assurance/"+userCompanyID, nil)
-			if err != nil {
-				tx.Rollback()
-				utils.JSONError(c, http.StatusInternalServerError, "Failed upload: "+err.Error())
+				if respondQuotaError(c, err) {
+					return
+				}
+				utils.JSONError(c, http.StatusBadRequest, "Failed upload: "+err.Error())
 				return
 			}
 
-			detail.AnswerFile = objectKey
+			detail.AnswerFile = evidence.ObjectKey
 		} else {
-			detail.AnswerText = strings.TrimSpace(p.AnswerText)
+			detail.AnswerText, detail.AnswerJSON = utils.NormalizeAnswer(question.Type, p.AnswerText)
 		}
 
 		details = append(details, detail)
@@ -747,3 +942,14 @@ func SubmitAllAnswersWithMaster(c *gin.Context) {
 	tx.Commit()
 	utils.JSONSuccess(c, "All answers submitted", master)
 }
+
+// replayMstrAnswer mengirim ulang master answer untuk submission_uuid yang sudah tersimpan
+func replayMstrAnswer(c *gin.Context, submissionID string) bool {
+	var existing models.MstrAnswer
+	if err := utils.TenantDB(c).Where("submission_uuid = ?", submissionID).First(&existing).Error; err != nil {
+		return false
+	}
+	c.Header("Idempotent-Replayed", "true")
+	utils.JSONSuccess(c, "All answers already submitted", existing)
+	return true
+}
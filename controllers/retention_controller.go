@@ -0,0 +1,578 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	jobRetentionPurge = "retention_purge"
+
+	retentionBatchSize       = 200
+	defaultRetentionInterval = 24 * time.Hour
+)
+
+// requestCompanyID: platform admin boleh memilih company lewat ?company_id, user lain selalu company sendiri
+func requestCompanyID(c *gin.Context) string {
+	if companyID, restricted := utils.TenantCompanyID(c); restricted {
+		return companyID
+	}
+	if companyID := c.Query("company_id"); companyID != "" {
+		return companyID
+	}
+	return c.GetString("company_id")
+}
+
+// GET /retention-policy?company_id= → policy company (default jika belum pernah diset)
+func GetRetentionPolicy(c *gin.Context) {
+	companyID := requestCompanyID(c)
+
+	var policy models.MstrRetentionPolicy
+	err := config.DB.Where("company_id = ?", companyID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		policy = models.MstrRetentionPolicy{CompanyID: companyID, DeletedRecordDays: 30}
+	} else if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Retention policy", policy)
+}
+
+type RetentionPolicyReq struct {
+	TrxInspectionDays       *int  `json:"trx_inspection_days"`
+	QuestionnaireAnswerDays *int  `json:"questionnaire_answer_days"`
+	EvidenceDays            *int  `json:"evidence_days"`
+	DeletedRecordDays       *int  `json:"deleted_record_days"`
+	IsActive                *bool `json:"is_active"`
+}
+
+// PUT /retention-policy?company_id= → buat / ubah policy company
+func UpsertRetentionPolicy(c *gin.Context) {
+	companyID := requestCompanyID(c)
+
+	var req RetentionPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, days := range []*int{req.TrxInspectionDays, req.QuestionnaireAnswerDays, req.EvidenceDays, req.DeletedRecordDays} {
+		if days != nil && *days < 0 {
+			utils.JSONError(c, http.StatusBadRequest, "retention days must be 0 (keep forever) or greater")
+			return
+		}
+	}
+
+	var company models.MstrCompany
+	if err := config.DB.Where("company_id = ?", companyID).First(&company).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Company not found")
+		return
+	}
+
+	username := c.GetString("username")
+	var policy models.MstrRetentionPolicy
+	err := config.DB.Where("company_id = ?", companyID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		policy = models.MstrRetentionPolicy{CompanyID: companyID, DeletedRecordDays: 30, IsActive: true, CreatedBy: username}
+	} else if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if req.TrxInspectionDays != nil {
+		policy.TrxInspectionDays = *req.TrxInspectionDays
+	}
+	if req.QuestionnaireAnswerDays != nil {
+		policy.QuestionnaireAnswerDays = *req.QuestionnaireAnswerDays
+	}
+	if req.EvidenceDays != nil {
+		policy.EvidenceDays = *req.EvidenceDays
+	}
+	if req.DeletedRecordDays != nil {
+		policy.DeletedRecordDays = *req.DeletedRecordDays
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+	policy.UpdatedBy = username
+
+	if policy.Id == 0 {
+		err = config.DB.Create(&policy).Error
+		// is_active punya default:true, false tidak ikut ter-insert oleh Create
+		if err == nil && !policy.IsActive {
+			err = config.DB.Model(&policy).Update("is_active", false).Error
+		}
+	} else {
+		// pakai map supaya nilai 0 / false ikut tersimpan
+		err = config.DB.Model(&policy).Updates(map[string]interface{}{
+			"trx_inspection_days":       policy.TrxInspectionDays,
+			"questionnaire_answer_days": policy.QuestionnaireAnswerDays,
+			"evidence_days":             policy.EvidenceDays,
+			"deleted_record_days":       policy.DeletedRecordDays,
+			"is_active":                 policy.IsActive,
+			"updated_by":                username,
+		}).Error
+	}
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Retention policy saved", policy)
+}
+
+type LegalHoldReq struct {
+	LegalHold bool   `json:"legal_hold"`
+	Reason    string `json:"reason"`
+}
+
+// PUT /trx-inspections/:id/legal-hold → pasang / lepas legal hold
+func SetTRXInspectionLegalHold(c *gin.Context) {
+	var req LegalHoldReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.LegalHold && req.Reason == "" {
+		utils.JSONError(c, http.StatusBadRequest, "reason is required to place a legal hold")
+		return
+	}
+
+	var inspection models.TrxInspection
+	if err := utils.TenantDB(c).First(&inspection, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "TRX Inspection not found")
+		return
+	}
+
+	updates := map[string]interface{}{
+		"legal_hold":        req.LegalHold,
+		"legal_hold_reason": req.Reason,
+		"legal_hold_by":     c.GetString("username"),
+		"legal_hold_at":     nil,
+	}
+	if req.LegalHold {
+		updates["legal_hold_at"] = time.Now()
+	}
+	if err := config.DB.Model(&inspection).Updates(updates).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	message := "Legal hold released"
+	if req.LegalHold {
+		message = "Legal hold placed"
+	}
+	utils.JSONSuccess(c, message, inspection)
+}
+
+// POST /jobs/retention-purge?company_id=&dry_run=true → jalankan purge sekarang (dry run hanya menghitung)
+func StartRetentionPurge(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	run, err := startJobRun(models.TrxJobRun{
+		JobName:   jobRetentionPurge,
+		CompanyID: c.Query("company_id"),
+		DryRun:    dryRun,
+		CreatedBy: c.GetString("username"),
+	}, runRetentionPurge)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errJobAlreadyRunning) {
+			status = http.StatusConflict
+		}
+		utils.JSONError(c, status, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Retention purge started", "data": run})
+}
+
+// StartRetentionScheduler menjalankan purge berkala (RETENTION_PURGE_INTERVAL, default 24h, "0" = nonaktif)
+func StartRetentionScheduler() {
+	interval := defaultRetentionInterval
+	if v := os.Getenv("RETENTION_PURGE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("invalid RETENTION_PURGE_INTERVAL %q, using %s", v, interval)
+		} else {
+			interval = d
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			_, err := startJobRun(models.TrxJobRun{JobName: jobRetentionPurge, CreatedBy: "scheduler"}, runRetentionPurge)
+			if err != nil && !errors.Is(err, errJobAlreadyRunning) {
+				log.Printf("retention purge: %v", err)
+			}
+		}
+	}()
+}
+
+// retentionPurge = state purge untuk satu company
+type retentionPurge struct {
+	ctx     context.Context
+	run     *models.TrxJobRun
+	report  *jobReport
+	company *models.MstrCompany
+	backend storage.Backend
+	held    map[uint]bool // trx-inspection legal hold yang dilewati (dihitung sekali per company)
+}
+
+func runRetentionPurge(run *models.TrxJobRun, report *jobReport) error {
+	query := config.DB.Where("is_active = ?", true)
+	if run.CompanyID != "" {
+		query = query.Where("company_id = ?", run.CompanyID)
+	}
+	var policies []models.MstrRetentionPolicy
+	if err := query.Find(&policies).Error; err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		// company yang sudah dihapus tetap diproses supaya file & transaksinya ikut dibersihkan
+		var company models.MstrCompany
+		if err := config.DB.Unscoped().Where("company_id = ?", policy.CompanyID).First(&company).Error; err != nil {
+			report.addError("%s: company not found", policy.CompanyID)
+			continue
+		}
+		backend, err := newCompanyStorage(&company)
+		if err != nil {
+			report.addError("%s: %v", policy.CompanyID, err)
+			continue
+		}
+
+		p := &retentionPurge{ctx: context.Background(), run: run, report: report, company: &company, backend: backend, held: map[uint]bool{}}
+		p.apply(policy)
+
+		config.DB.Model(run).Updates(map[string]interface{}{
+			"processed": run.Processed,
+			"succeeded": run.Succeeded,
+			"failed":    run.Failed,
+		})
+	}
+	return nil
+}
+
+func retentionCutoff(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
+
+func (p *retentionPurge) apply(policy models.MstrRetentionPolicy) {
+	cid := p.company.CompanyID
+
+	// 1. company sudah dihapus & lewat masa tenggang → semua transaksi & file company
+	if p.company.DeletedAt.Valid && policy.DeletedRecordDays > 0 &&
+		p.company.DeletedAt.Time.Before(retentionCutoff(policy.DeletedRecordDays)) {
+		p.purgeInspections(config.DB.Where("company_id = ?", cid))
+		p.purgeAnswers(config.DB.Where("company_id = ?", cid))
+		p.purgeCompanyPrefix()
+		p.report.count(cid, "held_skipped", len(p.held))
+		return
+	}
+
+	// 2. record yang di-soft delete & lewat masa tenggang
+	if policy.DeletedRecordDays > 0 {
+		cutoff := retentionCutoff(policy.DeletedRecordDays)
+		p.purgeInspections(config.DB.Where("company_id = ? AND deleted_at < ?", cid, cutoff))
+		p.purgeAnswers(config.DB.Where("company_id = ? AND deleted_at < ?", cid, cutoff))
+	}
+
+	// 3. transaksi yang lewat masa simpan
+	if policy.TrxInspectionDays > 0 {
+		p.purgeInspections(config.DB.Where("company_id = ? AND created_at < ?", cid, retentionCutoff(policy.TrxInspectionDays)))
+	}
+	if policy.QuestionnaireAnswerDays > 0 {
+		p.purgeAnswers(config.DB.Where("company_id = ? AND created_at < ?", cid, retentionCutoff(policy.QuestionnaireAnswerDays)))
+	}
+
+	// 4. file evidence yang lewat masa simpan, record transaksi tetap ada
+	if policy.EvidenceDays > 0 {
+		p.expireEvidence(retentionCutoff(policy.EvidenceDays))
+	}
+	p.report.count(cid, "held_skipped", len(p.held))
+}
+
+func (p *retentionPurge) skipHeld(scope *gorm.DB) {
+	var ids []uint
+	config.DB.Unscoped().Model(&models.TrxInspection{}).Where(scope).Where("legal_hold = ?", true).Pluck("id", &ids)
+	for _, id := range ids {
+		p.held[id] = true
+	}
+}
+
+// deleteObjects menghapus file evidence beserta thumbnail/preview dan metadata-nya.
+// Key yang gagal dicatat di report lalu dilewati supaya key lain tetap diproses; return key yang gagal.
+func (p *retentionPurge) deleteObjects(keys []string) map[string]bool {
+	cid := p.company.CompanyID
+	failed := make(map[string]bool)
+	var deleted []string
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if !p.run.DryRun {
+			var size int64
+			if info, err := p.backend.Head(p.ctx, key); err == nil {
+				size = info.Size
+			}
+			if err := p.backend.Delete(p.ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				p.report.addError("%s %s: %v", cid, key, err)
+				failed[key] = true
+				continue
+			}
+			if size > 0 {
+				recordStorageUsage(cid, usageModuleFromKey(key), -size, -1)
+			}
+			if utils.IsImageObject(key, "") {
+				for size := range utils.ImageVariantSizes {
+					p.backend.Delete(p.ctx, utils.ImageVariantKey(key, size))
+				}
+			}
+		}
+		deleted = append(deleted, key)
+	}
+	p.report.count(cid, "objects_deleted", len(deleted))
+	if len(failed) > 0 {
+		p.report.count(cid, "objects_failed", len(failed))
+	}
+
+	if p.run.DryRun || len(deleted) == 0 {
+		return failed
+	}
+	config.DB.Where("company_id = ? AND object_key IN ?", cid, deleted).Delete(&models.TrxEvidenceMetadata{})
+	config.DB.Where("company_id = ? AND object_key IN ?", cid, deleted).Delete(&models.TrxSubmissionEvidence{})
+	return failed
+}
+
+// purgeInspections hard delete trx-inspection (unscoped) beserta detail, jawaban & file-nya. Legal hold dilewati.
+// trx_inspection.image_url tidak dihapus karena dipakai bersama dengan master assurance.
+func (p *retentionPurge) purgeInspections(scope *gorm.DB) {
+	cid := p.company.CompanyID
+
+	p.skipHeld(scope)
+
+	var lastID uint
+	for {
+		var ids []uint
+		err := config.DB.Unscoped().Model(&models.TrxInspection{}).Where(scope).
+			Where("legal_hold = ? AND id > ?", false, lastID).
+			Order("id").Limit(retentionBatchSize).Pluck("id", &ids).Error
+		if err != nil {
+			p.report.addError("%s: %v", cid, err)
+			p.run.Failed++
+			return
+		}
+		if len(ids) == 0 {
+			return
+		}
+		lastID = ids[len(ids)-1]
+		p.run.Processed += len(ids)
+
+		var keys []string
+		config.DB.Unscoped().Model(&models.TrxInspectionDetail{}).
+			Where("id_trx_inspection IN ? AND capture_url <> ''", ids).Pluck("capture_url", &keys)
+		var answerKeys []string
+		config.DB.Unscoped().Model(&models.TrxInspectionAnswer{}).
+			Where("id_trx_inspection_detail IN (?) AND answer_file <> ''",
+				config.DB.Unscoped().Model(&models.TrxInspectionDetail{}).Select("id").Where("id_trx_inspection IN ?", ids)).
+			Pluck("answer_file", &answerKeys)
+		var attachmentKeys []string
+		config.DB.Unscoped().Model(&models.TrxEvidenceAttachment{}).
+			Where("id_trx_inspection IN ? AND object_key <> ''", ids).Pluck("object_key", &attachmentKeys)
+
+		keys = append(keys, answerKeys...)
+		// Record tetap disimpan jika ada file yang gagal, dicoba lagi di run berikutnya
+		if failed := p.deleteObjects(append(keys, attachmentKeys...)); len(failed) > 0 {
+			p.run.Failed += len(ids)
+			continue
+		}
+
+		if !p.run.DryRun {
+			err = config.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Unscoped().Where("id_trx_inspection IN ?", ids).Delete(&models.TrxEvidenceAttachment{}).Error; err != nil {
+					return err
+				}
+				details := tx.Unscoped().Model(&models.TrxInspectionDetail{}).Select("id").Where("id_trx_inspection IN ?", ids)
+				if err := tx.Unscoped().Where("id_trx_inspection_detail IN (?)", details).Delete(&models.TrxInspectionAnswer{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Where("id_trx_inspection IN ?", ids).Delete(&models.TrxInspectionDetail{}).Error; err != nil {
+					return err
+				}
+				return tx.Unscoped().Where("id IN ?", ids).Delete(&models.TrxInspection{}).Error
+			})
+			if err != nil {
+				p.report.addError("%s: %v", cid, err)
+				p.run.Failed += len(ids)
+				continue
+			}
+		}
+		p.run.Succeeded += len(ids)
+		p.report.count(cid, "inspections_deleted", len(ids))
+	}
+}
+
+// purgeAnswers hard delete jawaban questionnaire (unscoped) beserta detail & file-nya
+func (p *retentionPurge) purgeAnswers(scope *gorm.DB) {
+	cid := p.company.CompanyID
+
+	var lastID uint
+	for {
+		var ids []uint
+		err := config.DB.Unscoped().Model(&models.MstrAnswer{}).Where(scope).
+			Where("id > ?", lastID).Order("id").Limit(retentionBatchSize).Pluck("id", &ids).Error
+		if err != nil {
+			p.report.addError("%s: %v", cid, err)
+			p.run.Failed++
+			return
+		}
+		if len(ids) == 0 {
+			return
+		}
+		lastID = ids[len(ids)-1]
+		p.run.Processed += len(ids)
+
+		var keys []string
+		config.DB.Unscoped().Model(&models.MstrAnswerDetail{}).
+			Where("master_answer_id IN ? AND answer_file <> ''", ids).Pluck("answer_file", &keys)
+		if failed := p.deleteObjects(keys); len(failed) > 0 {
+			p.run.Failed += len(ids)
+			continue
+		}
+
+		if !p.run.DryRun {
+			err = config.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Unscoped().Where("master_answer_id IN ?", ids).Delete(&models.MstrAnswerDetail{}).Error; err != nil {
+					return err
+				}
+				return tx.Unscoped().Where("id IN ?", ids).Delete(&models.MstrAnswer{}).Error
+			})
+			if err != nil {
+				p.report.addError("%s: %v", cid, err)
+				p.run.Failed += len(ids)
+				continue
+			}
+		}
+		p.run.Succeeded += len(ids)
+		p.report.count(cid, "answers_deleted", len(ids))
+	}
+}
+
+// expireEvidence menghapus file evidence yang lebih tua dari cutoff dan mengosongkan referensinya
+func (p *retentionPurge) expireEvidence(cutoff time.Time) {
+	cid := p.company.CompanyID
+
+	type evidenceRef struct {
+		ID        uint
+		ObjectKey string
+	}
+	expire := func(table, column string, refs []evidenceRef) {
+		if len(refs) == 0 {
+			return
+		}
+		p.run.Processed += len(refs)
+		keys := make([]string, 0, len(refs))
+		for _, r := range refs {
+			keys = append(keys, r.ObjectKey)
+		}
+		// Referensi hanya dikosongkan untuk file yang benar-benar terhapus
+		failed := p.deleteObjects(keys)
+		ids := make([]uint, 0, len(refs))
+		for _, r := range refs {
+			if failed[r.ObjectKey] {
+				p.run.Failed++
+				continue
+			}
+			ids = append(ids, r.ID)
+		}
+		if len(ids) == 0 {
+			return
+		}
+		if !p.run.DryRun {
+			if err := config.DB.Table(table).Where("id IN ?", ids).Update(column, "").Error; err != nil {
+				p.report.addError("%s: %v", cid, err)
+				p.run.Failed += len(ids)
+				return
+			}
+		}
+		p.run.Succeeded += len(ids)
+		p.report.count(cid, "evidence_expired", len(ids))
+	}
+
+	p.skipHeld(config.DB.Where("company_id = ? AND created_at < ?", cid, cutoff))
+
+	var refs []evidenceRef
+	config.DB.Raw(`
+		SELECT d.id, d.capture_url AS object_key
+		FROM trx_inspection_detail d JOIN trx_inspection t ON t.id = d.id_trx_inspection
+		WHERE t.company_id = ? AND t.created_at < ? AND t.legal_hold = false AND d.capture_url <> ''`,
+		cid, cutoff).Scan(&refs)
+	expire("trx_inspection_detail", "capture_url", refs)
+
+	refs = nil
+	config.DB.Raw(`
+		SELECT a.id, a.answer_file AS object_key
+		FROM trx_inspection_answer a
+		JOIN trx_inspection_detail d ON d.id = a.id_trx_inspection_detail
+		JOIN trx_inspection t ON t.id = d.id_trx_inspection
+		WHERE t.company_id = ? AND t.created_at < ? AND t.legal_hold = false AND a.answer_file <> ''`,
+		cid, cutoff).Scan(&refs)
+	expire("trx_inspection_answer", "answer_file", refs)
+
+	refs = nil
+	config.DB.Raw(`
+		SELECT e.id, e.object_key
+		FROM trx_evidence_attachment e JOIN trx_inspection t ON t.id = e.id_trx_inspection
+		WHERE t.company_id = ? AND t.created_at < ? AND t.legal_hold = false AND e.object_key <> ''`,
+		cid, cutoff).Scan(&refs)
+	expire("trx_evidence_attachment", "object_key", refs)
+
+	refs = nil
+	config.DB.Raw(`
+		SELECT md.id, md.answer_file AS object_key
+		FROM mstr_answer_detail md JOIN mstr_answer m ON m.id = md.master_answer_id
+		WHERE m.company_id = ? AND m.created_at < ? AND md.answer_file <> ''`,
+		cid, cutoff).Scan(&refs)
+	expire("mstr_answer_detail", "answer_file", refs)
+}
+
+// purgeCompanyPrefix menghapus sisa file di bawah "Assurance/<companyID>/" milik company yang sudah dihapus.
+// Dilewati jika masih ada transaksi yang di-legal hold.
+func (p *retentionPurge) purgeCompanyPrefix() {
+	cid := p.company.CompanyID
+
+	if len(p.held) > 0 {
+		return
+	}
+
+	objects, err := p.backend.List(p.ctx, "Assurance/"+cid+"/")
+	if err != nil {
+		p.report.addError("%s: %v", cid, err)
+		return
+	}
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		if !utils.IsImageVariantKey(obj.Key) {
+			keys = append(keys, obj.Key)
+		}
+	}
+	p.deleteObjects(keys)
+}
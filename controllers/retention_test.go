@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-api/models"
+	"go-api/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingDeleteBackend gagal menghapus key tertentu (mis. permission bucket)
+type failingDeleteBackend struct {
+	storage.Backend
+	fail map[string]bool
+}
+
+func (b *failingDeleteBackend) Delete(ctx context.Context, key string) error {
+	if b.fail[key] {
+		return errors.New("access denied")
+	}
+	return b.Backend.Delete(ctx, key)
+}
+
+// Satu file gagal dihapus tidak menghentikan file lain, dan hanya referensi file yang terhapus yang dikosongkan
+func TestRetentionExpireEvidenceContinuesAfterDeleteError(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	backend := &failingDeleteBackend{Backend: storage.NewMemory(t.Name()), fail: map[string]bool{"ev/b.jpg": true}}
+	keys := []string{"ev/a.jpg", "ev/b.jpg", "ev/c.jpg"}
+	for _, key := range keys {
+		if err := backend.Put(ctx, key, strings.NewReader(key), int64(len(key)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+		mustCreate(t, db, &models.TrxEvidenceMetadata{CompanyID: "COMP-A", ObjectKey: key, SHA256: "x"})
+	}
+
+	old := time.Now().AddDate(0, 0, -40)
+	trx := models.TrxInspection{IdInspection: 1, NameInspection: "Old", IdUser: 1, CompanyID: "COMP-A", CreatedBy: "tester", CreatedAt: old}
+	mustCreate(t, db, &trx)
+	details := make([]models.TrxInspectionDetail, len(keys))
+	for i, key := range keys {
+		details[i] = models.TrxInspectionDetail{IdTrxInspection: trx.Id, IdCoordinate: uint(i + 1), CaptureUrl: key}
+		mustCreate(t, db, &details[i])
+	}
+
+	run := &models.TrxJobRun{}
+	report := &jobReport{}
+	p := &retentionPurge{ctx: ctx, run: run, report: report, company: &models.MstrCompany{CompanyID: "COMP-A"}, backend: backend, held: map[uint]bool{}}
+	p.expireEvidence(time.Now().AddDate(0, 0, -30))
+
+	for _, d := range details {
+		var got models.TrxInspectionDetail
+		db.First(&got, d.Id)
+		_, headErr := backend.Head(ctx, d.CaptureUrl)
+		var metadata int64
+		db.Model(&models.TrxEvidenceMetadata{}).Where("object_key = ?", d.CaptureUrl).Count(&metadata)
+
+		if backend.fail[d.CaptureUrl] {
+			if got.CaptureUrl != d.CaptureUrl || headErr != nil || metadata != 1 {
+				t.Errorf("%s: failed delete lost its reference (capture_url %q, head %v, metadata %d)", d.CaptureUrl, got.CaptureUrl, headErr, metadata)
+			}
+			continue
+		}
+		if got.CaptureUrl != "" || !errors.Is(headErr, storage.ErrNotFound) || metadata != 0 {
+			t.Errorf("%s: not expired (capture_url %q, head %v, metadata %d)", d.CaptureUrl, got.CaptureUrl, headErr, metadata)
+		}
+	}
+
+	if run.Processed != 3 || run.Succeeded != 2 || run.Failed != 1 {
+		t.Errorf("run processed/succeeded/failed = %d/%d/%d, want 3/2/1", run.Processed, run.Succeeded, run.Failed)
+	}
+	if c := report.Companies["COMP-A"]; c["objects_deleted"] != 2 || c["objects_failed"] != 1 || c["evidence_expired"] != 2 {
+		t.Errorf("report = %v", c)
+	}
+	if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "ev/b.jpg") {
+		t.Errorf("report errors = %v", report.Errors)
+	}
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleReq struct {
+	RoleName    string   `json:"role_name"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+	Permissions []string `json:"permissions"`
+}
+
+// validateRolePermissions cek permission dikenal dan bukan permission level platform
+func validateRolePermissions(perms []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, p := range perms {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !utils.IsKnownPermission(p) {
+			return nil, fmt.Errorf("unknown permission: %s", p)
+		}
+		if utils.IsPlatformPermission(p) {
+			return nil, fmt.Errorf("permission %s cannot be granted to a company role", p)
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// GET /permissions
+func ListPermissions(c *gin.Context) {
+	var perms []gin.H
+	for _, p := range utils.AllPermissions {
+		perms = append(perms, gin.H{
+			"permission": p,
+			"platform":   utils.IsPlatformPermission(p),
+		})
+	}
+	utils.JSONSuccess(c, "Available permissions", perms)
+}
+
+// GET /roles → role sistem (read-only) + custom role milik company
+func ListRoles(c *gin.Context) {
+	var systemRoles []gin.H
+	for _, name := range []string{utils.RoleSuperAdmin, utils.RoleAdmin, utils.RoleUser} {
+		if name == utils.RoleSuperAdmin && !utils.HasPermission(c, utils.PermPlatformAdmin) {
+			continue
+		}
+		systemRoles = append(systemRoles, gin.H{
+			"role_name":   name,
+			"is_system":   true,
+			"permissions": utils.DefaultRolePermissions[name],
+		})
+	}
+
+	query := config.DB.Preload("Permissions").Scopes(utils.CompanyScope(c)).Order("id DESC")
+	if companyID := c.Query("company_id"); companyID != "" && utils.HasPermission(c, utils.PermPlatformAdmin) {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	var roles []models.MstrRole
+	if err := query.Find(&roles).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Roles", gin.H{
+		"system_roles": systemRoles,
+		"custom_roles": roles,
+	})
+}
+
+// POST /roles
+func CreateRole(c *gin.Context) {
+	var req RoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	userCompanyID := c.GetString("company_id")
+	username := c.GetString("username")
+
+	name := normalizeRoleName(req.RoleName)
+	if name == "" {
+		utils.JSONError(c, http.StatusBadRequest, "Role name is required")
+		return
+	}
+	if utils.IsSystemRole(name) {
+		utils.JSONError(c, http.StatusBadRequest, "Role name is reserved for system role")
+		return
+	}
+
+	perms, err := validateRolePermissions(req.Permissions)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.MstrRole{}).
+		Where("company_id = ? AND role_name = ?", userCompanyID, name).
+		Count(&count)
+	if count > 0 {
+		utils.JSONError(c, http.StatusBadRequest, "Role already exists")
+		return
+	}
+
+	role := models.MstrRole{
+		CompanyID:   userCompanyID,
+		RoleName:    name,
+		Description: req.Description,
+		IsActive:    true,
+		CreatedBy:   username,
+		UpdatedBy:   username,
+	}
+	if req.IsActive != nil {
+		role.IsActive = *req.IsActive
+	}
+	for _, p := range perms {
+		role.Permissions = append(role.Permissions, models.MstrRolePermission{
+			Permission: p,
+			CreatedBy:  username,
+		})
+	}
+
+	if err := config.DB.Create(&role).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to create role")
+		return
+	}
+
+	utils.JSONSuccess(c, "Role created", role)
+}
+
+// PUT /roles/:id → ganti deskripsi, status dan seluruh permission
+func UpdateRoleByID(c *gin.Context) {
+	id := c.Param("id")
+	username := c.GetString("username")
+
+	var role models.MstrRole
+	if err := utils.TenantDB(c).First(&role, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Role not found")
+		return
+	}
+
+	var req RoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	perms, err := validateRolePermissions(req.Permissions)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		role.Description = req.Description
+		if req.IsActive != nil {
+			role.IsActive = *req.IsActive
+		}
+		role.UpdatedBy = username
+		if err := tx.Save(&role).Error; err != nil {
+			return err
+		}
+
+		// replace semua permission
+		if err := tx.Where("role_id = ?", role.Id).Delete(&models.MstrRolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(perms) == 0 {
+			return nil
+		}
+		rows := make([]models.MstrRolePermission, 0, len(perms))
+		for _, p := range perms {
+			rows = append(rows, models.MstrRolePermission{
+				RoleID:     role.Id,
+				Permission: p,
+				CreatedBy:  username,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to update role: "+err.Error())
+		return
+	}
+
+	config.DB.Preload("Permissions").First(&role, role.Id)
+	utils.JSONSuccess(c, "Role updated", role)
+}
+
+// DELETE /roles/:id
+func DeleteRoleByID(c *gin.Context) {
+	id := c.Param("id")
+	deletedBy := c.GetString("username")
+
+	var role models.MstrRole
+	if err := utils.TenantDB(c).First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(c, http.StatusNotFound, "Role not found")
+			return
+		}
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Jangan hapus role yang masih dipakai user
+	var count int64
+	config.DB.Model(&models.MstrUser{}).
+		Where("company_id = ? AND role = ?", role.CompanyID, role.RoleName).
+		Count(&count)
+	if count > 0 {
+		utils.JSONError(c, http.StatusBadRequest, "Role is still assigned to users")
+		return
+	}
+
+	if err := config.DB.Model(&role).Update("deleted_by", deletedBy).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := config.DB.Delete(&role).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Role deleted", nil)
+}
+
+// normalizeRoleName = bentuk simpan nama role (role_name dan mstr_user.role selalu lowercase)
+func normalizeRoleName(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
+
+// checkAssignableRole memastikan role ada untuk company dan boleh diberikan oleh user yang login.
+// Mengembalikan nama role yang sudah dinormalisasi untuk disimpan di user.
+func checkAssignableRole(c *gin.Context, companyID, role string) (string, error) {
+	role = normalizeRoleName(role)
+	if role == "" {
+		return "", errors.New("Role is required")
+	}
+
+	if role == utils.RoleSuperAdmin && !utils.HasPermission(c, utils.PermPlatformAdmin) {
+		return "", errors.New("Not allowed to assign super-admin role")
+	}
+
+	if utils.IsSystemRole(role) {
+		return role, nil
+	}
+
+	var count int64
+	config.DB.Model(&models.MstrRole{}).
+		Where("company_id = ? AND role_name = ? AND is_active = ?", companyID, role, true).
+		Count(&count)
+	if count == 0 {
+		return "", errors.New("Role not found")
+	}
+	return role, nil
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// scoreQuestionnaire menilai submit questionnaire; questions harus sudah preload Options
+func scoreQuestionnaire(questions []models.Question, answers []submittedAnswer, threshold float64) utils.ScoreResult {
+	scored := make([]utils.ScoreQuestion, 0, len(questions))
+	conditional := make([]utils.ConditionalQuestion, 0, len(questions))
+	for _, q := range questions {
+		scored = append(scored, utils.ScoreQuestion{ID: q.ID, Weight: q.Weight, Critical: q.IsCritical,
+			Multi: strings.EqualFold(q.Type, utils.QuestionCheckbox), Options: optionScoring(q.Options)})
+		conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+	}
+	return utils.ScoreAnswers(scored, answerValues(answers), hiddenQuestions(conditional, answers), threshold)
+}
+
+// scoreInspection menilai submit assurance terhadap snapshot versi. SAM yang tidak dikirim
+// hanya ikut dinilai jika wajib (required_coordinate).
+func scoreInspection(snapshot *models.InspectionSnapshot, answersBySam map[uint][]submittedAnswer) utils.ScoreResult {
+	var scored []utils.ScoreQuestion
+	var all []submittedAnswer
+	hidden := make(map[uint]bool)
+	for _, sam := range snapshot.Details {
+		answers, submitted := answersBySam[sam.Id]
+		if !submitted && !sam.RequiredCoordinate {
+			continue
+		}
+		conditional := make([]utils.ConditionalQuestion, 0, len(sam.Questions))
+		for _, q := range sam.Questions {
+			scored = append(scored, utils.ScoreQuestion{ID: q.ID, Weight: q.Weight, Critical: q.IsCritical,
+				Multi: strings.EqualFold(q.Type, utils.QuestionCheckbox), Options: inspectionOptionScoring(q.Options)})
+			conditional = append(conditional, utils.ConditionalQuestion{ID: q.ID, Condition: q.DisplayCondition})
+		}
+		for id := range hiddenQuestions(conditional, answers) {
+			hidden[id] = true
+		}
+		all = append(all, answers...)
+	}
+	return utils.ScoreAnswers(scored, answerValues(all), hidden, snapshot.PassThreshold)
+}
+
+// optionScoring = option questionnaire dalam bentuk yang dipakai utils.ScoreAnswers / CheckScoring
+func optionScoring(options []models.Option) []utils.ScoreOption {
+	scoring := make([]utils.ScoreOption, 0, len(options))
+	for _, o := range options {
+		scoring = append(scoring, utils.ScoreOption{Label: o.Label, IsCorrect: o.IsCorrect, Score: o.Score})
+	}
+	return scoring
+}
+
+// inspectionOptionScoring = optionScoring untuk option question SAM
+func inspectionOptionScoring(options []models.MstrInspectionQuestionOption) []utils.ScoreOption {
+	scoring := make([]utils.ScoreOption, 0, len(options))
+	for _, o := range options {
+		scoring = append(scoring, utils.ScoreOption{Label: o.Label, IsCorrect: o.IsCorrect, Score: o.Score})
+	}
+	return scoring
+}
+
+func answerValues(answers []submittedAnswer) map[uint]string {
+	values := make(map[uint]string, len(answers))
+	for _, a := range answers {
+		values[a.QuestionID] = a.answerValue()
+	}
+	return values
+}
+
+// checkPassThreshold: threshold dalam persen, 0 = tanpa threshold
+func checkPassThreshold(threshold float64) bool {
+	return threshold >= 0 && threshold <= 100
+}
+
+// scoreFilter = filter hasil scoring di list submission:
+// ?passed=true|false&critical_failed=true|false&min_score=&max_score= (persentase)
+func scoreFilter(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v, err := strconv.ParseBool(c.Query("passed")); err == nil {
+			db = db.Where("passed = ?", v)
+		}
+		if v, err := strconv.ParseBool(c.Query("critical_failed")); err == nil {
+			db = db.Where("critical_failed = ?", v)
+		}
+		if v, err := strconv.ParseFloat(c.Query("min_score"), 64); err == nil {
+			db = db.Where("score_percent >= ?", v)
+		}
+		if v, err := strconv.ParseFloat(c.Query("max_score"), 64); err == nil {
+			db = db.Where("score_percent <= ?", v)
+		}
+		return db
+	}
+}
+
+// GET /mstr-answers/filter → daftar submit questionnaire (MstrAnswer) beserta hasil scoring
+func GetFilteredMstrAnswers(c *gin.Context) {
+	query := utils.TenantDB(c).Scopes(scoreFilter(c))
+
+	if v := c.Query("questionnaire_id"); v != "" {
+		query = query.Where("questionnaire_id = ?", v)
+	}
+	if v := c.Query("user_id"); v != "" {
+		query = query.Where("user_id = ?", v)
+	}
+	if v := c.Query("device_id"); v != "" {
+		query = query.Where("device_id = ?", v)
+	}
+	if v := c.Query("created_by"); v != "" {
+		query = query.Where("created_by = ?", v)
+	}
+
+	var answers []models.MstrAnswer
+	if err := query.Order("id DESC").Find(&answers).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Filtered questionnaire submissions", answers)
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"go-api/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// is_critical / weight pada question yang tidak bisa dinilai ditolak saat disimpan, bukan diabaikan saat submit
+func TestQuestionScoringRejectedForUnscoredTypes(t *testing.T) {
+	db := newTestDB(t)
+	qn := models.Questionnaire{Title: "Pre", CompanyID: "COMP-A", IsActive: true}
+	mustCreate(t, db, &qn)
+	text := models.Question{QuestionnaireID: qn.ID, Text: "Catatan", Type: "text"}
+	mustCreate(t, db, &text)
+
+	r := adminRouter("COMP-A")
+	r.POST("/questionnaires/:questionnaireId/questions", CreateQuestion)
+	r.PUT("/questions/:id", UpdateQuestion)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   gin.H
+		want   int
+	}{
+		{"critical yesno", http.MethodPost, idPath("/questionnaires/%d/questions", qn.ID),
+			gin.H{"text": "APD lengkap?", "type": "yesno", "is_critical": true}, http.StatusBadRequest},
+		{"weighted number", http.MethodPost, idPath("/questionnaires/%d/questions", qn.ID),
+			gin.H{"text": "Suhu", "type": "number", "weight": 5}, http.StatusBadRequest},
+		{"critical multiple with correct option", http.MethodPost, idPath("/questionnaires/%d/questions", qn.ID),
+			gin.H{"text": "APD", "type": "multiple", "is_critical": true, "weight": 2, "options": []gin.H{
+				{"label": "A", "text": "Lengkap", "is_correct": true}, {"label": "B", "text": "Tidak"},
+			}}, http.StatusCreated},
+		{"update text to critical", http.MethodPut, idPath("/questions/%d", text.ID),
+			gin.H{"is_critical": true}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(r, tt.method, tt.path, tt.body)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
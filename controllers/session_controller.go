@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listActiveSessions mengambil sesi aktif milik user (terbaru dulu)
+func listActiveSessions(userID uint) ([]models.TrxUserSession, error) {
+	var sessions []models.TrxUserSession
+	err := config.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// GET /sessions → sesi aktif milik user yang login
+func GetMySessions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	currentID := c.GetUint("session_id")
+
+	sessions, err := listActiveSessions(userID)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var result []gin.H
+	for _, s := range sessions {
+		result = append(result, gin.H{
+			"id":           s.Id,
+			"device_id":    s.DeviceID,
+			"ip_address":   s.IPAddress,
+			"user_agent":   s.UserAgent,
+			"created_at":   s.CreatedAt,
+			"last_used_at": s.LastUsedAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.Id == currentID,
+		})
+	}
+
+	utils.JSONSuccess(c, "Active sessions", result)
+}
+
+// DELETE /sessions/:id → logout satu sesi milik user sendiri
+func RevokeMySession(c *gin.Context) {
+	id := parseUint(c.Param("id"))
+	userID := c.GetUint("user_id")
+
+	var session models.TrxUserSession
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL", userID).First(&session, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if err := utils.RevokeSession(session.Id, utils.RevokeLogout); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Session revoked", nil)
+}
+
+// POST /logout → logout sesi saat ini
+func Logout(c *gin.Context) {
+	if err := utils.RevokeSession(c.GetUint("session_id"), utils.RevokeLogout); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.JSONSuccess(c, "Logged out", nil)
+}
+
+// POST /logout-all → logout semua sesi user (termasuk sesi saat ini)
+func LogoutAll(c *gin.Context) {
+	if err := utils.RevokeUserSessions(c.GetUint("user_id"), utils.RevokeLogoutAll); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.JSONSuccess(c, "Logged out from all sessions", nil)
+}
+
+// GET /users/:id/sessions → admin melihat sesi aktif user di company-nya
+func GetUserSessions(c *gin.Context) {
+	var user models.MstrUser
+	if err := utils.TenantDB(c).Select("id").First(&user, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	sessions, err := listActiveSessions(user.Id)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Active sessions", sessions)
+}
+
+// DELETE /users/:id/sessions → admin memaksa logout semua sesi user
+func RevokeUserSessions(c *gin.Context) {
+	var user models.MstrUser
+	if err := utils.TenantDB(c).Select("id").First(&user, c.Param("id")).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := utils.RevokeUserSessions(user.Id, utils.RevokeLogoutAll); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "User sessions revoked", nil)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Backend lokal: file di STORAGE_LOCAL_ROOT/<company_id>, presigned URL diarahkan ke STORAGE_PUBLIC_URL
+func localStorageRoot() string {
+	if root := os.Getenv("STORAGE_LOCAL_ROOT"); root != "" {
+		return root
+	}
+	return filepath.Join("data", "storage")
+}
+
+// Label tetap untuk menurunkan kunci storage dari JWT secret
+const storageKeyLabel = "go-api/storage-signing-key/v1"
+
+// storageSigningKey = STORAGE_SIGNING_KEY, atau HMAC(JWT secret, label) jika kosong
+// supaya signature URL storage tidak pernah memakai kunci JWT secara langsung
+func storageSigningKey() []byte {
+	if key := os.Getenv("STORAGE_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+	mac := hmac.New(sha256.New, []byte(utils.GetJwtSecret()))
+	mac.Write([]byte(storageKeyLabel))
+	return mac.Sum(nil)
+}
+
+func (cfg E2Config) storageConfig() storage.Config {
+	sc := storage.Config{
+		Driver:    cfg.Driver,
+		Endpoint:  cfg.Endpoint,
+		Region:    cfg.Region,
+		Bucket:    cfg.BucketName,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+	}
+	if cfg.Driver == storage.DriverLocal {
+		sc.Bucket = cfg.CompanyID
+		sc.Root = filepath.Join(localStorageRoot(), cfg.CompanyID)
+		sc.BaseURL = os.Getenv("STORAGE_PUBLIC_URL")
+		sc.SigningKey = storageSigningKey()
+	}
+	return sc
+}
+
+// companyE2Config = konfigurasi storage yang tersimpan di master company
+func companyE2Config(company *models.MstrCompany) E2Config {
+	return E2Config{
+		Endpoint:   company.E2Endpoint,
+		Region:     company.E2Region,
+		BucketName: company.E2BucketName,
+		AccessKey:  company.E2AccessKey.Reveal(),
+		SecretKey:  company.E2SecretKey.Reveal(),
+		Driver:     company.StorageBackend,
+		CompanyID:  company.CompanyID,
+	}
+}
+
+func newCompanyStorage(company *models.MstrCompany) (storage.Backend, error) {
+	return storage.New(companyE2Config(company).storageConfig())
+}
+
+// companyStorage membuka backend storage milik company
+func companyStorage(companyID string) (storage.Backend, *models.MstrCompany, error) {
+	var company models.MstrCompany
+	if err := config.DB.Where("company_id = ?", companyID).First(&company).Error; err != nil {
+		return nil, nil, fmt.Errorf("company not found")
+	}
+	backend, err := newCompanyStorage(&company)
+	if err != nil {
+		return nil, nil, err
+	}
+	return backend, &company, nil
+}
+
+// localObjectRequest validasi presigned URL backend lokal, return backend & key
+func localObjectRequest(c *gin.Context) (storage.Backend, string, bool) {
+	companyID := c.Param("bucket")
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	exp, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || key == "" ||
+		!storage.VerifyLocal(storageSigningKey(), c.Request.Method, companyID, key, exp, c.Query("sig")) {
+		c.String(http.StatusForbidden, "Invalid or expired signature")
+		return nil, "", false
+	}
+
+	backend, company, err := companyStorage(companyID)
+	if err != nil || company.StorageBackend != storage.DriverLocal {
+		c.String(http.StatusNotFound, "Storage not found")
+		return nil, "", false
+	}
+	return backend, key, true
+}
+
+// GET /storage/local/:bucket/*key → download via presigned URL backend lokal
+func GetLocalObject(c *gin.Context) {
+	backend, key, ok := localObjectRequest(c)
+	if !ok {
+		return
+	}
+
+	body, info, err := backend.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.String(http.StatusNotFound, "File not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", info.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	c.Header("ETag", `"`+info.ETag+`"`)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, body)
+}
+
+// PUT /storage/local/:bucket/*key → upload via presigned URL backend lokal (pengganti presigned PUT S3)
+func PutLocalObject(c *gin.Context) {
+	backend, key, ok := localObjectRequest(c)
+	if !ok {
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxDirectUploadSize)
+	if err := backend.Put(c.Request.Context(), key, body, c.Request.ContentLength, c.GetHeader("Content-Type")); err != nil {
+		c.String(http.StatusBadRequest, "Failed to store file: %s", err.Error())
+		return
+	}
+
+	info, err := backend.Head(c.Request.Context(), key)
+	if err == nil {
+		c.Header("ETag", `"`+info.ETag+`"`)
+	}
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"bytes"
+	"go-api/utils"
+	"testing"
+)
+
+func TestStorageSigningKey(t *testing.T) {
+	t.Setenv("STORAGE_SIGNING_KEY", "")
+	derived := storageSigningKey()
+	if len(derived) == 0 || bytes.Equal(derived, []byte(utils.GetJwtSecret())) {
+		t.Fatalf("storage key without STORAGE_SIGNING_KEY must be derived, not the JWT secret")
+	}
+	if !bytes.Equal(derived, storageSigningKey()) {
+		t.Error("derived storage key is not stable")
+	}
+
+	t.Setenv("STORAGE_SIGNING_KEY", "storage-secret")
+	if got := storageSigningKey(); string(got) != "storage-secret" {
+		t.Errorf("storageSigningKey = %q, want STORAGE_SIGNING_KEY", got)
+	}
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	moduleTrnAssurance     = "Trn-Assurance"
+	moduleTrnQuestionnaire = "Trn-Questionnaire"
+)
+
+var errInvalidSubmissionUUID = errors.New("submission_uuid must be a valid UUID")
+
+// submissionUUID membaca idempotency key dari form submission_uuid atau header Idempotency-Key ("" jika tidak dikirim)
+func submissionUUID(c *gin.Context) (string, error) {
+	raw := c.PostForm("submission_uuid")
+	if raw == "" {
+		raw = c.GetHeader("Idempotency-Key")
+	}
+	if raw == "" {
+		return "", nil
+	}
+	id, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", errInvalidSubmissionUUID
+	}
+	return id.String(), nil
+}
+
+// findSubmissionEvidence mengambil file yang sudah pernah diterima untuk submission ini
+func findSubmissionEvidence(companyID, submissionID, fileKey string) (*models.TrxSubmissionEvidence, error) {
+	var evidence models.TrxSubmissionEvidence
+	err := config.DB.
+		Where("submission_uuid = ? AND file_key = ? AND company_id = ?", submissionID, fileKey, companyID).
+		First(&evidence).Error
+	if err != nil {
+		return nil, err
+	}
+	return &evidence, nil
+}
+
+// storeSubmissionFile upload satu file multipart ke E2. Kalau submission_uuid dikirim, file dicatat
+// (di luar transaksi submit) sehingga retry tidak meng-upload ulang file yang sama.
+func storeSubmissionFile(c *gin.Context, submissionID, fileKey, module string) (*models.TrxSubmissionEvidence, error) {
+	companyID := c.GetString("company_id")
+
+	if submissionID != "" {
+		if evidence, err := findSubmissionEvidence(companyID, submissionID, fileKey); err == nil {
+			return evidence, nil
+		}
+	}
+
+	fh, err := c.FormFile(fileKey)
+	if err != nil {
+		// Tidak ada di multipart → mungkin object key / upload_id dari presigned upload
+		upload, uerr := resolveUploadedObject(c, companyID, submissionID, fileKey)
+		if uerr != nil {
+			if errors.Is(uerr, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("File missing: %s", fileKey)
+			}
+			return nil, uerr
+		}
+		// satu upload hanya untuk satu submission; update bersyarat supaya submit paralel tidak bisa memakai yang sama
+		consumed := config.DB.Model(&models.TrxUploadSession{}).
+			Where("id = ? AND consumed_at IS NULL", upload.Id).
+			Update("consumed_at", time.Now())
+		if consumed.Error != nil {
+			return nil, consumed.Error
+		}
+		if consumed.RowsAffected == 0 {
+			return nil, fmt.Errorf("File already used: %s", fileKey)
+		}
+		evidence := &models.TrxSubmissionEvidence{
+			SubmissionUUID: submissionID,
+			FileKey:        fileKey,
+			CompanyID:      companyID,
+			Module:         upload.Module,
+			ObjectKey:      upload.ObjectKey,
+			FileName:       upload.FileName,
+			ContentType:    upload.ContentType,
+			Size:           upload.ExpectedSize,
+			CreatedBy:      upload.CreatedBy,
+		}
+		// dicatat supaya retry submission yang sama tetap menemukan file ini
+		if submissionID != "" {
+			config.DB.Create(evidence)
+		}
+		return evidence, nil
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hr, err := hashEvidenceFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	key := GenerateE2ObjectKey(c, module, fh.Filename)
+	objectKey, err := UploadFileToE2(c, f, key, fh.Header.Get("Content-Type"), "Assurance/"+companyID, nil)
+	if err != nil {
+		return nil, err
+	}
+	recordEvidenceMetadata(companyID, objectKey, fh.Header.Get("Content-Type"), c.GetString("username"), hr)
+
+	evidence := &models.TrxSubmissionEvidence{
+		SubmissionUUID: submissionID,
+		FileKey:        fileKey,
+		CompanyID:      companyID,
+		Module:         module,
+		ObjectKey:      objectKey,
+		FileName:       fh.Filename,
+		ContentType:    fh.Header.Get("Content-Type"),
+		Size:           fh.Size,
+		CreatedBy:      c.GetString("username"),
+	}
+	if submissionID != "" {
+		if err := config.DB.Create(evidence).Error; err != nil {
+			// upload paralel dengan key sama → pakai yang sudah tercatat
+			if existing, ferr := findSubmissionEvidence(companyID, submissionID, fileKey); ferr == nil {
+				return existing, nil
+			}
+			return nil, err
+		}
+	}
+	return evidence, nil
+}
+
+// POST /submissions/:uuid/evidence → upload evidence satu per satu sebelum submit (multipart, nama field = file key).
+// File yang sudah diterima dilewati.
+func UploadSubmissionEvidence(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, errInvalidSubmissionUUID.Error())
+		return
+	}
+	submissionID := id.String()
+
+	module := moduleTrnAssurance
+	if c.PostForm("module") == "questionnaire" {
+		module = moduleTrnQuestionnaire
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File) == 0 {
+		utils.JSONError(c, http.StatusBadRequest, "No files uploaded")
+		return
+	}
+
+	var stored []models.TrxSubmissionEvidence
+	for fileKey := range form.File {
+		evidence, err := storeSubmissionFile(c, submissionID, fileKey, module)
+		if err != nil {
+			if respondQuotaError(c, err) {
+				return
+			}
+			utils.JSONError(c, http.StatusInternalServerError, "Failed upload "+fileKey+": "+err.Error())
+			return
+		}
+		stored = append(stored, *evidence)
+	}
+
+	utils.JSONSuccess(c, "Evidence received", stored)
+}
+
+// GET /submissions/:uuid/evidence → file key yang sudah diterima, supaya tablet hanya mengirim sisanya
+func GetSubmissionEvidence(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, errInvalidSubmissionUUID.Error())
+		return
+	}
+
+	var evidences []models.TrxSubmissionEvidence
+	if err := config.DB.Scopes(utils.CompanyScope(c)).
+		Where("submission_uuid = ?", id.String()).
+		Order("id ASC").
+		Find(&evidences).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Submission evidence", evidences)
+}
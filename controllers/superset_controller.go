@@ -15,10 +15,9 @@ type GuestTokenResponse struct {
 
 // handler
 func GetSupersetGuestToken(c *gin.Context) {
-	role := c.GetString("role")
 	companyID := c.GetString("company_id")
 
-	if role != "super-admin" && companyID == "" {
+	if !utils.HasPermission(c, utils.PermPlatformAdmin) && companyID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id not found in token"})
 		return
 	}
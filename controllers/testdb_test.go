@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB mengganti config.DB dengan database sqlite sementara selama test berjalan.
+// Schema dibuat lewat AutoMigrate yang sama dengan main.go.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=off"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.MstrCompany{},
+		&models.MstrUser{},
+		&models.MstrRole{},
+		&models.MstrRolePermission{},
+		&models.MstrInspection{},
+		&models.MstrInspectionDetail{},
+		&models.MstrInspectionVersion{},
+		&models.MstrInspectionPage{},
+		&models.MstrChaining{},
+		&models.MstrChainingDetail{},
+		&models.MstrEventTrigger{},
+		&models.MstrTypeTrigger{},
+		&models.TrxInspection{},
+		&models.TrxInspectionDetail{},
+		&models.TrxInspectionAnswer{},
+		&models.MstrDevice{},
+		&models.MstrGroup{},
+		&models.Questionnaire{},
+		&models.Question{},
+		&models.Option{},
+		&models.Answer{},
+		&models.MstrAnswer{},
+		&models.MstrAnswerDetail{},
+		&models.MstrInspectionQuestion{},
+		&models.MstrInspectionQuestionOption{},
+		&models.TrxUserSession{},
+		&models.TrxRefreshToken{},
+		&models.MstrDeviceCredential{},
+		&models.MstrDevicePairingCode{},
+		&models.TrxDeviceEnrolmentLog{},
+		&models.TrxDeviceHeartbeat{},
+		&models.TrxSubmissionEvidence{},
+		&models.TrxEvidenceAttachment{},
+		&models.TrxUploadSession{},
+		&models.TrxEvidenceMetadata{},
+		&models.TrxStorageUsage{},
+		&models.MstrCompanyQuota{},
+	); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+
+	prev := config.DB
+	config.DB = db
+	t.Cleanup(func() {
+		config.DB = prev
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// mustCreate menyimpan fixture dan menghentikan test jika gagal
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// newTestRouter = gin engine dengan context login seperti hasil AuthMiddleware (role sistem, tanpa JWT)
+func newTestRouter(companyID, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("username", "tester@"+companyID)
+		c.Set("company_id", companyID)
+		c.Set("role", role)
+		c.Next()
+	})
+	return r
+}
+
+// doJSON mengirim request dengan body JSON (nil = tanpa body)
+func doJSON(r http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		raw, _ := json.Marshal(body)
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// adminRouter = router untuk admin company tertentu
+func adminRouter(companyID string) *gin.Engine {
+	return newTestRouter(companyID, utils.RoleAdmin)
+}
+
+func idPath(format string, id uint) string {
+	return fmt.Sprintf(format, id)
+}
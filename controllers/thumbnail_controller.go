@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const jobThumbnailBackfill = "thumbnail_backfill"
+
+// batasi resize paralel (decode gambar besar memakan memory)
+var thumbnailSem = make(chan struct{}, 4)
+
+// Gambar yang gagal di-resize tidak dicoba ulang selama TTL ini (file asli tetap dikirim)
+const thumbnailFailureTTL = 10 * time.Minute
+
+// Status generate per company+object: yang sedang jalan & yang baru saja gagal
+var (
+	thumbnailMu       sync.Mutex
+	thumbnailInflight = make(map[string]bool)
+	thumbnailFailed   = make(map[string]time.Time)
+)
+
+// generateImageVariants membuat thumbnail & preview dari object asli dan menyimpannya di samping key asli
+func generateImageVariants(ctx context.Context, backend storage.Backend, objectKey string) error {
+	body, _, err := backend.Get(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	variants, err := utils.GenerateImageVariants(body)
+	if err != nil {
+		return err
+	}
+	for size, data := range variants {
+		if err := backend.Put(ctx, utils.ImageVariantKey(objectKey, size), bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimImageVariants menandai object sedang diproses; false jika sudah jalan atau baru gagal
+func claimImageVariants(id string) bool {
+	thumbnailMu.Lock()
+	defer thumbnailMu.Unlock()
+	if thumbnailInflight[id] || time.Now().Before(thumbnailFailed[id]) {
+		return false
+	}
+	thumbnailInflight[id] = true
+	return true
+}
+
+// releaseImageVariants melepas tanda in-flight dan mencatat kegagalan ke negative cache
+func releaseImageVariants(id string, err error) {
+	thumbnailMu.Lock()
+	defer thumbnailMu.Unlock()
+	delete(thumbnailInflight, id)
+	if err == nil {
+		delete(thumbnailFailed, id)
+		return
+	}
+	now := time.Now()
+	for key, until := range thumbnailFailed {
+		if now.After(until) {
+			delete(thumbnailFailed, key)
+		}
+	}
+	thumbnailFailed[id] = now.Add(thumbnailFailureTTL)
+}
+
+// queueImageVariants dipanggil setelah upload; resize jalan di background supaya response upload tidak tertahan.
+// Satu object hanya diproses sekali walaupun diminta berkali-kali selama resize berjalan.
+func queueImageVariants(backend storage.Backend, companyID, objectKey, contentType string) {
+	if !utils.IsImageObject(objectKey, contentType) {
+		return
+	}
+	id := companyID + "/" + objectKey
+	if !claimImageVariants(id) {
+		return
+	}
+	go func() {
+		thumbnailSem <- struct{}{}
+		defer func() { <-thumbnailSem }()
+		err := generateImageVariants(context.Background(), backend, objectKey)
+		releaseImageVariants(id, err)
+		if err != nil {
+			log.Printf("thumbnail %s: %v", id, err)
+		}
+	}()
+}
+
+// imageVariantObject memilih thumbnail/preview untuk proxy (?size=thumb|preview).
+// Jika turunan belum ada (atau gagal dibuat), file asli yang dikirim dan turunan dibuat di background.
+func imageVariantObject(ctx context.Context, backend storage.Backend, companyID, objectKey, size string) string {
+	if size == "" || !utils.IsImageObject(objectKey, "") {
+		return objectKey
+	}
+	variantKey := utils.ImageVariantKey(objectKey, size)
+	if _, err := backend.Head(ctx, variantKey); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			queueImageVariants(backend, companyID, objectKey, "")
+		}
+		return objectKey
+	}
+	return variantKey
+}
+
+// evidenceImageKeys = semua object evidence (assurance & questionnaire) per company, untuk backfill
+func evidenceImageKeys(companyID string) (map[string][]string, error) {
+	var rows []struct {
+		CompanyID string
+		ObjectKey string
+	}
+	err := config.DB.Raw(`
+		SELECT t.company_id, d.capture_url AS object_key
+		FROM trx_inspection_detail d JOIN trx_inspection t ON t.id = d.id_trx_inspection
+		WHERE d.capture_url <> '' AND d.deleted_at IS NULL
+		UNION
+		SELECT t.company_id, a.answer_file
+		FROM trx_inspection_answer a
+		JOIN trx_inspection_detail d ON d.id = a.id_trx_inspection_detail
+		JOIN trx_inspection t ON t.id = d.id_trx_inspection
+		WHERE a.answer_file <> '' AND a.deleted_at IS NULL
+		UNION
+		SELECT company_id, object_key
+		FROM trx_evidence_attachment
+		WHERE object_key <> '' AND deleted_at IS NULL
+		UNION
+		SELECT m.company_id, md.answer_file
+		FROM mstr_answer_detail md JOIN mstr_answer m ON m.id = md.master_answer_id
+		WHERE md.answer_file <> '' AND md.deleted_at IS NULL`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]string)
+	for _, r := range rows {
+		if companyID != "" && r.CompanyID != companyID {
+			continue
+		}
+		if utils.IsImageObject(r.ObjectKey, "") {
+			keys[r.CompanyID] = append(keys[r.CompanyID], r.ObjectKey)
+		}
+	}
+	return keys, nil
+}
+
+// POST /jobs/thumbnail-backfill?company_id= → buat thumbnail/preview untuk evidence lama yang belum punya
+func StartThumbnailBackfill(c *gin.Context) {
+	companyID := c.Query("company_id")
+
+	run, err := startJobRun(models.TrxJobRun{
+		JobName:   jobThumbnailBackfill,
+		CompanyID: companyID,
+		CreatedBy: c.GetString("username"),
+	}, runThumbnailBackfill)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errJobAlreadyRunning) {
+			status = http.StatusConflict
+		}
+		utils.JSONError(c, status, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Thumbnail backfill started", "data": run})
+}
+
+func runThumbnailBackfill(run *models.TrxJobRun, report *jobReport) error {
+	ctx := context.Background()
+	keysByCompany, err := evidenceImageKeys(run.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	for companyID, keys := range keysByCompany {
+		backend, _, err := companyStorage(companyID)
+		if err != nil {
+			report.addError("%s: %v", companyID, err)
+			run.Failed += len(keys)
+			continue
+		}
+
+		for _, key := range keys {
+			run.Processed++
+			if _, err := backend.Head(ctx, utils.ImageVariantKey(key, utils.ImageSizeThumb)); err == nil {
+				report.count(companyID, "skipped", 1)
+				continue
+			}
+			if err := generateImageVariants(ctx, backend, key); err != nil {
+				run.Failed++
+				report.count(companyID, "failed", 1)
+				report.addError("%s %s: %v", companyID, key, err)
+				continue
+			}
+			run.Succeeded++
+			report.count(companyID, "generated", 1)
+		}
+
+		// progress tersimpan per company supaya bisa dipantau lewat GET /jobs/:id
+		config.DB.Model(run).Updates(map[string]interface{}{
+			"processed": run.Processed,
+			"succeeded": run.Succeeded,
+			"failed":    run.Failed,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"go-api/storage"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBackend menghitung Get supaya terlihat berapa kali resize benar-benar jalan
+type countingBackend struct {
+	storage.Backend
+	gets atomic.Int32
+}
+
+func (b *countingBackend) Get(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	b.gets.Add(1)
+	return b.Backend.Get(ctx, key)
+}
+
+func waitImageVariantsIdle(t *testing.T, id string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		thumbnailMu.Lock()
+		busy := thumbnailInflight[id]
+		thumbnailMu.Unlock()
+		if !busy {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("thumbnail %s still in flight", id)
+}
+
+func TestImageVariantObjectDedupAndNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingBackend{Backend: storage.NewMemory(t.Name())}
+	// bukan gambar valid → resize pasti gagal
+	if err := backend.Put(ctx, "evidence/broken.jpg", strings.NewReader("not an image"), 12, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	id := "COMP-A/evidence/broken.jpg"
+	t.Cleanup(func() {
+		thumbnailMu.Lock()
+		delete(thumbnailFailed, id)
+		thumbnailMu.Unlock()
+	})
+
+	// Tahan semua slot resize supaya request paralel terjadi selama resize pertama belum jalan
+	for i := 0; i < cap(thumbnailSem); i++ {
+		thumbnailSem <- struct{}{}
+	}
+	for i := 0; i < 5; i++ {
+		if got := imageVariantObject(ctx, backend, "COMP-A", "evidence/broken.jpg", "thumb"); got != "evidence/broken.jpg" {
+			t.Fatalf("imageVariantObject = %q, want original", got)
+		}
+	}
+	for i := 0; i < cap(thumbnailSem); i++ {
+		<-thumbnailSem
+	}
+	waitImageVariantsIdle(t, id)
+	if n := backend.gets.Load(); n != 1 {
+		t.Fatalf("resize ran %d times, want 1", n)
+	}
+
+	// Kegagalan diingat: request berikutnya tetap dapat file asli tanpa resize ulang
+	if got := imageVariantObject(ctx, backend, "COMP-A", "evidence/broken.jpg", "preview"); got != "evidence/broken.jpg" {
+		t.Fatalf("imageVariantObject after failure = %q, want original", got)
+	}
+	waitImageVariantsIdle(t, id)
+	if n := backend.gets.Load(); n != 1 {
+		t.Errorf("failed resize retried: %d runs", n)
+	}
+
+	// Setelah TTL lewat boleh dicoba lagi
+	thumbnailMu.Lock()
+	thumbnailFailed[id] = time.Now().Add(-time.Second)
+	thumbnailMu.Unlock()
+	imageVariantObject(ctx, backend, "COMP-A", "evidence/broken.jpg", "thumb")
+	waitImageVariantsIdle(t, id)
+	if n := backend.gets.Load(); n != 2 {
+		t.Errorf("resize after TTL ran %d times in total, want 2", n)
+	}
+}
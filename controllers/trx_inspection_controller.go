@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-api/config"
 	"go-api/models"
@@ -12,11 +13,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Create TRXInspection
 func CreateTRXInspection(c *gin.Context) {
 	idInspectionStr := c.PostForm("id_inspection")
+	versionStr := c.PostForm("inspection_version")
 	idUserStr := c.PostForm("id_user")
 	deviceID := c.PostForm("device_id")
 	chainingIDStr := c.PostForm("chaining_id")
@@ -32,12 +35,21 @@ func CreateTRXInspection(c *gin.Context) {
 		return
 	}
 
+	// Dengan credential device, submit hanya atas nama device itu sendiri
+	deviceID, err := utils.AuthDeviceID(c, deviceID)
+	if err != nil {
+		utils.JSONError(c, http.StatusForbidden, "Device key does not match device_id")
+		return
+	}
+
 	// ================= PAYLOAD STRUCT =================
 	type AnswerPayload struct {
 		QuestionID uint   `json:"question_id"`
 		AnswerText string `json:"answer_text"`
 		AnswerFile string `json:"answer_file"`
 		Type       string `json:"type"`
+
+		Attachments []attachmentPayload `json:"attachments"`
 	}
 
 	type DetailPayload struct {
@@ -46,6 +58,12 @@ func CreateTRXInspection(c *gin.Context) {
 		CaptureFile  string          `json:"capture_file"`
 		Description  string          `json:"description"`
 		Answers      []AnswerPayload `json:"answers"`
+
+		// Evidence tambahan SAM point (foto / video / audio dengan caption & urutan)
+		Attachments []attachmentPayload `json:"attachments"`
+
+		// Halaman SAM (opsional, harus sama dengan halaman SAM di versi yang dipakai)
+		IdPage *uint `json:"id_page"`
 	}
 
 	var payloads []DetailPayload
@@ -54,17 +72,125 @@ func CreateTRXInspection(c *gin.Context) {
 		return
 	}
 
+	// ================= IDEMPOTENCY =================
+	// Retry dari tablet dengan submission_uuid yang sama → kembalikan hasil submit pertama
+	submissionID, err := submissionUUID(c)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if submissionID != "" && replayTrxInspection(c, submissionID) {
+		return
+	}
+
+	// ================= QUOTA =================
+	if err := checkSubmissionQuota(userCompanyID); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
 	// ================= INIT =================
 	idInspection := parseUint(idInspectionStr)
 	idUser := parseUint(idUserStr)
 	chainingID := parseUint(chainingIDStr)
 	now := time.Now()
 
+	// ================= VERSI ASSURANCE =================
+	// Tablet boleh kirim inspection_version yang dipakai saat mengisi, default = versi published terakhir
+	// TenantDB → assurance company lain dianggap tidak ada (404)
+	version, snapshot, err := loadInspectionVersion(utils.TenantDB(c), idInspection, parseUint(versionStr))
+	if err != nil {
+		if errors.Is(err, errNoPublishedVersion) {
+			utils.JSONError(c, http.StatusConflict, "Assurance has no published version")
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.JSONError(c, http.StatusNotFound, "Assurance version not found")
+			return
+		}
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if nameInspection == "" {
+		nameInspection = version.NameInspection
+	}
+	if imageUrl == "" {
+		imageUrl = version.ImageUrl
+	}
+
+	// ================= SAM & QUESTION DARI SNAPSHOT VERSI =================
+	// Bukan dari baris live, supaya edit draft tidak mengubah arti submission ini
+	questionMap := make(map[uint]models.MstrInspectionQuestion)
+	samMap := make(map[uint]models.MstrInspectionDetail)
+	pageMap := make(map[uint]models.MstrInspectionPage, len(snapshot.Pages))
+	for _, p := range snapshot.Pages {
+		pageMap[p.Id] = p
+	}
+	for _, s := range snapshot.Details {
+		samMap[s.Id] = s
+		for _, q := range s.Questions {
+			questionMap[q.ID] = q
+		}
+	}
+
+	// ================= VALIDASI JAWABAN =================
+	// Semua jawaban dicek sebelum ada yang disimpan / di-upload, error dikirim per question
+	var answerErrs []utils.AnswerError
+	answersBySam := make(map[uint][]submittedAnswer, len(payloads))
+	for _, dp := range payloads {
+		sam, ok := samMap[dp.IdCoordinate]
+		if !ok {
+			answerErrs = append(answerErrs, utils.AnswerError{SamID: dp.IdCoordinate, Errors: []string{fmt.Sprintf("SAM ID %d not found", dp.IdCoordinate)}})
+			continue
+		}
+		answers := make([]submittedAnswer, 0, len(dp.Answers))
+		for _, a := range dp.Answers {
+			answers = append(answers, submittedAnswer{QuestionID: a.QuestionID, Text: a.AnswerText, FileKey: a.AnswerFile})
+			if errs := checkAttachments(a.Attachments); len(errs) > 0 {
+				answerErrs = append(answerErrs, utils.AnswerError{QuestionID: a.QuestionID, SamID: sam.Id, Errors: errs})
+			}
+		}
+		answerErrs = append(answerErrs, validateInspectionAnswers(c, userCompanyID, submissionID, sam, answers)...)
+
+		// Jumlah evidence SAM point = capture_url + attachments
+		evidenceCount := len(dp.Attachments)
+		if dp.CaptureUrl != "" {
+			evidenceCount++
+		}
+		samErrs := append(checkAttachments(dp.Attachments), checkEvidenceCount(sam, evidenceCount)...)
+		if dp.IdPage != nil && (sam.IdPage == nil || *sam.IdPage != *dp.IdPage) {
+			samErrs = append(samErrs, fmt.Sprintf("SAM is not on page %d", *dp.IdPage))
+		}
+		if len(samErrs) > 0 {
+			answerErrs = append(answerErrs, utils.AnswerError{SamID: sam.Id, Errors: samErrs})
+		}
+		answersBySam[sam.Id] = append(answersBySam[sam.Id], answers...)
+	}
+	// SAM yang tidak dikirim sama sekali dihitung 0 evidence, min_evidence tetap berlaku
+	for _, sam := range snapshot.Details {
+		if _, sent := answersBySam[sam.Id]; sent {
+			continue
+		}
+		if errs := checkEvidenceCount(sam, 0); len(errs) > 0 {
+			answerErrs = append(answerErrs, utils.AnswerError{SamID: sam.Id, Errors: errs})
+		}
+	}
+	if len(answerErrs) > 0 {
+		utils.JSONAnswerErrors(c, answerErrs)
+		return
+	}
+
+	// ================= SCORING =================
+	score := scoreInspection(snapshot, answersBySam)
+
 	tx := config.DB.Begin()
 
 	// ================= CREATE INSPECTION =================
 	inspection := models.TrxInspection{
 		IdInspection:   idInspection,
+		IdVersion:      version.Id,
+		Version:        version.Version,
 		NameInspection: nameInspection,
 		ImageUrl:       imageUrl,
 		IdUser:         idUser,
@@ -75,57 +201,31 @@ func CreateTRXInspection(c *gin.Context) {
 		UpdatedBy:      username,
 		CreatedAt:      now,
 		UpdatedAt:      now,
-	}
-
-	if err := tx.Create(&inspection).Error; err != nil {
-		tx.Rollback()
-		utils.JSONError(c, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	// ================= AMBIL QUESTION SEKALI =================
-	questionIDsMap := make(map[uint]bool)
-	coordinateIDsMap := make(map[uint]bool)
-
-	for _, d := range payloads {
-		coordinateIDsMap[d.IdCoordinate] = true
-		for _, a := range d.Answers {
-			questionIDsMap[a.QuestionID] = true
-		}
-	}
 
-	var questionIDs, coordinateIDs []uint
-	for id := range questionIDsMap {
-		questionIDs = append(questionIDs, id)
+		Score:          score.Score,
+		MaxScore:       score.MaxScore,
+		ScorePercent:   score.Percent,
+		Passed:         score.Passed,
+		CriticalFailed: len(score.CriticalFailed) > 0,
 	}
-	for id := range coordinateIDsMap {
-		coordinateIDs = append(coordinateIDs, id)
+	if submissionID != "" {
+		inspection.SubmissionUUID = &submissionID
 	}
 
-	var questions []models.MstrInspectionQuestion
-	if err := tx.Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
-		tx.Rollback()
-		utils.JSONError(c, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	var sams []models.MstrInspectionDetail
-	if err := tx.Where("id IN ?", coordinateIDs).Find(&sams).Error; err != nil {
+	if err := tx.Create(&inspection).Error; err != nil {
 		tx.Rollback()
+		// submit paralel dengan UUID sama → yang kalah mengembalikan hasil yang menang
+		if submissionID != "" && replayTrxInspection(c, submissionID) {
+			return
+		}
+		if submissionID != "" {
+			utils.JSONError(c, http.StatusConflict, "submission_uuid already used")
+			return
+		}
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	questionMap := make(map[uint]models.MstrInspectionQuestion)
-	for _, q := range questions {
-		questionMap[q.ID] = q
-	}
-
-	samMap := make(map[uint]models.MstrInspectionDetail)
-	for _, s := range sams {
-		samMap[s.Id] = s
-	}
-
 	// ================= RESPONSE STRUCT =================
 	type AnswerResponse struct {
 		QuestionID   uint   `json:"question_id"`
@@ -133,6 +233,10 @@ func CreateTRXInspection(c *gin.Context) {
 		Type         string `json:"type"`
 		AnswerText   string `json:"answer_text,omitempty"`
 		AnswerFile   string `json:"answer_file,omitempty"`
+
+		AnswerJSON datatypes.JSON `json:"answer_json,omitempty"`
+
+		Attachments []attachmentResponse `json:"attachments,omitempty"`
 	}
 
 	type DetailResponse struct {
@@ -146,25 +250,31 @@ func CreateTRXInspection(c *gin.Context) {
 		CaptureFile string           `json:"evidence_capture_type"`
 		Description string           `json:"evidence_description"`
 		Answers     []AnswerResponse `json:"answers"`
+
+		Attachments []attachmentResponse `json:"attachments,omitempty"`
+
+		// Area SAM dari versi yang dipakai (null = titik di sam_x / sam_y)
+		SamX        float64        `json:"sam_x"`
+		SamY        float64        `json:"sam_y"`
+		SamGeometry datatypes.JSON `json:"sam_geometry,omitempty"`
+
+		PageID    *uint  `json:"page_id,omitempty"`
+		PageTitle string `json:"page_title,omitempty"`
+		PageImage string `json:"page_image_path,omitempty"`
 	}
 
 	var responseDetails []DetailResponse
 
 	// ================= LOOP DETAIL =================
 	for _, dp := range payloads {
-		sam, ok := samMap[dp.IdCoordinate]
-		if !ok {
-			tx.Rollback()
-			utils.JSONError(c, http.StatusBadRequest,
-				fmt.Sprintf("SAM ID %d not found", dp.IdCoordinate))
-			return
-		}
+		sam := samMap[dp.IdCoordinate] // SAM yang tidak ada sudah ditolak saat validasi
 
 		detail := models.TrxInspectionDetail{
 			IdTrxInspection: inspection.Id,
 			IdCoordinate:    dp.IdCoordinate,
 			CaptureFile:     dp.CaptureFile,
 			Description:     dp.Description,
+			IdPage:          sam.IdPage,
 			CreatedBy:       username,
 			UpdatedBy:       username,
 			CreatedAt:       now,
@@ -172,30 +282,19 @@ func CreateTRXInspection(c *gin.Context) {
 		}
 
 		// ===== Upload Evidence =====
+		// File bisa dikirim di request ini atau sudah di-upload lebih dulu lewat /submissions/:uuid/evidence
 		if dp.CaptureUrl != "" {
-			fh, err := c.FormFile(dp.CaptureUrl)
+			evidence, err := storeSubmissionFile(c, submissionID, dp.CaptureUrl, moduleTrnAssurance)
 			if err != nil {
 				tx.Rollback()
+				if respondQuotaError(c, err) {
+					return
+				}
 				utils.JSONError(c, http.StatusBadRequest, err.Error())
 				return
 			}
 
-			f, _ := fh.Open()
-			defer f.Close()
-
-			key := GenerateE2ObjectKey(c, "Trn-Assurance", fh.Filename)
-			objectKey, err := UploadFileToE2(
-				c, f, key,
-				fh.Header.Get("Content-Type"),
-				"Assurance/"+userCompanyID, nil,
-			)
-			if err != nil {
-				tx.Rollback()
-				utils.JSONError(c, http.StatusInternalServerError, err.Error())
-				return
-			}
-
-			detail.CaptureUrl = objectKey
+			detail.CaptureUrl = evidence.ObjectKey
 		}
 
 		if err := tx.Create(&detail).Error; err != nil {
@@ -204,6 +303,31 @@ func CreateTRXInspection(c *gin.Context) {
 			return
 		}
 
+		// ===== Attachments SAM point =====
+		base := models.TrxEvidenceAttachment{
+			IdTrxInspection:       inspection.Id,
+			IdTrxInspectionDetail: detail.Id,
+			CompanyID:             userCompanyID,
+			CreatedBy:             username,
+			CreatedAt:             now,
+		}
+		samAttachments, err := uploadEvidenceAttachments(c, submissionID, base, dp.Attachments)
+		if err != nil {
+			tx.Rollback()
+			if respondQuotaError(c, err) {
+				return
+			}
+			utils.JSONError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(samAttachments) > 0 {
+			if err := tx.Create(&samAttachments).Error; err != nil {
+				tx.Rollback()
+				utils.JSONError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
 		respDetail := DetailResponse{
 			IdCoordinate:        dp.IdCoordinate,
 			SamName:             sam.NameCoordinate,
@@ -214,11 +338,23 @@ func CreateTRXInspection(c *gin.Context) {
 			CaptureUrl:  detail.CaptureUrl,
 			CaptureFile: detail.CaptureFile,
 			Description: detail.Description,
+
+			Attachments: attachmentResponses(samAttachments),
+
+			SamX:        sam.X,
+			SamY:        sam.Y,
+			SamGeometry: sam.Geometry,
+		}
+		if sam.IdPage != nil {
+			respDetail.PageID = sam.IdPage
+			respDetail.PageTitle = pageMap[*sam.IdPage].Title
+			respDetail.PageImage = pageMap[*sam.IdPage].ImageUrl
 		}
 
 		// ===== Answers =====
 		var answers []models.TrxInspectionAnswer
 		var respAnswers []AnswerResponse
+		var answerAttachments [][]models.TrxEvidenceAttachment // sejajar dengan answers, ID jawaban diisi setelah disimpan
 
 		for _, a := range dp.Answers {
 			q, ok := questionMap[a.QuestionID]
@@ -244,38 +380,39 @@ func CreateTRXInspection(c *gin.Context) {
 				Type:         a.Type,
 			}
 
-			if strings.ToLower(a.Type) == "image" {
-				fh, err := c.FormFile(a.AnswerFile)
+			if utils.IsFileQuestionType(q.Type) || strings.EqualFold(a.Type, utils.QuestionImage) {
+				evidence, err := storeSubmissionFile(c, submissionID, a.AnswerFile, moduleTrnAssurance)
 				if err != nil {
 					tx.Rollback()
+					if respondQuotaError(c, err) {
+						return
+					}
 					utils.JSONError(c, http.StatusBadRequest, err.Error())
 					return
 				}
 
-				f, _ := fh.Open()
-				defer f.Close()
+				answer.AnswerFile = evidence.ObjectKey
+				respAnswer.AnswerFile = evidence.ObjectKey
+			} else {
+				answer.AnswerText, answer.AnswerJSON = utils.NormalizeAnswer(q.Type, a.AnswerText)
+				respAnswer.AnswerText = answer.AnswerText
+				respAnswer.AnswerJSON = answer.AnswerJSON
+			}
 
-				key := GenerateE2ObjectKey(c, "Trn-Assurance", fh.Filename)
-				objectKey, err := UploadFileToE2(
-					c, f, key,
-					fh.Header.Get("Content-Type"),
-					"Assurance/"+userCompanyID, nil,
-				)
-				if err != nil {
-					tx.Rollback()
-					utils.JSONError(c, http.StatusInternalServerError, err.Error())
+			attachments, err := uploadEvidenceAttachments(c, submissionID, base, a.Attachments)
+			if err != nil {
+				tx.Rollback()
+				if respondQuotaError(c, err) {
 					return
 				}
-
-				answer.AnswerFile = objectKey
-				respAnswer.AnswerFile = objectKey
-			} else {
-				answer.AnswerText = strings.TrimSpace(a.AnswerText)
-				respAnswer.AnswerText = answer.AnswerText
+				utils.JSONError(c, http.StatusBadRequest, err.Error())
+				return
 			}
+			respAnswer.Attachments = attachmentResponses(attachments)
 
 			answers = append(answers, answer)
 			respAnswers = append(respAnswers, respAnswer)
+			answerAttachments = append(answerAttachments, attachments)
 		}
 
 		if len(answers) > 0 {
@@ -286,6 +423,21 @@ func CreateTRXInspection(c *gin.Context) {
 			}
 		}
 
+		var rows []models.TrxEvidenceAttachment
+		for i := range answers {
+			for _, att := range answerAttachments[i] {
+				att.IdTrxInspectionAnswer = &answers[i].ID
+				rows = append(rows, att)
+			}
+		}
+		if len(rows) > 0 {
+			if err := tx.Create(&rows).Error; err != nil {
+				tx.Rollback()
+				utils.JSONError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
 		respDetail.Answers = respAnswers
 		responseDetails = append(responseDetails, respDetail)
 	}
@@ -293,6 +445,7 @@ func CreateTRXInspection(c *gin.Context) {
 	// ================= RAW PAYLOAD =================
 	finalPayload := gin.H{
 		"assurance_id":         idInspectionStr,
+		"assurance_version":    version.Version,
 		"assurance_name":       nameInspection,
 		"assurance_image_path": imageUrl,
 		"user_id":              idUserStr,
@@ -300,6 +453,9 @@ func CreateTRXInspection(c *gin.Context) {
 		"device_id":            deviceID,
 		"company_id":           userCompanyID,
 		"chaining_id":          chainingIDStr,
+		"submission_uuid":      submissionID,
+		"trx_inspection_id":    inspection.Id,
+		"score":                score,
 		"details":              responseDetails,
 	}
 
@@ -321,10 +477,21 @@ func CreateTRXInspection(c *gin.Context) {
 	utils.JSONSuccess(c, "Inspection created successfully", finalPayload)
 }
 
+// replayTrxInspection mengirim ulang hasil submit pertama untuk submission_uuid yang sudah tersimpan
+func replayTrxInspection(c *gin.Context, submissionID string) bool {
+	var existing models.TrxInspection
+	if err := utils.TenantDB(c).Where("submission_uuid = ?", submissionID).First(&existing).Error; err != nil {
+		return false
+	}
+	c.Header("Idempotent-Replayed", "true")
+	utils.JSONSuccess(c, "Inspection already submitted", json.RawMessage(existing.RawPayload))
+	return true
+}
+
 func UpdateTRXInspectionByID(c *gin.Context) {
 	id := c.Param("id")
 	var inspection models.TrxInspection
-	if err := config.DB.Preload("Details").First(&inspection, id).Error; err != nil {
+	if err := utils.TenantDB(c).Preload("Details").First(&inspection, id).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "TRX Inspection not found")
 		return
 	}
@@ -361,6 +528,16 @@ func DeleteTRXInspectionByID(c *gin.Context) {
 
 	id := c.Param("id")
 
+	var inspection models.TrxInspection
+	if err := utils.TenantDB(c).Select("id", "legal_hold").First(&inspection, id).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "TRX Inspection not found")
+		return
+	}
+	if inspection.LegalHold {
+		utils.JSONError(c, http.StatusConflict, "TRX Inspection is under legal hold")
+		return
+	}
+
 	if err := config.DB.Where("id_trx_inspection = ?", id).Delete(&models.TrxInspectionDetail{}).Error; err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -382,7 +559,12 @@ func GetFilteredTRXInspections(c *gin.Context) {
 	idTrx := c.Query("id_trx")
 
 	var inspections []models.TrxInspection
-	query := config.DB.Preload("Details")
+	// Attachments detail = evidence SAM point saja, attachment jawaban ada di raw_payload
+	query := config.DB.Preload("Details").
+		Preload("Details.Attachments", func(db *gorm.DB) *gorm.DB {
+			return db.Where("id_trx_inspection_answer IS NULL").Order("sort_order ASC, id ASC")
+		}).
+		Scopes(utils.CompanyScope(c), scoreFilter(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trxFixture = assurance published dengan SAM biasa dan SAM yang butuh 2 evidence
+type trxFixture struct {
+	inspection models.MstrInspection
+	plain      models.MstrInspectionDetail
+	evidence   models.MstrInspectionDetail
+}
+
+func seedTrxInspection(t *testing.T) trxFixture {
+	t.Helper()
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", IsActive: true, StorageBackend: storage.DriverMemory, E2BucketName: t.Name()})
+
+	var f trxFixture
+	f.inspection = models.MstrInspection{NameInspection: "Gudang", ImageUrl: "img/main.jpg", CompanyID: "COMP-A"}
+	mustCreate(t, db, &f.inspection)
+	f.plain = models.MstrInspectionDetail{IdMstrInspection: f.inspection.Id, NameCoordinate: "Pintu"}
+	mustCreate(t, db, &f.plain)
+	f.evidence = models.MstrInspectionDetail{IdMstrInspection: f.inspection.Id, NameCoordinate: "Atap", MinEvidence: 2}
+	mustCreate(t, db, &f.evidence)
+	if _, err := publishInspection(config.DB, f.inspection.Id, "tester", ""); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// postTrxInspection mengirim submit assurance multipart dengan file evidence
+func postTrxInspection(t *testing.T, inspectionID uint, details []gin.H, files map[string][]byte) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, _ := json.Marshal(details)
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("id_inspection", strconv.Itoa(int(inspectionID)))
+	w.WriteField("id_user", "1")
+	w.WriteField("details", string(raw))
+	for name, data := range files {
+		part, _ := w.CreateFormFile(name, name+".jpg")
+		part.Write(data)
+	}
+	w.Close()
+
+	r := adminRouter("COMP-A")
+	r.POST("/trx-inspections", CreateTRXInspection)
+	req := httptest.NewRequest(http.MethodPost, "/trx-inspections", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func assertNoSubmission(t *testing.T) {
+	t.Helper()
+	var count int64
+	config.DB.Model(&models.TrxInspection{}).Count(&count)
+	if count != 0 || len(bucketObjects(t, t.Name())) != 0 {
+		t.Errorf("rejected submission left %d trx rows and %d objects", count, len(bucketObjects(t, t.Name())))
+	}
+}
+
+// SAM yang tidak ada ditolak sebelum evidence SAM lain di-upload
+func TestCreateTRXInspectionUnknownSamBeforeUpload(t *testing.T) {
+	f := seedTrxInspection(t)
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+	rec := postTrxInspection(t, f.inspection.Id, []gin.H{
+		{"id_coordinate": f.evidence.Id, "capture_url": "cap1", "attachments": []gin.H{{"file_key": "cap2", "file_type": "photo"}}},
+		{"id_coordinate": 9999},
+	}, map[string][]byte{"cap1": jpeg, "cap2": jpeg})
+
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "SAM ID 9999 not found") {
+		t.Fatalf("status = %d, body = %s, want 422 with unknown SAM", rec.Code, rec.Body.String())
+	}
+	assertNoSubmission(t)
+}
+
+// SAM dengan min_evidence tidak bisa dilewati dengan tidak mengirimnya sama sekali
+func TestCreateTRXInspectionMissingEvidenceSam(t *testing.T) {
+	f := seedTrxInspection(t)
+
+	rec := postTrxInspection(t, f.inspection.Id, []gin.H{{"id_coordinate": f.plain.Id}}, nil)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Errors []struct {
+				SamID  uint     `json:"sam_id"`
+				Errors []string `json:"errors"`
+			} `json:"errors"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Data.Errors) != 1 || resp.Data.Errors[0].SamID != f.evidence.Id || !strings.Contains(resp.Data.Errors[0].Errors[0], "at least 2 evidence") {
+		t.Fatalf("errors = %+v, want min_evidence error for SAM %d", resp.Data.Errors, f.evidence.Id)
+	}
+	assertNoSubmission(t)
+}
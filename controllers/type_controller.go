@@ -36,7 +36,7 @@ func UpdateTypeByID(c *gin.Context) {
 	typeID := c.Param("id")
 
 	var type_trigger models.MstrTypeTrigger
-	if err := config.DB.
+	if err := utils.TenantDB(c).
 		Where("id = ?", typeID).
 		First(&type_trigger).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "Type not found")
@@ -74,6 +74,11 @@ func DeleteTypeByID(c *gin.Context) {
 	typeID := c.Param("id")
 	deletedBy := c.GetString("username")
 
+	if err := utils.TenantDB(c).Select("id").First(&models.MstrTypeTrigger{}, typeID).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Type not found")
+		return
+	}
+
 	// Set DeletedBy
 	if err := config.DB.Model(&models.MstrTypeTrigger{}).
 		Where("id = ?", typeID).
@@ -96,13 +101,7 @@ func GetFilteredTypes(c *gin.Context) {
 	typeID := c.Query("id")
 
 	var types []models.MstrTypeTrigger
-	query := config.DB.Model(&models.MstrTypeTrigger{})
-
-	role := c.GetString("role")
-	userCompanyID := c.GetString("company_id")
-	if role != "super-admin" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query := config.DB.Model(&models.MstrTypeTrigger{}).Scopes(utils.CompanyScope(c))
 
 	if createdBy != "" {
 		query = query.Where("created_by = ?", createdBy)
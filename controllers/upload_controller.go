@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/storage"
+	"go-api/utils"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	presignPutTTL       = 15 * time.Minute
+	presignMultipartTTL = time.Hour
+	multipartThreshold  = 32 << 20 // di atas ini wajib multipart
+	multipartPartSize   = 16 << 20
+	maxDirectUploadSize = 5 << 30
+	maxMultipartParts   = 10000
+)
+
+var (
+	sha256HexPattern = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+	errUploadSizeMismatch = errors.New("uploaded object size does not match")
+	errUploadHashMismatch = errors.New("uploaded object sha256 does not match")
+	errUploadNotFound     = errors.New("uploaded object not found in storage")
+)
+
+type PresignUploadReq struct {
+	Module         string `json:"module"`
+	FileName       string `json:"file_name" binding:"required"`
+	ContentType    string `json:"content_type"`
+	Size           int64  `json:"size" binding:"required"`
+	SHA256         string `json:"sha256"`
+	Multipart      bool   `json:"multipart"`
+	SubmissionUUID string `json:"submission_uuid"`
+	FileKey        string `json:"file_key"`
+}
+
+type CompleteUploadReq struct {
+	Parts []struct {
+		PartNumber int64  `json:"part_number"`
+		ETag       string `json:"etag"`
+	} `json:"parts"`
+}
+
+// POST /uploads/presign → presigned PUT (atau multipart) langsung ke bucket company
+func PresignUpload(c *gin.Context) {
+	var req PresignUploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	companyID := c.GetString("company_id")
+	username := c.GetString("username")
+
+	module := moduleTrnAssurance
+	if req.Module == "questionnaire" {
+		module = moduleTrnQuestionnaire
+	}
+	if req.Size <= 0 || req.Size > maxDirectUploadSize {
+		utils.JSONError(c, http.StatusBadRequest, "size must be between 1 byte and 5 GB")
+		return
+	}
+	req.SHA256 = strings.ToLower(strings.TrimSpace(req.SHA256))
+	if req.SHA256 != "" && !sha256HexPattern.MatchString(req.SHA256) {
+		utils.JSONError(c, http.StatusBadRequest, "sha256 must be 64 hex characters")
+		return
+	}
+	if req.SubmissionUUID != "" {
+		id, err := uuid.Parse(req.SubmissionUUID)
+		if err != nil {
+			utils.JSONError(c, http.StatusBadRequest, errInvalidSubmissionUUID.Error())
+			return
+		}
+		req.SubmissionUUID = id.String()
+	}
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+	if err := checkStorageQuota(companyID, req.Size); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
+	backend, _, err := companyStorage(companyID)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	multipartBackend, canMultipart := backend.(storage.MultipartBackend)
+
+	upload := models.TrxUploadSession{
+		UploadID:       uuid.New().String(),
+		CompanyID:      companyID,
+		Module:         module,
+		ObjectKey:      "Assurance/" + companyID + "/" + GenerateE2ObjectKey(c, module, req.FileName),
+		FileName:       req.FileName,
+		ContentType:    req.ContentType,
+		ExpectedSize:   req.Size,
+		ExpectedSHA256: req.SHA256,
+		SubmissionUUID: req.SubmissionUUID,
+		FileKey:        req.FileKey,
+		DeviceID:       c.GetString("auth_device_id"),
+		Status:         models.UploadStatusPending,
+		CreatedBy:      username,
+	}
+
+	// ===== Single PUT ===== (backend tanpa multipart, mis. lokal, selalu single PUT)
+	if !canMultipart || (!req.Multipart && req.Size <= multipartThreshold) {
+		upload.ExpiresAt = time.Now().Add(presignPutTTL)
+		url, err := backend.PresignPut(c.Request.Context(), upload.ObjectKey, req.ContentType, presignPutTTL)
+		if err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to presign upload: "+err.Error())
+			return
+		}
+		if err := config.DB.Create(&upload).Error; err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.JSONSuccess(c, "Upload URL created", gin.H{
+			"upload":  upload,
+			"method":  http.MethodPut,
+			"url":     url,
+			"headers": gin.H{"Content-Type": req.ContentType},
+		})
+		return
+	}
+
+	// ===== Multipart =====
+	upload.PartSize = multipartPartSize
+	upload.PartCount = int((req.Size + multipartPartSize - 1) / multipartPartSize)
+	if upload.PartCount > maxMultipartParts {
+		utils.JSONError(c, http.StatusBadRequest, "File too large for multipart upload")
+		return
+	}
+	upload.ExpiresAt = time.Now().Add(presignMultipartTTL)
+
+	multipartID, err := multipartBackend.CreateMultipart(c.Request.Context(), upload.ObjectKey, req.ContentType)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to start multipart upload: "+err.Error())
+		return
+	}
+	upload.MultipartID = multipartID
+
+	parts := make([]gin.H, 0, upload.PartCount)
+	for i := 1; i <= upload.PartCount; i++ {
+		url, err := multipartBackend.PresignPart(c.Request.Context(), upload.ObjectKey, multipartID, int64(i), presignMultipartTTL)
+		if err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to presign part: "+err.Error())
+			return
+		}
+		parts = append(parts, gin.H{"part_number": i, "url": url})
+	}
+
+	if err := config.DB.Create(&upload).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Multipart upload created", gin.H{
+		"upload":    upload,
+		"method":    http.MethodPut,
+		"part_size": upload.PartSize,
+		"parts":     parts,
+	})
+}
+
+// POST /uploads/:uploadID/complete → selesaikan multipart (jika ada) lalu verifikasi size/hash
+func CompleteUpload(c *gin.Context) {
+	var upload models.TrxUploadSession
+	if err := utils.TenantDB(c).Where("upload_id = ?", c.Param("uploadID")).First(&upload).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Upload not found")
+		return
+	}
+	if upload.Status == models.UploadStatusVerified {
+		utils.JSONSuccess(c, "Upload verified", upload)
+		return
+	}
+	if upload.Status == models.UploadStatusAborted {
+		utils.JSONError(c, http.StatusConflict, "Upload was aborted")
+		return
+	}
+
+	var req CompleteUploadReq
+	_ = c.ShouldBindJSON(&req)
+
+	backend, _, err := companyStorage(upload.CompanyID)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if upload.MultipartID != "" {
+		if len(req.Parts) != upload.PartCount {
+			utils.JSONError(c, http.StatusBadRequest, fmt.Sprintf("Expected %d parts", upload.PartCount))
+			return
+		}
+		multipartBackend, ok := backend.(storage.MultipartBackend)
+		if !ok {
+			utils.JSONError(c, http.StatusConflict, storage.ErrMultipartUnsupported.Error())
+			return
+		}
+		completed := make([]storage.CompletedPart, 0, len(req.Parts))
+		for _, p := range req.Parts {
+			completed = append(completed, storage.CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag})
+		}
+		if err := multipartBackend.CompleteMultipart(c.Request.Context(), upload.ObjectKey, upload.MultipartID, completed); err != nil {
+			utils.JSONError(c, http.StatusBadRequest, "Failed to complete multipart upload: "+err.Error())
+			return
+		}
+	}
+
+	if err := verifyUploadSession(backend, &upload); err != nil {
+		status := http.StatusUnprocessableEntity
+		if !errors.Is(err, errUploadSizeMismatch) && !errors.Is(err, errUploadHashMismatch) && !errors.Is(err, errUploadNotFound) {
+			status = http.StatusInternalServerError
+		}
+		utils.JSONError(c, status, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Upload verified", upload)
+}
+
+// DELETE /uploads/:uploadID → batalkan upload (abort multipart / hapus object)
+func AbortUpload(c *gin.Context) {
+	var upload models.TrxUploadSession
+	if err := utils.TenantDB(c).Where("upload_id = ?", c.Param("uploadID")).First(&upload).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Upload not found")
+		return
+	}
+	if upload.Status == models.UploadStatusVerified {
+		utils.JSONError(c, http.StatusConflict, "Upload already verified")
+		return
+	}
+
+	backend, _, err := companyStorage(upload.CompanyID)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if multipartBackend, ok := backend.(storage.MultipartBackend); ok && upload.MultipartID != "" {
+		multipartBackend.AbortMultipart(c.Request.Context(), upload.ObjectKey, upload.MultipartID)
+	}
+	backend.Delete(c.Request.Context(), upload.ObjectKey)
+
+	if err := config.DB.Model(&upload).Update("status", models.UploadStatusAborted).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Upload aborted", nil)
+}
+
+// verifyUploadSession cek object benar-benar ada dan size/sha256 sesuai yang dideklarasikan.
+// Hash dihitung secara streaming, object tidak disimpan di memory.
+func verifyUploadSession(backend storage.Backend, upload *models.TrxUploadSession) error {
+	ctx := context.Background()
+	head, err := backend.Head(ctx, upload.ObjectKey)
+	if err != nil {
+		return errUploadNotFound
+	}
+	if head.Size != upload.ExpectedSize {
+		return errUploadSizeMismatch
+	}
+
+	// selalu di-hash (streaming) untuk metadata evidence; dibandingkan jika client mengirim sha256
+	body, _, err := backend.Get(ctx, upload.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	hr := newEvidenceHasher()
+	if _, err := io.Copy(hr, body); err != nil {
+		return err
+	}
+	if upload.ExpectedSHA256 != "" && hr.Sum() != upload.ExpectedSHA256 {
+		return errUploadHashMismatch
+	}
+
+	now := time.Now()
+	upload.Status = models.UploadStatusVerified
+	upload.VerifiedAt = &now
+	if err := config.DB.Model(upload).Updates(map[string]interface{}{
+		"status":      upload.Status,
+		"verified_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	recordEvidenceMetadata(upload.CompanyID, upload.ObjectKey, upload.ContentType, upload.CreatedBy, hr)
+	recordStorageUsage(upload.CompanyID, upload.Module, hr.size, 1)
+	queueImageVariants(backend, upload.CompanyID, upload.ObjectKey, upload.ContentType)
+
+	// Upload untuk submission tertentu → tercatat sebagai evidence (dipakai saat submit)
+	if upload.SubmissionUUID != "" && upload.FileKey != "" {
+		evidence := models.TrxSubmissionEvidence{
+			SubmissionUUID: upload.SubmissionUUID,
+			FileKey:        upload.FileKey,
+			CompanyID:      upload.CompanyID,
+			Module:         upload.Module,
+			ObjectKey:      upload.ObjectKey,
+			FileName:       upload.FileName,
+			ContentType:    upload.ContentType,
+			Size:           upload.ExpectedSize,
+			CreatedBy:      upload.CreatedBy,
+		}
+		if _, err := findSubmissionEvidence(upload.CompanyID, upload.SubmissionUUID, upload.FileKey); err != nil {
+			config.DB.Create(&evidence)
+		}
+	}
+
+	return nil
+}
+
+// findUploadSession mencari upload langsung (presigned) berdasarkan object key / upload_id.
+// Hanya upload dari device yang sama, untuk submission yang sama (kalau upload terikat submission)
+// dan yang belum pernah dipakai submission lain.
+func findUploadSession(c *gin.Context, companyID, submissionID, ref string) (*models.TrxUploadSession, error) {
+	var upload models.TrxUploadSession
+	if err := config.DB.
+		Where("company_id = ? AND (object_key = ? OR upload_id = ?) AND status <> ?", companyID, ref, ref, models.UploadStatusAborted).
+		Where("device_id = ? AND submission_uuid IN ? AND consumed_at IS NULL", c.GetString("auth_device_id"), []string{"", submissionID}).
+		First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// resolveUploadedObject = findUploadSession, upload yang belum diverifikasi dicek dulu ke storage.
+func resolveUploadedObject(c *gin.Context, companyID, submissionID, ref string) (*models.TrxUploadSession, error) {
+	upload, err := findUploadSession(c, companyID, submissionID, ref)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status == models.UploadStatusVerified {
+		return upload, nil
+	}
+	if upload.MultipartID != "" {
+		return nil, fmt.Errorf("multipart upload %s is not completed", upload.UploadID)
+	}
+
+	backend, _, err := companyStorage(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyUploadSession(backend, upload); err != nil {
+		return nil, fmt.Errorf("%s: %v", ref, err)
+	}
+	return upload, nil
+}
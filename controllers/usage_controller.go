@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	moduleMasterAssurance = "Master-Assurance"
+	moduleMasterCompany   = "Master-Company"
+	moduleOther           = "Other"
+
+	usagePeriodLayout = "2006-01"
+)
+
+var usageModules = []string{moduleMasterAssurance, moduleTrnAssurance, moduleTrnQuestionnaire, moduleMasterCompany}
+
+// quotaError = batas MstrCompanyQuota terlampaui
+type quotaError struct {
+	Status int
+	Limit  string
+	Max    int64
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("%s quota exceeded (limit %d)", e.Limit, e.Max)
+}
+
+// respondQuotaError menulis response 402/429 jika err berasal dari quota, return false jika bukan
+func respondQuotaError(c *gin.Context, err error) bool {
+	var qe *quotaError
+	if !errors.As(err, &qe) {
+		return false
+	}
+	if qe.Status == http.StatusTooManyRequests {
+		// quota bulanan → reset awal bulan berikutnya
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+		c.Header("Retry-After", strconv.Itoa(int(next.Sub(now).Seconds())))
+	}
+	utils.JSONError(c, qe.Status, qe.Error())
+	return true
+}
+
+// usageModuleFromKey menebak module dari object key ("Assurance/<cid>/trn-assurance/2024/...")
+func usageModuleFromKey(objectKey string) string {
+	for _, part := range strings.Split(objectKey, "/") {
+		for _, module := range usageModules {
+			if strings.EqualFold(part, module) {
+				return module
+			}
+		}
+	}
+	return moduleOther
+}
+
+// recordStorageUsage menambah (atau mengurangi, jika negatif) pemakaian storage bulan berjalan
+func recordStorageUsage(companyID, module string, bytes, objects int64) {
+	if companyID == "" {
+		return
+	}
+	usage := models.TrxStorageUsage{
+		CompanyID: companyID,
+		Module:    module,
+		Period:    time.Now().Format(usagePeriodLayout),
+		Bytes:     bytes,
+		Objects:   objects,
+	}
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "company_id"}, {Name: "module"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"bytes":      gorm.Expr("trx_storage_usage.bytes + ?", bytes),
+			"objects":    gorm.Expr("trx_storage_usage.objects + ?", objects),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&usage).Error
+	if err != nil {
+		log.Printf("storage usage %s/%s: %v", companyID, module, err)
+	}
+}
+
+func companyQuota(companyID string) *models.MstrCompanyQuota {
+	var quota models.MstrCompanyQuota
+	if err := config.DB.Where("company_id = ?", companyID).First(&quota).Error; err != nil {
+		return nil
+	}
+	return &quota
+}
+
+func companyStorageBytes(companyID string) int64 {
+	var total int64
+	config.DB.Model(&models.TrxStorageUsage{}).Where("company_id = ?", companyID).
+		Select("COALESCE(SUM(bytes), 0)").Scan(&total)
+	return total
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// companySubmissions = submit assurance + questionnaire sejak `since` (yang sudah dihapus tetap dihitung)
+func companySubmissions(companyID string, since time.Time) int64 {
+	var trx, answers int64
+	config.DB.Unscoped().Model(&models.TrxInspection{}).Where("company_id = ? AND created_at >= ?", companyID, since).Count(&trx)
+	config.DB.Unscoped().Model(&models.MstrAnswer{}).Where("company_id = ? AND created_at >= ?", companyID, since).Count(&answers)
+	return trx + answers
+}
+
+// checkStorageQuota dipanggil sebelum upload dengan ukuran file yang akan masuk
+func checkStorageQuota(companyID string, incoming int64) error {
+	quota := companyQuota(companyID)
+	if quota == nil || quota.MaxStorageBytes <= 0 {
+		return nil
+	}
+	if companyStorageBytes(companyID)+incoming > quota.MaxStorageBytes {
+		return &quotaError{Status: http.StatusPaymentRequired, Limit: "Storage", Max: quota.MaxStorageBytes}
+	}
+	return nil
+}
+
+// checkSubmissionQuota dipanggil sebelum submit baru (retry dengan submission_uuid yang sama tidak dihitung)
+func checkSubmissionQuota(companyID string) error {
+	quota := companyQuota(companyID)
+	if quota == nil || quota.MaxSubmissionsPerMonth <= 0 {
+		return nil
+	}
+	if companySubmissions(companyID, monthStart(time.Now())) >= int64(quota.MaxSubmissionsPerMonth) {
+		return &quotaError{Status: http.StatusTooManyRequests, Limit: "Monthly submission", Max: int64(quota.MaxSubmissionsPerMonth)}
+	}
+	return nil
+}
+
+// checkDeviceQuota dipanggil sebelum device diaktifkan; db bisa berupa tx yang sedang berjalan
+func checkDeviceQuota(db *gorm.DB, companyID string) error {
+	quota := companyQuota(companyID)
+	if quota == nil || quota.MaxDevices <= 0 {
+		return nil
+	}
+	var active int64
+	db.Model(&models.MstrDevice{}).Where("company_id = ? AND is_active = ?", companyID, true).Count(&active)
+	if active >= int64(quota.MaxDevices) {
+		return &quotaError{Status: http.StatusPaymentRequired, Limit: "Device", Max: int64(quota.MaxDevices)}
+	}
+	return nil
+}
+
+func checkUserQuota(companyID string) error {
+	quota := companyQuota(companyID)
+	if quota == nil || quota.MaxUsers <= 0 {
+		return nil
+	}
+	var users int64
+	config.DB.Model(&models.MstrUser{}).Where("company_id = ?", companyID).Count(&users)
+	if users >= int64(quota.MaxUsers) {
+		return &quotaError{Status: http.StatusPaymentRequired, Limit: "User", Max: int64(quota.MaxUsers)}
+	}
+	return nil
+}
+
+type submissionUsage struct {
+	Period        string `json:"period"`
+	TrxInspection int64  `json:"trx_inspection"`
+	Questionnaire int64  `json:"questionnaire"`
+}
+
+// GET /usage?company_id=&months=12 → pemakaian storage & submission per bulan beserta quota
+func GetCompanyUsage(c *gin.Context) {
+	companyID := requestCompanyID(c)
+
+	months, _ := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if months <= 0 || months > 60 {
+		months = 12
+	}
+	since := monthStart(time.Now()).AddDate(0, -(months - 1), 0)
+
+	var storageUsage []models.TrxStorageUsage
+	if err := config.DB.Where("company_id = ? AND period >= ?", companyID, since.Format(usagePeriodLayout)).
+		Order("period DESC, module").Find(&storageUsage).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var trxRows, answerRows []struct {
+		Period string
+		Total  int64
+	}
+	config.DB.Unscoped().Model(&models.TrxInspection{}).
+		Select("to_char(created_at, 'YYYY-MM') AS period, COUNT(*) AS total").
+		Where("company_id = ? AND created_at >= ?", companyID, since).Group("period").Scan(&trxRows)
+	config.DB.Unscoped().Model(&models.MstrAnswer{}).
+		Select("to_char(created_at, 'YYYY-MM') AS period, COUNT(*) AS total").
+		Where("company_id = ? AND created_at >= ?", companyID, since).Group("period").Scan(&answerRows)
+
+	submissions := make([]submissionUsage, 0, months)
+	index := make(map[string]int, months)
+	for i := 0; i < months; i++ {
+		period := monthStart(time.Now()).AddDate(0, -i, 0).Format(usagePeriodLayout)
+		index[period] = len(submissions)
+		submissions = append(submissions, submissionUsage{Period: period})
+	}
+	for _, r := range trxRows {
+		if i, ok := index[r.Period]; ok {
+			submissions[i].TrxInspection = r.Total
+		}
+	}
+	for _, r := range answerRows {
+		if i, ok := index[r.Period]; ok {
+			submissions[i].Questionnaire = r.Total
+		}
+	}
+
+	var devices, users int64
+	config.DB.Model(&models.MstrDevice{}).Where("company_id = ? AND is_active = ?", companyID, true).Count(&devices)
+	config.DB.Model(&models.MstrUser{}).Where("company_id = ?", companyID).Count(&users)
+
+	quota := companyQuota(companyID)
+	if quota == nil {
+		quota = &models.MstrCompanyQuota{CompanyID: companyID}
+	}
+
+	utils.JSONSuccess(c, "Company usage", gin.H{
+		"company_id": companyID,
+		"quota":      quota,
+		"current": gin.H{
+			"storage_bytes":         companyStorageBytes(companyID),
+			"active_devices":        devices,
+			"users":                 users,
+			"submissions_per_month": companySubmissions(companyID, monthStart(time.Now())),
+		},
+		"storage":     storageUsage,
+		"submissions": submissions,
+	})
+}
+
+type CompanyQuotaReq struct {
+	MaxDevices             *int   `json:"max_devices"`
+	MaxUsers               *int   `json:"max_users"`
+	MaxSubmissionsPerMonth *int   `json:"max_submissions_per_month"`
+	MaxStorageBytes        *int64 `json:"max_storage_bytes"`
+}
+
+// PUT /usage/quota?company_id= → set quota company (0 = tidak dibatasi)
+func UpsertCompanyQuota(c *gin.Context) {
+	companyID := requestCompanyID(c)
+
+	var req CompanyQuotaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (req.MaxDevices != nil && *req.MaxDevices < 0) || (req.MaxUsers != nil && *req.MaxUsers < 0) ||
+		(req.MaxSubmissionsPerMonth != nil && *req.MaxSubmissionsPerMonth < 0) || (req.MaxStorageBytes != nil && *req.MaxStorageBytes < 0) {
+		utils.JSONError(c, http.StatusBadRequest, "quota must be 0 (unlimited) or greater")
+		return
+	}
+
+	var company models.MstrCompany
+	if err := config.DB.Where("company_id = ?", companyID).First(&company).Error; err != nil {
+		utils.JSONError(c, http.StatusNotFound, "Company not found")
+		return
+	}
+
+	username := c.GetString("username")
+	quota := companyQuota(companyID)
+	if quota == nil {
+		quota = &models.MstrCompanyQuota{CompanyID: companyID, CreatedBy: username}
+	}
+	if req.MaxDevices != nil {
+		quota.MaxDevices = *req.MaxDevices
+	}
+	if req.MaxUsers != nil {
+		quota.MaxUsers = *req.MaxUsers
+	}
+	if req.MaxSubmissionsPerMonth != nil {
+		quota.MaxSubmissionsPerMonth = *req.MaxSubmissionsPerMonth
+	}
+	if req.MaxStorageBytes != nil {
+		quota.MaxStorageBytes = *req.MaxStorageBytes
+	}
+	quota.UpdatedBy = username
+
+	// Save → nilai 0 ikut tersimpan (kolom tanpa default true)
+	if err := config.DB.Save(quota).Error; err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONSuccess(c, "Company quota saved", quota)
+}
@@ -19,8 +19,6 @@ import (
 
 func GetFilteredUsers(c *gin.Context) {
 
-	role := c.GetString("role")
-	userCompanyID := c.GetString("company_id")
 	//username := c.GetString("username")
 
 	// Filter dari query param
@@ -39,9 +37,7 @@ func GetFilteredUsers(c *gin.Context) {
 		}
 	*/
 
-	if role != "super-admin" && userCompanyID != "" {
-		query = query.Where("company_id = ?", userCompanyID)
-	}
+	query = query.Scopes(utils.CompanyScope(c))
 
 	// Tambahkan filter dinamis
 	for field, value := range filters {
@@ -66,7 +62,6 @@ func CreateUser(c *gin.Context) {
 	// Ambil data dari context dengan type assertion
 
 	username := c.GetString("username")
-	role := c.GetString("role")
 	userCompanyID := c.GetString("company_id")
 
 	// Bind request body
@@ -94,10 +89,22 @@ func CreateUser(c *gin.Context) {
 
 	// Tentukan CompanyID berdasarkan role
 	companyCode := userCompanyID
-	if role == "super-admin" && input.CompanyID != "" {
+	if utils.HasPermission(c, utils.PermPlatformAdmin) && input.CompanyID != "" {
 		companyCode = input.CompanyID
 	}
 
+	// Role harus role sistem atau custom role aktif milik company
+	role, err := checkAssignableRole(c, companyCode, input.Role)
+	if err != nil {
+		utils.JSONError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.Role = role
+	if err := checkUserQuota(companyCode); err != nil {
+		respondQuotaError(c, err)
+		return
+	}
+
 	//now := time.Now()
 	user := models.MstrUser{
 		Username:  input.Username,
@@ -130,7 +137,7 @@ func UpdateUserByID(c *gin.Context) {
 
 	// Cari user by ID
 	var user models.MstrUser
-	if err := config.DB.WithContext(c.Request.Context()).First(&user, id).Error; err != nil {
+	if err := utils.TenantDB(c).First(&user, id).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "User not found")
 		return
 	}
@@ -166,6 +173,18 @@ func UpdateUserByID(c *gin.Context) {
 		}
 	}
 
+	// Role harus role sistem atau custom role aktif milik company
+	if normalizeRoleName(input.Role) != user.Role {
+		role, err := checkAssignableRole(c, user.CompanyID, input.Role)
+		if err != nil {
+			utils.JSONError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		input.Role = role
+	} else {
+		input.Role = user.Role
+	}
+
 	// Update field
 	user.Username = input.Username
 	user.FullName = input.FullName
@@ -193,6 +212,14 @@ func UpdateUserByID(c *gin.Context) {
 		return
 	}
 
+	// User dinonaktifkan → sesi langsung tidak berlaku
+	if !user.IsActive {
+		if err := utils.RevokeUserSessions(user.Id, utils.RevokeUserDeactivated); err != nil {
+			utils.JSONError(c, http.StatusInternalServerError, "Failed to revoke user sessions")
+			return
+		}
+	}
+
 	utils.JSONSuccess(c, "User updated", user)
 }
 
@@ -202,7 +229,7 @@ func DeleteUserByID(c *gin.Context) {
 
 	// Cari user yang akan dihapus
 	var user models.MstrUser
-	if err := config.DB.WithContext(c.Request.Context()).First(&user, id).Error; err != nil {
+	if err := utils.TenantDB(c).First(&user, id).Error; err != nil {
 		utils.JSONError(c, http.StatusNotFound, "User not found")
 		return
 	}
@@ -229,6 +256,11 @@ func DeleteUserByID(c *gin.Context) {
 		return
 	}
 
+	if err := utils.RevokeUserSessions(user.Id, utils.RevokeUserDeleted); err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to revoke user sessions")
+		return
+	}
+
 	utils.JSONSuccess(c, "User deleted", nil)
 }
 
@@ -263,15 +295,23 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Cek role admin/super-admin — tidak boleh login via mobile app (pakai device_id)
-	if (user.Role == "admin" || user.Role == "super-admin") && req.DeviceID != "" {
+	// Permission dari role (sistem / custom role company)
+	perms, err := utils.ResolvePermissions(user.CompanyID, user.Role)
+	if err != nil {
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to load role permissions")
+		return
+	}
+	isPlatformAdmin := perms[utils.PermPlatformAdmin]
+
+	// Role tanpa device.sync (dan platform admin) tidak boleh login via mobile app (pakai device_id)
+	if (isPlatformAdmin || !perms[utils.PermDeviceSync]) && req.DeviceID != "" {
 		utils.JSONError(c, http.StatusBadRequest, "This role is not allowed to login to mobile app")
 		return
 	}
 
-	// Kalau bukan super-admin
+	// Kalau bukan platform admin
 	var company models.MstrCompany
-	if user.Role != "super-admin" {
+	if !isPlatformAdmin {
 
 		//Cek apakah company ada
 		if err := config.DB.WithContext(c.Request.Context()).
@@ -292,8 +332,8 @@ func Login(c *gin.Context) {
 			return
 		}
 
-		// Kalau role user
-		if user.Role == "user" {
+		// Kalau role untuk tablet (device.sync)
+		if perms[utils.PermDeviceSync] {
 
 			//cek apakah device_id ada
 			if req.DeviceID == "" {
@@ -318,18 +358,19 @@ func Login(c *gin.Context) {
 
 	}
 
-	// Generate tokens
-	accessToken, expiresAt, err := utils.GenerateAccessToken(
-		user.Id, user.Username, user.Email, user.Role, user.CompanyID,
-		30*24*time.Hour)
+	// Buat sesi server-side + refresh token (disimpan hash-nya saja)
+	session, refreshToken, err := utils.CreateSession(user, req.DeviceID, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		utils.JSONError(c, http.StatusInternalServerError, "Failed to generate access token")
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to create session")
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.Id, 7*24*time.Hour)
+	// Generate access token (berumur pendek, terikat ke sesi)
+	accessToken, expiresAt, err := utils.GenerateAccessToken(
+		user.Id, user.Username, user.Email, user.Role, user.CompanyID,
+		session.Id, utils.AccessTokenTTL)
 	if err != nil {
-		utils.JSONError(c, http.StatusInternalServerError, "Failed to generate refresh token")
+		utils.JSONError(c, http.StatusInternalServerError, "Failed to generate access token")
 		return
 	}
 
@@ -342,13 +383,13 @@ func Login(c *gin.Context) {
 		"company_name":  company.CompanyName,
 		"company_logo":  company.ImageUrl,
 		"role":          user.Role,
+		"permissions":   utils.PermissionList(perms),
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
 		"expires_at":    expiresAt,
+		"session_id":    session.Id,
 	}
 
-	fmt.Println("[DEBUG] Login successful:", response) // debug
-
 	utils.JSONSuccess(c, "Login successful", response)
 }
 
@@ -361,42 +402,42 @@ func Refresh(c *gin.Context) {
 		return
 	}
 
-	claims, err := utils.ParseToken(req.RefreshToken)
+	// Rotate: refresh token lama langsung tidak berlaku, dipakai ulang → sesi di-revoke
+	session, refreshToken, err := utils.RotateRefreshToken(req.RefreshToken, c.ClientIP())
 	if err != nil {
+		if errors.Is(err, utils.ErrRefreshTokenReused) {
+			utils.JSONError(c, http.StatusUnauthorized, "Refresh token already used, session revoked")
+			return
+		}
 		utils.JSONError(c, http.StatusUnauthorized, "Invalid refresh token")
 		return
 	}
 
-	if claims["scope"] != "refresh" {
-		utils.JSONError(c, http.StatusForbidden, "Invalid token scope")
-		return
-	}
-
-	// Ambil user_id dari refresh token
-	userIDFloat, ok := claims["sub"].(float64)
-	if !ok {
-		utils.JSONError(c, http.StatusUnauthorized, "Invalid subject")
-		return
-	}
-	userID := uint(userIDFloat)
-
 	// Cari user dari DB
 	var user models.MstrUser
-	if err := config.DB.First(&user, userID).Error; err != nil {
+	if err := config.DB.First(&user, session.UserID).Error; err != nil {
+		utils.RevokeSession(session.Id, utils.RevokeUserDeleted)
 		utils.JSONError(c, http.StatusUnauthorized, "User not found")
 		return
 	}
 
+	if !user.IsActive {
+		utils.RevokeSession(session.Id, utils.RevokeUserDeactivated)
+		utils.JSONError(c, http.StatusForbidden, "User is not active")
+		return
+	}
+
 	// Buat access token baru
-	accessToken, expiresAt, err := utils.GenerateAccessToken(user.Id, user.Username, user.Email, user.Role, user.CompanyID, 15*time.Minute)
+	accessToken, expiresAt, err := utils.GenerateAccessToken(user.Id, user.Username, user.Email, user.Role, user.CompanyID, session.Id, utils.AccessTokenTTL)
 	if err != nil {
 		utils.JSONError(c, http.StatusInternalServerError, "Failed to generate new access token")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
-		"expires_at":   expiresAt,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_at":    expiresAt,
 	})
 }
 
@@ -483,5 +524,8 @@ func ResetPassword(c *gin.Context) {
 	reset.Used = true
 	config.DB.Save(&reset)
 
+	// Password diganti → semua sesi lama harus login ulang
+	utils.RevokeUserSessions(user.Id, utils.RevokeLogoutAll)
+
 	utils.JSONSuccess(c, "Password successfully updated", nil)
 }
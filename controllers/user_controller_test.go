@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Admin login lewat web (tanpa device_id), user tablet wajib device_id yang terdaftar
+func TestLoginDeviceRules(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.MstrCompany{CompanyName: "A", CompanyID: "COMP-A", IsActive: true})
+	mustCreate(t, db, &models.MstrDevice{DeviceID: "TAB-1", CompanyID: "COMP-A", IsActive: true})
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	mustCreate(t, db, &models.MstrUser{Username: "admin", Email: "admin@a.test", Password: string(hash), Role: utils.RoleAdmin, CompanyID: "COMP-A", IsActive: true})
+	mustCreate(t, db, &models.MstrUser{Username: "user", Email: "user@a.test", Password: string(hash), Role: utils.RoleUser, CompanyID: "COMP-A", IsActive: true})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", Login)
+
+	tests := []struct {
+		name     string
+		email    string
+		deviceID string
+		want     int
+		message  string
+	}{
+		{"admin without device", "admin@a.test", "", http.StatusOK, "Login successful"},
+		{"admin with device", "admin@a.test", "TAB-1", http.StatusBadRequest, "not allowed to login to mobile app"},
+		{"user with device", "user@a.test", "TAB-1", http.StatusOK, "Login successful"},
+		{"user without device", "user@a.test", "", http.StatusBadRequest, "Device ID is required"},
+		{"user with unknown device", "user@a.test", "TAB-9", http.StatusNotFound, "Device not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(r, http.MethodPost, "/login", gin.H{"email": tt.email, "password": "secret", "device_id": tt.deviceID})
+			if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.message) {
+				t.Fatalf("status = %d, body = %s, want %d %q", w.Code, w.Body.String(), tt.want, tt.message)
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"go-api/config"
+	"go-api/controllers"
 	"go-api/middleware"
 	"go-api/models"
 	"go-api/routes"
+	"go-api/secrets"
 	"log"
 	"os"
 
@@ -30,8 +32,12 @@ func main() {
 	config.DB.AutoMigrate(
 		&models.MstrCompany{},
 		&models.MstrUser{},
+		&models.MstrRole{},
+		&models.MstrRolePermission{},
 		&models.MstrInspection{},
 		&models.MstrInspectionDetail{},
+		&models.MstrInspectionVersion{},
+		&models.MstrInspectionPage{},
 		//&models.ChildInspection{},
 		//&models.ChildInspectionDetail{},
 		&models.MstrChaining{},
@@ -54,7 +60,34 @@ func main() {
 		&models.MstrInspectionQuestion{},
 		&models.MstrInspectionQuestionOption{},
 		&models.PasswordResetToken{},
+		&models.TrxUserSession{},
+		&models.TrxRefreshToken{},
+		&models.MstrDeviceCredential{},
+		&models.MstrDevicePairingCode{},
+		&models.TrxDeviceEnrolmentLog{},
+		&models.TrxDeviceHeartbeat{},
+		&models.TrxSubmissionEvidence{},
+		&models.TrxEvidenceAttachment{},
+		&models.TrxUploadSession{},
+		&models.TrxJobRun{},
+		&models.TrxEvidenceMetadata{},
+		&models.MstrRetentionPolicy{},
+		&models.TrxStorageUsage{},
+		&models.MstrCompanyQuota{},
 	)
+	controllers.MigrateLegacyInspectionVersions()
+	controllers.MigrateLegacyDeviceEnrolment()
+	controllers.MarkInterruptedJobRuns()
+	// tanpa master key credential storage company tidak bisa disimpan maupun dibaca
+	if _, err := secrets.ActiveKeyID(); err != nil {
+		log.Fatal(err)
+	}
+	if n, err := controllers.RotateCompanySecrets(); err != nil {
+		log.Println("Company secrets not encrypted:", err)
+	} else if n > 0 {
+		log.Printf("Encrypted storage credentials of %d companies", n)
+	}
+	controllers.StartRetentionScheduler()
 
 	r := gin.Default()
 
@@ -1,23 +1,47 @@
 package middleware
 
 import (
-	"fmt"
+	"crypto/subtle"
+	"go-api/utils"
 	"net/http"
 	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
+// APIKeyAuth menerima credential per device (X-Device-Key) atau API key global (legacy).
+// Pemakaian API key global dicek lagi per company di CheckCompanyActive.
 func APIKeyAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		fmt.Println("[DEBUG] APIKeyAuth START")
+		if raw := c.GetHeader(utils.DeviceKeyHeader); raw != "" {
+			cred, device, err := utils.VerifyDeviceKey(raw)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+				c.Abort()
+				return
+			}
+			c.Set("device_key_id", cred.KeyID)
+			c.Set("auth_device_id", device.DeviceID)
+			c.Set("auth_device_company", device.CompanyID)
+
+			// Endpoint tablet /devices/:deviceID/... hanya untuk device pemilik credential
+			if _, err := utils.AuthDeviceID(c, c.Param("deviceID")); err != nil {
+				utils.JSONError(c, http.StatusForbidden, "Device key does not match device")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		key := c.GetHeader("X-API-KEY")
-		if key != os.Getenv("API_KEY") {
+		legacy := os.Getenv("API_KEY")
+		if legacy == "" || subtle.ConstantTimeCompare([]byte(key), []byte(legacy)) != 1 {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-		fmt.Println("[DEBUG] APIKeyAuth END")
+		c.Set("legacy_api_key", true)
 		c.Next()
 	}
 }
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"go-api/config"
+	"go-api/models"
+	"go-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAPIKeyAuthDeviceMatch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "api_key.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.MstrDevice{}, &models.MstrDeviceCredential{}); err != nil {
+		t.Fatal(err)
+	}
+	prev := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = prev })
+
+	device := models.MstrDevice{DeviceID: "TAB-1", CompanyID: "COMP-A", IsActive: true}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, key, err := utils.IssueDeviceCredential(db, device, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("API_KEY", "legacy-key")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(APIKeyAuth())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/devices/:deviceID/inspections", ok)
+	r.POST("/trx-inspections", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"own device", http.MethodGet, "/devices/TAB-1/inspections", utils.DeviceKeyHeader, key, http.StatusOK},
+		{"other device in path", http.MethodGet, "/devices/TAB-2/inspections", utils.DeviceKeyHeader, key, http.StatusForbidden},
+		{"route without device param", http.MethodPost, "/trx-inspections", utils.DeviceKeyHeader, key, http.StatusOK},
+		{"legacy key any device", http.MethodGet, "/devices/TAB-2/inspections", "X-API-KEY", "legacy-key", http.StatusOK},
+		{"bad device key", http.MethodGet, "/devices/TAB-1/inspections", utils.DeviceKeyHeader, "dk_x.y", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
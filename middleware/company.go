@@ -15,18 +15,16 @@ func CheckCompanyActive() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fmt.Println("[DEBUG] CheckCompanyActive START")
 		// Ambil data dari JWT
-		roleVal, _ := c.Get("role")
 		userCompanyID, _ := c.Get("company_id")
 		emailVal, _ := c.Get("email")
 
-		role := roleVal.(string)
 		companyID := userCompanyID.(string)
 		email := emailVal.(string)
 
 		//log.Printf("[DEBUG] Email: %v, Role: %s, CompanyID: %s", email, role, companyID)
 
-		// Kalau super-admin → skip pengecekan
-		if role == "super-admin" {
+		// Kalau platform admin (super-admin) → skip pengecekan
+		if utils.HasPermission(c, utils.PermPlatformAdmin) {
 			c.Next()
 			return
 		}
@@ -47,6 +45,21 @@ func CheckCompanyActive() gin.HandlerFunc {
 			return
 		}
 
+		// === Cek credential device / API key legacy ===
+		if deviceCompany := c.GetString("auth_device_company"); deviceCompany != "" && deviceCompany != companyID {
+			log.Printf("[ERROR] Device key company mismatch: %s / %s", deviceCompany, companyID)
+			utils.JSONError(c, http.StatusForbidden, "Device does not belong to this company")
+			c.Abort()
+			return
+		}
+
+		if c.GetBool("legacy_api_key") && !company.AllowLegacyAPIKey && utils.HasPermission(c, utils.PermDeviceSync) {
+			log.Printf("[ERROR] Legacy API key disabled for company: %s", companyID)
+			utils.JSONError(c, http.StatusUnauthorized, "Legacy API key is disabled for this company, use device credentials")
+			c.Abort()
+			return
+		}
+
 		// === Cek user aktif di company ===
 		var user models.MstrUser
 		if err := config.DB.Where("email = ? AND company_id = ?", email, companyID).First(&user).Error; err != nil {
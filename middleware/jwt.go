@@ -34,6 +34,26 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if claims["scope"] != "access" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token scope"})
+			return
+		}
+
+		// Access token harus terikat ke sesi yang masih aktif (token lama tanpa sid wajib login ulang)
+		sid, ok := claims["sid"].(float64)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session expired, please login again"})
+			return
+		}
+		session, err := utils.ActiveSession(uint(sid))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session revoked or expired"})
+			return
+		}
+		utils.TouchSession(session)
+		c.Set("session_id", session.Id)
+		c.Set("user_id", session.UserID)
+
 		// Simpan ke context
 		if username, exists := claims["username"]; exists {
 			c.Set("username", username)
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"go-api/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission memastikan role user punya permission tertentu (lihat utils.DefaultRolePermissions / MstrRole)
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.HasPermission(c, perm) {
+			log.Printf("[ERROR] Permission denied: %s (role=%s, company=%s)", perm, c.GetString("role"), c.GetString("company_id"))
+			utils.JSONError(c, http.StatusForbidden, "Forbidden: missing permission "+perm)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
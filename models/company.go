@@ -1,6 +1,7 @@
 package models
 
 import (
+	"go-api/secrets"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,8 +15,8 @@ type MstrCompany struct {
 	E2Endpoint   string         `json:"e2_endpoint" gorm:"type:varchar(100);comment:E2 Endpoint"`
 	E2Region     string         `json:"e2_region" gorm:"type:varchar(100);comment:E2 Region"`
 	E2BucketName string         `json:"e2_bucket_name" gorm:"type:varchar(100);comment:E2 Bucket Name"`
-	E2AccessKey  string         `json:"e2_access_key" gorm:"type:varchar(100);comment:E2 Access Key"`
-	E2SecretKey  string         `json:"e2_secret_key" gorm:"type:varchar(100);comment:E2 Secret Key"`
+	E2AccessKey  secrets.String `json:"e2_access_key" gorm:"type:text;comment:E2 Access Key (envelope encrypted, masked in API responses)"`
+	E2SecretKey  secrets.String `json:"e2_secret_key" gorm:"type:text;comment:E2 Secret Key (envelope encrypted, masked in API responses)"`
 	IsActive     bool           `json:"is_active" gorm:"default:true;comment:Company status (true = active, false = inactive)"`
 	CreatedBy    string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created the company record"`
 	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the company record was first created"`
@@ -24,6 +25,10 @@ type MstrCompany struct {
 	DeletedBy    string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	AllowLegacyAPIKey bool `json:"allow_legacy_api_key" gorm:"default:true;comment:Allow tablets to authenticate with the shared global X-API-KEY instead of per-device credentials"`
+
+	StorageBackend string `json:"storage_backend" gorm:"type:varchar(20);default:s3;comment:Object storage driver for company files (s3 = S3-compatible/IDrive E2, local = server filesystem)"`
+
 	Devices        []MstrDevice     `json:"devices" gorm:"foreignKey:CompanyID;references:CompanyID;comment:List of devices owned by the company"`
 	Groups         []MstrGroup      `json:"groups" gorm:"foreignKey:CompanyID;references:CompanyID;comment:List of groups associated with the company"`
 	Users          []MstrUser       `json:"users" gorm:"foreignKey:CompanyID;references:CompanyID;comment:List of users registered under the company"`
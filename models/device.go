@@ -19,9 +19,110 @@ type MstrDevice struct {
 	DeletedBy  string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	EnrolmentStatus    string `json:"enrolment_status" gorm:"type:varchar(20);index;comment:Enrolment status (pending, approved, rejected)"`
+	EnrolledVia        string `json:"enrolled_via" gorm:"type:varchar(20);comment:How the device was enrolled (pairing_code, request, admin)"`
+	EnrolmentTokenHash string `json:"-" gorm:"type:varchar(64);index;comment:SHA-256 hash of the one-time token the tablet uses to poll its enrolment status"`
+
+	LastSeenAt     *time.Time `json:"last_seen_at" gorm:"index;comment:Timestamp of the last heartbeat received from the device"`
+	LastSyncAt     *time.Time `json:"last_sync_at" gorm:"comment:Timestamp of the last successful sync reported by the device"`
+	AppVersion     string     `json:"app_version" gorm:"type:varchar(50);index;comment:App version reported in the last heartbeat"`
+	OSName         string     `json:"os_name" gorm:"type:varchar(50);comment:Operating system reported in the last heartbeat"`
+	OSVersion      string     `json:"os_version" gorm:"type:varchar(50);comment:OS version reported in the last heartbeat"`
+	BatteryLevel   *int       `json:"battery_level" gorm:"comment:Battery level in percent from the last heartbeat"`
+	IsCharging     *bool      `json:"is_charging" gorm:"comment:Charging state from the last heartbeat"`
+	StorageFreeMB  *int64     `json:"storage_free_mb" gorm:"comment:Free storage in MB from the last heartbeat"`
+	StorageTotalMB *int64     `json:"storage_total_mb" gorm:"comment:Total storage in MB from the last heartbeat"`
+
 	Groups []MstrGroup `gorm:"many2many:mstr_group_device;comment:List of groups associated with the device" json:"groups"`
 }
 
 func (MstrDevice) TableName() string {
 	return "mstr_device"
 }
+
+// MstrDeviceCredential = key pair per device (X-Device-Key: <key_id>.<secret>), secret hanya disimpan hash-nya
+type MstrDeviceCredential struct {
+	Id         uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for device credential"`
+	DeviceID   uint       `json:"device_id" gorm:"not null;index;comment:Foreign key to MstrDevice.Id"`
+	CompanyID  string     `json:"company_id" gorm:"type:varchar(50);index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	KeyID      string     `json:"key_id" gorm:"type:varchar(64);uniqueIndex;not null;comment:Public key identifier sent by the tablet"`
+	SecretHash string     `json:"-" gorm:"type:varchar(64);not null;comment:SHA-256 hash of the device secret"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"comment:Timestamp when the credential was last used"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"index;comment:Timestamp when the credential was revoked (NULL = active)"`
+	RevokedBy  string     `json:"revoked_by" gorm:"type:varchar(100);comment:User or system that revoked the credential"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(100);comment:User or system that issued the credential"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the credential was issued"`
+}
+
+func (MstrDeviceCredential) TableName() string {
+	return "mstr_device_credential"
+}
+
+// Status enrolment device
+const (
+	EnrolmentPending  = "pending"
+	EnrolmentApproved = "approved"
+	EnrolmentRejected = "rejected"
+)
+
+// MstrDevicePairingCode = kode pendek (atau QR) yang dibuat admin, ditukar tablet untuk langsung terdaftar ke company/group
+type MstrDevicePairingCode struct {
+	Id        uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for pairing code"`
+	CompanyID string     `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	GroupID   *uint      `json:"group_id" gorm:"comment:Optional MstrGroup.ID the redeemed device is assigned to"`
+	Code      string     `json:"code" gorm:"type:varchar(16);uniqueIndex;not null;comment:Pairing code entered or scanned by the tablet"`
+	MaxUses   int        `json:"max_uses" gorm:"default:1;comment:Maximum number of devices that can redeem this code"`
+	UsedCount int        `json:"used_count" gorm:"default:0;comment:Number of devices that redeemed this code"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;comment:Pairing code expiry"`
+	RevokedAt *time.Time `json:"revoked_at" gorm:"comment:Timestamp when the code was revoked (NULL = active)"`
+	RevokedBy string     `json:"revoked_by" gorm:"type:varchar(100);comment:User that revoked the code"`
+	CreatedBy string     `json:"created_by" gorm:"type:varchar(100);comment:User that generated the code"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the code was generated"`
+
+	// Kode re-enrolment untuk satu device yang sudah aktif (tablet diganti / di-reset), credential lama di-revoke saat redeem
+	ReenrolDeviceID *uint `json:"reenrol_device_id" gorm:"index;comment:MstrDevice.Id this code re-enrols (replacing its credentials), NULL = new or inactive devices only"`
+
+	Group *MstrGroup `json:"group,omitempty" gorm:"foreignKey:GroupID;references:ID"`
+}
+
+func (MstrDevicePairingCode) TableName() string {
+	return "mstr_device_pairing_code"
+}
+
+// TrxDeviceEnrolmentLog = audit trail enrolment device (request, redeem, approve, reject, ...)
+type TrxDeviceEnrolmentLog struct {
+	Id            uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for enrolment log"`
+	DeviceID      uint      `json:"device_id" gorm:"not null;index;comment:Foreign key to MstrDevice.Id"`
+	CompanyID     string    `json:"company_id" gorm:"type:varchar(50);index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	Action        string    `json:"action" gorm:"type:varchar(30);not null;comment:Enrolment action (requested, redeemed, reenrolled, approved, rejected, credential_delivered)"`
+	PairingCodeID *uint     `json:"pairing_code_id" gorm:"comment:Pairing code used (for redeemed action)"`
+	Actor         string    `json:"actor" gorm:"type:varchar(100);comment:User or system that performed the action"`
+	Note          string    `json:"note" gorm:"type:text;comment:Optional note (e.g. rejection reason)"`
+	IPAddress     string    `json:"ip_address" gorm:"type:varchar(64);comment:Client IP of the request"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp of the action"`
+}
+
+func (TrxDeviceEnrolmentLog) TableName() string {
+	return "trx_device_enrolment_log"
+}
+
+// TrxDeviceHeartbeat = riwayat heartbeat tablet (state terakhir juga disimpan di MstrDevice)
+type TrxDeviceHeartbeat struct {
+	Id             uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for device heartbeat"`
+	DeviceID       uint       `json:"device_id" gorm:"not null;index:idx_heartbeat_device_time;comment:Foreign key to MstrDevice.Id"`
+	CompanyID      string     `json:"company_id" gorm:"type:varchar(50);index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	AppVersion     string     `json:"app_version" gorm:"type:varchar(50);comment:App version reported by the device"`
+	OSName         string     `json:"os_name" gorm:"type:varchar(50);comment:Operating system reported by the device"`
+	OSVersion      string     `json:"os_version" gorm:"type:varchar(50);comment:OS version reported by the device"`
+	BatteryLevel   *int       `json:"battery_level" gorm:"comment:Battery level in percent"`
+	IsCharging     *bool      `json:"is_charging" gorm:"comment:Charging state"`
+	StorageFreeMB  *int64     `json:"storage_free_mb" gorm:"comment:Free storage in MB"`
+	StorageTotalMB *int64     `json:"storage_total_mb" gorm:"comment:Total storage in MB"`
+	LastSyncAt     *time.Time `json:"last_sync_at" gorm:"comment:Last successful sync reported by the device"`
+	IPAddress      string     `json:"ip_address" gorm:"type:varchar(64);comment:Client IP of the heartbeat"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_heartbeat_device_time;comment:Timestamp when the heartbeat was received"`
+}
+
+func (TrxDeviceHeartbeat) TableName() string {
+	return "trx_device_heartbeat"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Status & alasan flag metadata evidence
+const (
+	EvidenceStatusOK      = "ok"
+	EvidenceStatusFlagged = "flagged"
+
+	EvidenceFlagContentType  = "content_type_mismatch" // MIME hasil sniff beda dengan Content-Type dari client
+	EvidenceFlagReuploaded   = "reuploaded"            // object key yang sama di-upload ulang dengan isi berbeda
+	EvidenceFlagDuplicate    = "duplicate"             // isi identik dengan evidence lain milik company
+	EvidenceFlagHashMismatch = "hash_mismatch"         // object di storage tidak lagi cocok dengan hash tercatat
+	EvidenceFlagMissing      = "missing"               // object hilang dari storage
+)
+
+// TrxEvidenceMetadata = sidik jari file evidence saat pertama diterima (hash, MIME, EXIF) untuk tamper-evidence
+type TrxEvidenceMetadata struct {
+	Id                  uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for evidence metadata"`
+	CompanyID           string     `json:"company_id" gorm:"type:varchar(50);not null;uniqueIndex:idx_evidence_metadata_object;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	ObjectKey           string     `json:"object_key" gorm:"type:varchar(500);not null;uniqueIndex:idx_evidence_metadata_object;comment:Object key of the evidence file in storage"`
+	SHA256              string     `json:"sha256" gorm:"type:varchar(64);not null;index;comment:SHA-256 hex of the file as first received"`
+	Size                int64      `json:"size" gorm:"comment:File size in bytes"`
+	MimeType            string     `json:"mime_type" gorm:"type:varchar(100);comment:MIME type sniffed from file content"`
+	DeclaredContentType string     `json:"declared_content_type" gorm:"type:varchar(100);comment:Content-Type sent by the client (not trusted)"`
+	CaptureTime         *time.Time `json:"capture_time" gorm:"comment:EXIF DateTimeOriginal (camera local time)"`
+	GPSLatitude         *float64   `json:"gps_latitude" gorm:"comment:EXIF GPS latitude (decimal degrees)"`
+	GPSLongitude        *float64   `json:"gps_longitude" gorm:"comment:EXIF GPS longitude (decimal degrees)"`
+	CameraMake          string     `json:"camera_make" gorm:"type:varchar(100);comment:EXIF camera make"`
+	CameraModel         string     `json:"camera_model" gorm:"type:varchar(100);comment:EXIF camera model"`
+	Status              string     `json:"status" gorm:"type:varchar(20);not null;default:ok;index;comment:Integrity status (ok, flagged)"`
+	FlagReason          string     `json:"flag_reason" gorm:"type:varchar(50);comment:Why the evidence was flagged (content_type_mismatch, reuploaded, duplicate, hash_mismatch, missing)"`
+	FlagNote            string     `json:"flag_note" gorm:"type:text;comment:Detail of the flag (e.g. new hash, duplicate object key)"`
+	UploadCount         int        `json:"upload_count" gorm:"default:1;comment:Number of times content was received for this object key"`
+	LastVerifiedAt      *time.Time `json:"last_verified_at" gorm:"comment:Timestamp of the last integrity verification"`
+	CreatedBy           string     `json:"created_by" gorm:"type:varchar(100);comment:User that uploaded the evidence"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when metadata was recorded"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when metadata was last updated"`
+}
+
+func (TrxEvidenceMetadata) TableName() string {
+	return "trx_evidence_metadata"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -18,7 +19,17 @@ type MstrInspection struct {
 	DeletedBy      string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Lifecycle: baris live (Details dst) adalah draft, versi published disimpan immutable di MstrInspectionVersion
+	Status             string `json:"status" gorm:"type:varchar(20);comment:Lifecycle status (draft|published), empty for legacy rows not yet versioned"`
+	PublishedVersion   uint   `json:"published_version" gorm:"comment:Latest published version number (0 = never published)"`
+	PublishedVersionID *uint  `json:"published_version_id" gorm:"comment:Foreign key to the latest published MstrInspectionVersion"`
+	HasDraftChanges    bool   `json:"has_draft_changes" gorm:"comment:Whether the draft differs from the latest published version"`
+
+	// Persentase skor minimal untuk lulus (0 = tanpa threshold), ikut tersimpan di snapshot versi
+	PassThreshold float64 `json:"pass_threshold" gorm:"default:0;comment:Minimum score percentage to pass a submission (0 = no threshold)"`
+
 	Details []MstrInspectionDetail `gorm:"foreignKey:IdMstrInspection;constraint:OnDelete:CASCADE;comment:List of coordinates/details for this inspection"`
+	Pages   []MstrInspectionPage   `json:"pages,omitempty" gorm:"foreignKey:IdMstrInspection;comment:Additional pages (floor plans, equipment views) of this inspection"`
 	Groups  []MstrGroup            `gorm:"many2many:mstr_group_inspection;constraint:OnDelete:CASCADE;comment:Groups assigned to this inspection" json:"groups"`
 }
 
@@ -26,6 +37,57 @@ func (MstrInspection) TableName() string {
 	return "mstr_inspection"
 }
 
+const (
+	InspectionStatusDraft     = "draft"
+	InspectionStatusPublished = "published"
+)
+
+// MstrInspectionVersion = snapshot immutable dari satu kali publish assurance master
+type MstrInspectionVersion struct {
+	Id               uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for published inspection version"`
+	IdMstrInspection uint           `json:"id_mstr_inspection" gorm:"not null;uniqueIndex:idx_mstr_inspection_version;comment:Foreign key to MstrInspection"`
+	Version          uint           `json:"version" gorm:"not null;uniqueIndex:idx_mstr_inspection_version;comment:Sequential version number per inspection"`
+	NameInspection   string         `json:"name_inspection" gorm:"type:varchar(200);not null;comment:Name of the inspection at publish time"`
+	ImageUrl         string         `json:"image_url" gorm:"type:varchar(500);comment:Object key of the inspection image at publish time"`
+	CompanyID        string         `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	Snapshot         datatypes.JSON `json:"snapshot" gorm:"type:jsonb;not null;comment:Immutable snapshot of SAM details, questions and options"`
+	Notes            string         `json:"notes" gorm:"type:text;comment:Optional release notes for this version"`
+	PublishedBy      string         `json:"published_by" gorm:"type:varchar(100);comment:User that published this version"`
+	PublishedAt      time.Time      `json:"published_at" gorm:"comment:Timestamp when this version was published"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the version record was created"`
+}
+
+func (MstrInspectionVersion) TableName() string {
+	return "mstr_inspection_version"
+}
+
+// InspectionSnapshot = isi kolom Snapshot pada MstrInspectionVersion
+type InspectionSnapshot struct {
+	Details       []MstrInspectionDetail `json:"details"`
+	PassThreshold float64                `json:"pass_threshold,omitempty"`
+	Pages         []MstrInspectionPage   `json:"pages,omitempty"`
+}
+
+// MstrInspectionPage = halaman tambahan assurance master (denah lantai lain, tampak peralatan) dengan gambar sendiri.
+// SAM dengan IdPage kosong ada di gambar utama MstrInspection.ImageUrl.
+type MstrInspectionPage struct {
+	Id               uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for inspection page"`
+	IdMstrInspection uint           `json:"id_mstr_inspection" gorm:"index;not null;comment:Foreign key to MstrInspection"`
+	Title            string         `json:"title" gorm:"type:varchar(200);comment:Page title (floor, room or equipment view)"`
+	ImageUrl         string         `json:"image_url" gorm:"type:varchar(500);not null;comment:Object key of the page background image"`
+	SortOrder        int            `json:"sort_order" gorm:"default:0;comment:Display order of the page within the inspection"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when page was created"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when page was last updated"`
+	CreatedBy        string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created the page"`
+	UpdatedBy        string         `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated the page"`
+	DeletedBy        string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+}
+
+func (MstrInspectionPage) TableName() string {
+	return "mstr_inspection_page"
+}
+
 type MstrInspectionDetail struct {
 	Id                 uint             `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key (auto increment) for inspection detail"`
 	IdMstrInspection   uint             `json:"id_mstr_inspection" gorm:"not null;comment:Foreign key to MstrInspection"`
@@ -44,6 +106,16 @@ type MstrInspectionDetail struct {
 	DeletedBy          string           `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt          gorm.DeletedAt   `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Jumlah evidence (capture_url + attachments) yang harus dikirim untuk SAM ini, 0 = tanpa batas
+	MinEvidence int `json:"min_evidence" gorm:"default:0;comment:Minimum number of evidence files required for this point (0 = no minimum)"`
+	MaxEvidence int `json:"max_evidence" gorm:"default:0;comment:Maximum number of evidence files allowed for this point (0 = unlimited)"`
+
+	// Area SAM (point / rect / polygon, ternormalisasi 0..1, lihat utils.SamGeometry). Null = titik di X/Y.
+	Geometry datatypes.JSON `json:"geometry" gorm:"type:jsonb;comment:SAM zone geometry (point, rect or polygon) normalized to the master image size"`
+
+	// Halaman tempat SAM berada (null = gambar utama assurance)
+	IdPage *uint `json:"id_page" gorm:"index;comment:Foreign key to MstrInspectionPage, null for the main inspection image"`
+
 	Questions []MstrInspectionQuestion `json:"questions" gorm:"foreignKey:InspectionDetailID;constraint:OnDelete:CASCADE;comment:List of questions belonging to this Inspection Detail"`
 }
 
@@ -55,7 +127,7 @@ type MstrInspectionQuestion struct {
 	ID                 uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for inspection question"`
 	InspectionDetailID uint           `json:"inspection_detail_id" gorm:"index;not null;comment:Foreign key to inspection detail"`
 	Text               string         `json:"text" gorm:"type:text;not null;comment:Question text"`
-	Type               string         `json:"type" gorm:"type:varchar(20);not null;comment:Question type (yesno|multiple|essay|image)"`
+	Type               string         `json:"type" gorm:"type:varchar(20);not null;comment:Question type (yesno|multiple|checkbox|text|essay|number|rating|date|time|datetime|image|signature|gps|barcode)"`
 	CreatedBy          string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created the question"`
 	UpdatedBy          string         `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated the question"`
 	CreatedAt          time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when question was created"`
@@ -63,6 +135,16 @@ type MstrInspectionQuestion struct {
 	DeletedBy          string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Aturan validasi jawaban (lihat utils.ValidationRules), ikut tersimpan di snapshot versi
+	ValidationRules datatypes.JSON `json:"validation_rules" gorm:"type:jsonb;comment:Declarative answer validation rules (required, min/max, pattern, allowed labels, file limits)"`
+
+	// Skip-logic: question hanya tampil jika kondisi terhadap jawaban question lain terpenuhi (lihat utils.DisplayCondition)
+	DisplayCondition datatypes.JSON `json:"display_condition" gorm:"type:jsonb;comment:Display condition referencing answers of other questions (equals, in, numeric comparisons, all/any)"`
+
+	// Scoring: bobot question (default 1) dan question critical yang menggagalkan submission jika salah
+	Weight     float64 `json:"weight" gorm:"default:1;comment:Weight of the question in the submission score (0 counts as 1)"`
+	IsCritical bool    `json:"is_critical" gorm:"default:false;comment:Whether a wrong or missing answer fails the whole submission regardless of score"`
+
 	// Options are used only for multiple-choice or yes/no or essay or image
 	Options []MstrInspectionQuestionOption `json:"options" gorm:"foreignKey:InspectionQuestionID;constraint:OnDelete:CASCADE;comment:List of options for multiple-choice inspection questions"`
 }
@@ -83,6 +165,9 @@ type MstrInspectionQuestionOption struct {
 	UpdatedAt            time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when option was last updated"`
 	//DeletedBy            string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	//DeletedAt            gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	// Bagian bobot question yang didapat jika option ini dipilih (0..1), null = 1 jika is_correct
+	Score *float64 `json:"score" gorm:"comment:Fraction of the question weight earned when this option is chosen (0..1), null uses is_correct"`
 }
 
 func (MstrInspectionQuestionOption) TableName() string {
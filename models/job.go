@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Status job batch
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// TrxJobRun = satu kali eksekusi job batch (backfill, purge, dll) beserta ringkasan hasilnya
+type TrxJobRun struct {
+	Id         uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for job run"`
+	JobName    string         `json:"job_name" gorm:"type:varchar(100);not null;index;comment:Job identifier (e.g. thumbnail_backfill)"`
+	CompanyID  string         `json:"company_id" gorm:"type:varchar(50);index;comment:Company the run is limited to (empty = all companies)"`
+	DryRun     bool           `json:"dry_run" gorm:"default:false;comment:Report only, nothing was changed"`
+	Status     string         `json:"status" gorm:"type:varchar(20);not null;default:running;comment:Run status (running, succeeded, failed)"`
+	Processed  int            `json:"processed" gorm:"default:0;comment:Number of items examined"`
+	Succeeded  int            `json:"succeeded" gorm:"default:0;comment:Number of items processed successfully"`
+	Failed     int            `json:"failed" gorm:"default:0;comment:Number of items that failed"`
+	Report     datatypes.JSON `json:"report" gorm:"type:jsonb;comment:Job specific report (errors, per company totals)"`
+	Error      string         `json:"error" gorm:"type:text;comment:Fatal error that stopped the run"`
+	StartedAt  time.Time      `json:"started_at" gorm:"not null;comment:Timestamp when the run started"`
+	FinishedAt *time.Time     `json:"finished_at" gorm:"comment:Timestamp when the run finished"`
+	CreatedBy  string         `json:"created_by" gorm:"type:varchar(100);comment:User or scheduler that started the run"`
+}
+
+func (TrxJobRun) TableName() string {
+	return "trx_job_run"
+}
@@ -3,10 +3,12 @@ package models
 import (
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
-// Enum yang dipakai di code: "yesno", "multiple", "essay", "image"
+// Enum yang dipakai di code: "yesno", "multiple", "checkbox", "text", "essay", "number", "rating",
+// "date", "time", "datetime", "image", "signature", "gps", "barcode" (lihat utils.ValidQuestionType)
 
 type Questionnaire struct {
 	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for questionnaire"`
@@ -22,6 +24,9 @@ type Questionnaire struct {
 	DeletedBy   string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Persentase skor minimal untuk lulus (0 = tanpa threshold)
+	PassThreshold float64 `json:"pass_threshold" gorm:"default:0;comment:Minimum score percentage to pass a submission (0 = no threshold)"`
+
 	Questions  []Question   `json:"questions" gorm:"foreignKey:QuestionnaireID;constraint:OnDelete:CASCADE;comment:List of questions belonging to this questionnaire"`
 	Groups     []MstrGroup  `gorm:"many2many:mstr_group_questionnaire;comment:List of groups associated with the questionnaire" json:"groups"`
 	Mstranswer []MstrAnswer `json:"mstranswer" gorm:"foreignKey:QuestionnaireID;constraint:OnDelete:CASCADE;comment:List of master answer belonging to this questionnaire"`
@@ -31,7 +36,7 @@ type Question struct {
 	ID              uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for question"`
 	QuestionnaireID uint           `json:"questionnaire_id" gorm:"index;not null;comment:Foreign key to Questionnaire"`
 	Text            string         `json:"text" gorm:"type:text;not null;comment:Question text"`
-	Type            string         `json:"type" gorm:"type:varchar(20);not null;comment:Question type (yesno|multiple|essay|image)"`
+	Type            string         `json:"type" gorm:"type:varchar(20);not null;comment:Question type (yesno|multiple|checkbox|text|essay|number|rating|date|time|datetime|image|signature|gps|barcode)"`
 	CreatedBy       string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created the question"`
 	UpdatedBy       string         `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated the question"`
 	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when question was created"`
@@ -39,6 +44,16 @@ type Question struct {
 	DeletedBy       string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Aturan validasi jawaban (lihat utils.ValidationRules)
+	ValidationRules datatypes.JSON `json:"validation_rules" gorm:"type:jsonb;comment:Declarative answer validation rules (required, min/max, pattern, allowed labels, file limits)"`
+
+	// Skip-logic: question hanya tampil jika kondisi terhadap jawaban question lain terpenuhi (lihat utils.DisplayCondition)
+	DisplayCondition datatypes.JSON `json:"display_condition" gorm:"type:jsonb;comment:Display condition referencing answers of other questions (equals, in, numeric comparisons, all/any)"`
+
+	// Scoring: bobot question (default 1) dan question critical yang menggagalkan submission jika salah
+	Weight     float64 `json:"weight" gorm:"default:1;comment:Weight of the question in the submission score (0 counts as 1)"`
+	IsCritical bool    `json:"is_critical" gorm:"default:false;comment:Whether a wrong or missing answer fails the whole submission regardless of score"`
+
 	// Options are used only for multiple-choice or yes/no or essay or image
 	Options          []Option           `json:"options" gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE;comment:List of options for multiple-choice questions"`
 	Answers          []Answer           `json:"answers" gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE;comment:List of options for multiple-choice questions"`
@@ -46,15 +61,18 @@ type Question struct {
 }
 
 type Option struct {
-	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for option"`
-	QuestionID uint      `json:"question_id" gorm:"index;not null;comment:Foreign key to Question"`
-	Label      string    `json:"label" gorm:"type:varchar(10);not null;comment:Option label like A,B,C,D,E"`
-	Text       string    `json:"text" gorm:"type:varchar(200);not null;comment:Text description of the option"`
-	IsCorrect  bool      `json:"is_correct" gorm:"default:false;comment:Optional flag indicating if option is correct"`
-	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when option was created"`
-	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when option was last updated"`
-	//DeletedBy  string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
-	//DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+	ID         uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for option"`
+	QuestionID uint           `json:"question_id" gorm:"index;not null;comment:Foreign key to Question"`
+	Label      string         `json:"label" gorm:"type:varchar(10);not null;comment:Option label like A,B,C,D,E"`
+	Text       string         `json:"text" gorm:"type:varchar(200);not null;comment:Text description of the option"`
+	IsCorrect  bool           `json:"is_correct" gorm:"default:false;comment:Optional flag indicating if option is correct"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when option was created"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when option was last updated"`
+	DeletedBy  string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	// Bagian bobot question yang didapat jika option ini dipilih (0..1), null = 1 jika is_correct
+	Score *float64 `json:"score" gorm:"comment:Fraction of the question weight earned when this option is chosen (0..1), null uses is_correct"`
 }
 
 type Answer struct {
@@ -69,6 +87,9 @@ type Answer struct {
 	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when answer was last updated"`
 	DeletedBy  string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	// Jawaban terstruktur: daftar label (checkbox) atau titik {"lat","lng","accuracy"} (gps)
+	AnswerJSON datatypes.JSON `json:"answer_json,omitempty" gorm:"type:jsonb;comment:Structured answer for checkbox (selected labels) and gps (point) questions"`
 }
 
 // MasterAnswer = 1 kali submit questionnaire
@@ -79,6 +100,7 @@ type MstrAnswer struct {
 	DeviceID        string         `json:"device_id" gorm:"type:varchar(50);not null;comment:device identifier"`
 	CompanyID       string         `json:"company_id" gorm:"type:varchar(50);comment:reference to Company (MstrCompany.CompanyID)"`
 	ChainingID      uint           `json:"chaining_id" gorm:"comment:chaining_id"`
+	SubmissionUUID  *string        `json:"submission_uuid" gorm:"type:varchar(64);uniqueIndex;comment:Client-generated submission UUID used as idempotency key"`
 	CreatedBy       string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created this answer record"`
 	UpdatedBy       string         `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated this answer record"`
 	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when answer was created"`
@@ -86,6 +108,13 @@ type MstrAnswer struct {
 	DeletedBy       string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Hasil scoring saat submit (lihat utils.ScoreAnswers)
+	Score          float64  `json:"score" gorm:"default:0;comment:Weighted score earned by the submission"`
+	MaxScore       float64  `json:"max_score" gorm:"default:0;comment:Maximum weighted score of the visible scored questions"`
+	ScorePercent   *float64 `json:"score_percent" gorm:"index;comment:Score as percentage of max score, null when nothing is scored"`
+	Passed         *bool    `json:"passed" gorm:"index;comment:Pass/fail result, null when no threshold or critical question applies"`
+	CriticalFailed bool     `json:"critical_failed" gorm:"default:false;comment:Whether a critical question was answered wrong or left empty"`
+
 	Details []MstrAnswerDetail `json:"details" gorm:"foreignKey:MasterAnswerID;constraint:OnDelete:CASCADE"`
 }
 
@@ -106,6 +135,9 @@ type MstrAnswerDetail struct {
 	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when answer was last updated"`
 	DeletedBy      string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	// Jawaban terstruktur: daftar label (checkbox) atau titik {"lat","lng","accuracy"} (gps)
+	AnswerJSON datatypes.JSON `json:"answer_json,omitempty" gorm:"type:jsonb;comment:Structured answer for checkbox (selected labels) and gps (point) questions"`
 }
 
 func (MstrAnswerDetail) TableName() string {
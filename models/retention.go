@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MstrRetentionPolicy = masa simpan data per company. Nilai 0 = disimpan selamanya.
+type MstrRetentionPolicy struct {
+	Id                      uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for retention policy"`
+	CompanyID               string    `json:"company_id" gorm:"type:varchar(50);not null;uniqueIndex;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	TrxInspectionDays       int       `json:"trx_inspection_days" gorm:"default:0;comment:Days assurance transactions are kept before hard delete (0 = forever)"`
+	QuestionnaireAnswerDays int       `json:"questionnaire_answer_days" gorm:"default:0;comment:Days questionnaire answers are kept before hard delete (0 = forever)"`
+	EvidenceDays            int       `json:"evidence_days" gorm:"default:0;comment:Days evidence files are kept in storage (0 = forever)"`
+	DeletedRecordDays       int       `json:"deleted_record_days" gorm:"default:30;comment:Grace period in days before soft deleted records and their files are purged (0 = never)"`
+	IsActive                bool      `json:"is_active" gorm:"default:true;comment:Whether the retention purge job applies this policy"`
+	CreatedAt               time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when policy was created"`
+	UpdatedAt               time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when policy was last updated"`
+	CreatedBy               string    `json:"created_by" gorm:"type:varchar(100);comment:User or system that created this policy"`
+	UpdatedBy               string    `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated this policy"`
+}
+
+func (MstrRetentionPolicy) TableName() string {
+	return "mstr_retention_policy"
+}
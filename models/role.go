@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MstrRole = custom role milik company (role sistem super-admin/admin/user ada di utils.DefaultRolePermissions)
+type MstrRole struct {
+	Id          uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for custom role"`
+	CompanyID   string         `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	RoleName    string         `json:"role_name" gorm:"type:varchar(50);not null;comment:Role name, stored in MstrUser.Role"`
+	Description string         `json:"description" gorm:"type:varchar(255);comment:Description of the role"`
+	IsActive    bool           `json:"is_active" gorm:"default:true;comment:Role status (true = active, false = inactive)"`
+	CreatedBy   string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created the role"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the role was created"`
+	UpdatedBy   string         `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated the role"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when the role was last updated"`
+	DeletedBy   string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	Permissions []MstrRolePermission `json:"permissions" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE;comment:Permissions granted to this role"`
+}
+
+func (MstrRole) TableName() string {
+	return "mstr_role"
+}
+
+type MstrRolePermission struct {
+	Id         uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for role permission"`
+	RoleID     uint      `json:"role_id" gorm:"not null;index;comment:Foreign key to MstrRole"`
+	Permission string    `json:"permission" gorm:"type:varchar(100);not null;comment:Permission key (e.g. inspection.write, user.manage)"`
+	CreatedBy  string    `json:"created_by" gorm:"type:varchar(100);comment:User or system that granted the permission"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the permission was granted"`
+}
+
+func (MstrRolePermission) TableName() string {
+	return "mstr_role_permission"
+}
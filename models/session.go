@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// TrxUserSession = satu sesi login (satu refresh token aktif per sesi, di-rotate setiap /api/refresh)
+type TrxUserSession struct {
+	Id            uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for user session"`
+	UserID        uint       `json:"user_id" gorm:"not null;index;comment:Foreign key to MstrUser"`
+	CompanyID     string     `json:"company_id" gorm:"type:varchar(50);index;comment:Foreign key reference to Company (MstrCompany.CompanyID)"`
+	DeviceID      string     `json:"device_id" gorm:"type:varchar(100);index;comment:Device ID used at login (empty for web login)"`
+	IPAddress     string     `json:"ip_address" gorm:"type:varchar(64);comment:Client IP at login / last refresh"`
+	UserAgent     string     `json:"user_agent" gorm:"type:varchar(255);comment:Client user agent at login"`
+	LastUsedAt    time.Time  `json:"last_used_at" gorm:"comment:Timestamp when the session was last used"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;comment:Session expiry (extended on every refresh, capped at created_at + max lifetime)"`
+	RevokedAt     *time.Time `json:"revoked_at" gorm:"index;comment:Timestamp when the session was revoked (NULL = active)"`
+	RevokedReason string     `json:"revoked_reason" gorm:"type:varchar(100);comment:Reason of revocation (logout, token_reuse, user_deactivated, ...)"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the session was created"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when the session was last updated"`
+}
+
+func (TrxUserSession) TableName() string {
+	return "trx_user_session"
+}
+
+// TrxRefreshToken menyimpan hash (sha256) refresh token, token asli tidak pernah disimpan
+type TrxRefreshToken struct {
+	Id        uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for refresh token"`
+	SessionID uint       `json:"session_id" gorm:"not null;index;comment:Foreign key to TrxUserSession"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null;comment:SHA-256 hash of the refresh token"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;comment:Refresh token expiry"`
+	UsedAt    *time.Time `json:"used_at" gorm:"comment:Timestamp when the token was rotated (reuse after this revokes the session)"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the token was issued"`
+}
+
+func (TrxRefreshToken) TableName() string {
+	return "trx_refresh_token"
+}
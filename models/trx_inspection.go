@@ -10,6 +10,8 @@ import (
 type TrxInspection struct {
 	Id             uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for inspection transaction"`
 	IdInspection   uint           `json:"id_inspection" gorm:"not null;comment:Foreign key to MstrInspection"`
+	IdVersion      uint           `json:"id_inspection_version" gorm:"column:id_inspection_version;comment:Foreign key to MstrInspectionVersion the inspection was filled against"`
+	Version        uint           `json:"inspection_version" gorm:"column:inspection_version;comment:Published version number of the assurance master"`
 	NameInspection string         `json:"name_inspection" gorm:"type:varchar(200);not null;comment:Name of the inspection"`
 	ImageUrl       string         `json:"image_url" gorm:"type:varchar(500);comment:URL of the inspection image"`
 	IdUser         uint           `json:"id_user" gorm:"not null;comment:Foreign key to MstrUser performing the inspection"`
@@ -24,6 +26,22 @@ type TrxInspection struct {
 	DeletedBy      string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
+	// Idempotency key dari tablet, submit ulang dengan UUID yang sama mengembalikan hasil pertama
+	SubmissionUUID *string `json:"submission_uuid" gorm:"type:varchar(64);uniqueIndex;comment:Client-generated submission UUID used as idempotency key"`
+
+	// Legal hold: selama aktif tidak bisa dihapus dan dilewati purge retention
+	LegalHold       bool       `json:"legal_hold" gorm:"default:false;index;comment:Legal hold flag, blocks deletion and retention purge"`
+	LegalHoldReason string     `json:"legal_hold_reason" gorm:"type:text;comment:Reason or case reference for the legal hold"`
+	LegalHoldBy     string     `json:"legal_hold_by" gorm:"type:varchar(100);comment:User that placed or released the legal hold"`
+	LegalHoldAt     *time.Time `json:"legal_hold_at" gorm:"comment:Timestamp when the legal hold was placed"`
+
+	// Hasil scoring saat submit (lihat utils.ScoreAnswers)
+	Score          float64  `json:"score" gorm:"default:0;comment:Weighted score earned by the submission"`
+	MaxScore       float64  `json:"max_score" gorm:"default:0;comment:Maximum weighted score of the visible scored questions"`
+	ScorePercent   *float64 `json:"score_percent" gorm:"index;comment:Score as percentage of max score, null when nothing is scored"`
+	Passed         *bool    `json:"passed" gorm:"index;comment:Pass/fail result, null when no threshold or critical question applies"`
+	CriticalFailed bool     `json:"critical_failed" gorm:"default:false;comment:Whether a critical question was answered wrong or left empty"`
+
 	Details []TrxInspectionDetail `json:"details" gorm:"foreignKey:IdTrxInspection;constraint:OnDelete:CASCADE;comment:List of detailed coordinates and captured data for this transaction"`
 }
 
@@ -46,6 +64,11 @@ type TrxInspectionDetail struct {
 	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
 
 	Details []TrxInspectionAnswer `json:"details" gorm:"foreignKey:IdTrxInspectionDetail;constraint:OnDelete:CASCADE;comment:List of detailed coordinates and captured data for this transaction"`
+
+	IdPage *uint `json:"id_page" gorm:"comment:MstrInspectionPage of the SAM at submit time, null for the main inspection image"`
+
+	// Evidence tambahan milik SAM point (attachment jawaban ada di TrxInspectionAnswer.Attachments)
+	Attachments []TrxEvidenceAttachment `json:"attachments,omitempty" gorm:"foreignKey:IdTrxInspectionDetail"`
 }
 
 func (TrxInspectionDetail) TableName() string {
@@ -64,8 +87,57 @@ type TrxInspectionAnswer struct {
 	UpdatedAt             time.Time      `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when answer was last updated"`
 	DeletedBy             string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
 	DeletedAt             gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+
+	// Jawaban terstruktur: daftar label (checkbox) atau titik {"lat","lng","accuracy"} (gps)
+	AnswerJSON datatypes.JSON `json:"answer_json,omitempty" gorm:"type:jsonb;comment:Structured answer for checkbox (selected labels) and gps (point) questions"`
+
+	Attachments []TrxEvidenceAttachment `json:"attachments,omitempty" gorm:"foreignKey:IdTrxInspectionAnswer"`
 }
 
 func (TrxInspectionAnswer) TableName() string {
 	return "trx_inspection_answer"
 }
+
+// TrxEvidenceAttachment = satu file evidence (foto / video / audio) dengan caption dan urutan.
+// Milik SAM point jika IdTrxInspectionAnswer kosong, selain itu milik jawaban tersebut.
+type TrxEvidenceAttachment struct {
+	Id                    uint           `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for evidence attachment"`
+	IdTrxInspection       uint           `json:"id_trx_inspection" gorm:"index;not null;comment:Foreign key to TrxInspection"`
+	IdTrxInspectionDetail uint           `json:"id_trx_inspection_detail" gorm:"index;not null;comment:Foreign key to TrxInspectionDetail (SAM point)"`
+	IdTrxInspectionAnswer *uint          `json:"id_trx_inspection_answer" gorm:"index;comment:Foreign key to TrxInspectionAnswer, null when attached to the SAM point"`
+	CompanyID             string         `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	FileType              string         `json:"file_type" gorm:"type:varchar(20);not null;comment:Attachment type (Photo, Video, Audio)"`
+	ObjectKey             string         `json:"object_key" gorm:"type:varchar(500);comment:Object key of the file in storage, emptied when the evidence expires"`
+	ContentType           string         `json:"content_type" gorm:"type:varchar(100);comment:Content type of the file"`
+	Size                  int64          `json:"size" gorm:"comment:File size in bytes"`
+	Caption               string         `json:"caption" gorm:"type:text;comment:Caption entered by the inspector"`
+	SortOrder             int            `json:"sort_order" gorm:"default:0;comment:Display order within the point or answer"`
+	CreatedBy             string         `json:"created_by" gorm:"type:varchar(100);comment:User or system that created this attachment"`
+	CreatedAt             time.Time      `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when attachment was created"`
+	DeletedBy             string         `json:"deleted_by" gorm:"type:varchar(100);comment:User or system that deleted the record"`
+	DeletedAt             gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:Timestamp when the record was soft deleted"`
+}
+
+func (TrxEvidenceAttachment) TableName() string {
+	return "trx_evidence_attachment"
+}
+
+// TrxSubmissionEvidence = file evidence yang sudah diterima untuk satu submission_uuid,
+// supaya tablet cukup mengirim ulang file yang belum ada saat retry
+type TrxSubmissionEvidence struct {
+	Id             uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for submission evidence"`
+	SubmissionUUID string    `json:"submission_uuid" gorm:"type:varchar(64);not null;uniqueIndex:idx_submission_evidence_key;comment:Client-generated submission UUID"`
+	FileKey        string    `json:"file_key" gorm:"type:varchar(255);not null;uniqueIndex:idx_submission_evidence_key;comment:Multipart field name the tablet uses to reference the file in the payload"`
+	CompanyID      string    `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	Module         string    `json:"module" gorm:"type:varchar(50);comment:Module of the submission (Trn-Assurance, Trn-Questionnaire)"`
+	ObjectKey      string    `json:"object_key" gorm:"type:varchar(500);not null;comment:Object key of the uploaded file in storage"`
+	FileName       string    `json:"file_name" gorm:"type:varchar(255);comment:Original file name"`
+	ContentType    string    `json:"content_type" gorm:"type:varchar(100);comment:Content type of the uploaded file"`
+	Size           int64     `json:"size" gorm:"comment:File size in bytes"`
+	CreatedBy      string    `json:"created_by" gorm:"type:varchar(100);comment:User that uploaded the file"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the file was received"`
+}
+
+func (TrxSubmissionEvidence) TableName() string {
+	return "trx_submission_evidence"
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Status upload session
+const (
+	UploadStatusPending  = "pending"
+	UploadStatusVerified = "verified"
+	UploadStatusAborted  = "aborted"
+)
+
+// TrxUploadSession = upload langsung dari tablet ke bucket company lewat presigned URL (PUT tunggal atau multipart)
+type TrxUploadSession struct {
+	Id             uint       `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for upload session"`
+	UploadID       string     `json:"upload_id" gorm:"type:varchar(64);uniqueIndex;not null;comment:Public identifier of the upload session"`
+	CompanyID      string     `json:"company_id" gorm:"type:varchar(50);not null;index;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	Module         string     `json:"module" gorm:"type:varchar(50);comment:Module of the upload (Trn-Assurance, Trn-Questionnaire)"`
+	ObjectKey      string     `json:"object_key" gorm:"type:varchar(500);not null;index;comment:Object key reserved for the upload"`
+	FileName       string     `json:"file_name" gorm:"type:varchar(255);comment:Original file name"`
+	ContentType    string     `json:"content_type" gorm:"type:varchar(100);comment:Content type the client must send"`
+	ExpectedSize   int64      `json:"expected_size" gorm:"comment:File size in bytes declared by the client"`
+	ExpectedSHA256 string     `json:"expected_sha256" gorm:"type:varchar(64);comment:SHA-256 hex declared by the client (verified after upload)"`
+	MultipartID    string     `json:"multipart_id" gorm:"type:varchar(255);comment:Storage multipart upload ID (empty for single PUT)"`
+	PartSize       int64      `json:"part_size" gorm:"comment:Part size in bytes for multipart upload"`
+	PartCount      int        `json:"part_count" gorm:"comment:Number of parts for multipart upload"`
+	DeviceID       string     `json:"device_id" gorm:"type:varchar(100);index;comment:Device that requested the upload (X-Device-Key), empty for legacy API key"`
+	SubmissionUUID string     `json:"submission_uuid" gorm:"type:varchar(64);index;comment:Optional submission UUID the file belongs to"`
+	FileKey        string     `json:"file_key" gorm:"type:varchar(255);comment:Optional file key referenced in the submission payload"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:pending;comment:Upload status (pending, verified, aborted)"`
+	VerifiedAt     *time.Time `json:"verified_at" gorm:"comment:Timestamp when size/hash were verified"`
+	ConsumedAt     *time.Time `json:"consumed_at" gorm:"comment:Timestamp when the upload was attached to a submission (cannot be reused)"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;comment:Presigned URL expiry"`
+	CreatedBy      string     `json:"created_by" gorm:"type:varchar(100);comment:User that requested the upload"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when the session was created"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when the session was last updated"`
+}
+
+func (TrxUploadSession) TableName() string {
+	return "trx_upload_session"
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// TrxStorageUsage = akumulasi byte & jumlah object yang di-upload per company, module dan bulan.
+// Object yang dihapus purge retention dicatat sebagai nilai negatif di bulan penghapusan.
+type TrxStorageUsage struct {
+	Id        uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for storage usage"`
+	CompanyID string    `json:"company_id" gorm:"type:varchar(50);not null;uniqueIndex:idx_storage_usage_period;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	Module    string    `json:"module" gorm:"type:varchar(50);not null;uniqueIndex:idx_storage_usage_period;comment:Module of the objects (Master-Assurance, Trn-Assurance, Trn-Questionnaire, Master-Company)"`
+	Period    string    `json:"period" gorm:"type:varchar(7);not null;uniqueIndex:idx_storage_usage_period;comment:Month of the usage (YYYY-MM)"`
+	Bytes     int64     `json:"bytes" gorm:"default:0;comment:Net bytes stored during the month"`
+	Objects   int64     `json:"objects" gorm:"default:0;comment:Net number of objects stored during the month"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when usage was last updated"`
+}
+
+func (TrxStorageUsage) TableName() string {
+	return "trx_storage_usage"
+}
+
+// MstrCompanyQuota = batas pemakaian per company. Nilai 0 = tidak dibatasi.
+type MstrCompanyQuota struct {
+	Id                     uint      `json:"id" gorm:"primaryKey;autoIncrement;comment:Primary key for company quota"`
+	CompanyID              string    `json:"company_id" gorm:"type:varchar(50);not null;uniqueIndex;comment:Foreign key to Company (MstrCompany.CompanyID)"`
+	MaxDevices             int       `json:"max_devices" gorm:"default:0;comment:Maximum active devices (0 = unlimited)"`
+	MaxUsers               int       `json:"max_users" gorm:"default:0;comment:Maximum users (0 = unlimited)"`
+	MaxSubmissionsPerMonth int       `json:"max_submissions_per_month" gorm:"default:0;comment:Maximum assurance and questionnaire submissions per calendar month (0 = unlimited)"`
+	MaxStorageBytes        int64     `json:"max_storage_bytes" gorm:"default:0;comment:Maximum bytes stored in the company bucket (0 = unlimited)"`
+	CreatedAt              time.Time `json:"created_at" gorm:"autoCreateTime;comment:Timestamp when quota was created"`
+	UpdatedAt              time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:Timestamp when quota was last updated"`
+	CreatedBy              string    `json:"created_by" gorm:"type:varchar(100);comment:User or system that created this quota"`
+	UpdatedBy              string    `json:"updated_by" gorm:"type:varchar(100);comment:User or system that last updated this quota"`
+}
+
+func (MstrCompanyQuota) TableName() string {
+	return "mstr_company_quota"
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"go-api/controllers"
 	"go-api/middleware"
+	"go-api/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,128 +13,220 @@ func SetupRoutes(router *gin.Engine) {
 	public := router.Group("/api")
 	{
 		public.POST("/login", controllers.Login)
-		public.POST("/refresh", controllers.Refresh) // opsional kalau pakai refresh token
+		public.POST("/refresh", controllers.Refresh) // rotate refresh token (server-side session)
 		public.POST("/mstr-device", controllers.CreateDevice)
+		public.POST("/device-enrolment/redeem", controllers.RedeemPairingCode) // tukar pairing code → device aktif + credential
+		public.POST("/device-enrolment/status", controllers.GetEnrolmentStatus)
 		public.POST("/forgot-password", controllers.ForgotPassword)
 		public.POST("/reset-password", controllers.ResetPassword)
-		public.GET("/e2-signed-company/:companyID/*objectKey", controllers.GetSignedFileURLWithCompany)
+		public.GET("/e2-signed-company/:companyID/*objectKey", controllers.GetSignedFileURLWithCompany) // wajib ?exp=&sig= dari /file-url
+		public.HEAD("/e2-signed-company/:companyID/*objectKey", controllers.GetSignedFileURLWithCompany)
+
+		// presigned URL backend storage lokal (signature HMAC di query, tanpa JWT)
+		public.GET("/storage/local/:bucket/*key", controllers.GetLocalObject)
+		public.PUT("/storage/local/:bucket/*key", controllers.PutLocalObject)
 
 	}
 
 	api := router.Group("/api", middleware.APIKeyAuth(), middleware.AuthMiddleware(), middleware.CheckCompanyActive())
 	{
+		// Setiap route dicek permission-nya (lihat utils.DefaultRolePermissions / MstrRole)
+		perm := middleware.RequirePermission
 
 		//User
-		api.POST("/users", controllers.CreateUser)             //user-mstr
-		api.PUT("/users/:id", controllers.UpdateUserByID)      //user-mstr
-		api.DELETE("/users/:id", controllers.DeleteUserByID)   //user-mstr
-		api.GET("/users/filter", controllers.GetFilteredUsers) //user-mstr
+		api.POST("/users", perm(utils.PermUserManage), controllers.CreateUser)             //user-mstr
+		api.PUT("/users/:id", perm(utils.PermUserManage), controllers.UpdateUserByID)      //user-mstr
+		api.DELETE("/users/:id", perm(utils.PermUserManage), controllers.DeleteUserByID)   //user-mstr
+		api.GET("/users/filter", perm(utils.PermUserManage), controllers.GetFilteredUsers) //user-mstr
+
+		api.GET("/users/:id/sessions", perm(utils.PermUserManage), controllers.GetUserSessions)
+		api.DELETE("/users/:id/sessions", perm(utils.PermUserManage), controllers.RevokeUserSessions)
+
+		//SESSION (sesi milik user sendiri, tanpa permission khusus)
+		api.GET("/sessions", controllers.GetMySessions)
+		api.DELETE("/sessions/:id", controllers.RevokeMySession)
+		api.POST("/logout", controllers.Logout)
+		api.POST("/logout-all", controllers.LogoutAll)
 
 		//MSTR Inspection
-		api.POST("/mstr-inspections", controllers.CreateMstrInspection) //assurance-master
+		api.POST("/mstr-inspections", perm(utils.PermInspectionWrite), controllers.CreateMstrInspection) //assurance-master
 		//api.PUT("/mstr-inspections/:id", controllers.UpdateMstrInspectionByID)
-		api.PUT("/mstr-inspections/:id/name", controllers.UpdateMstrInspectionByID) //assurance-master/only update assurance_name
-		api.DELETE("/mstr-inspections/:id", controllers.DeleteMstrInspectionByID)   //assurance-master
-		api.GET("/mstr-inspections/filter", controllers.GetFilteredInspections)     //assurance-master
-		api.POST("/mstr-inspections/:id/copy", controllers.CopyMstrInspectionByID)  //assurance-master
+		api.PUT("/mstr-inspections/:id/name", perm(utils.PermInspectionWrite), controllers.UpdateMstrInspectionByID) //assurance-master/only update assurance_name
+		api.DELETE("/mstr-inspections/:id", perm(utils.PermInspectionWrite), controllers.DeleteMstrInspectionByID)   //assurance-master
+		api.GET("/mstr-inspections/filter", perm(utils.PermInspectionRead), controllers.GetFilteredInspections)      //assurance-master
+		api.POST("/mstr-inspections/:id/copy", perm(utils.PermInspectionWrite), controllers.CopyMstrInspectionByID)  //assurance-master
+
+		api.POST("/mstr-inspections/:id/publish", perm(utils.PermInspectionWrite), controllers.PublishMstrInspection)            //assurance-master/publish draft
+		api.GET("/mstr-inspections/:id/versions", perm(utils.PermInspectionRead), controllers.GetMstrInspectionVersions)         //assurance-master/history
+		api.GET("/mstr-inspections/:id/versions/:version", perm(utils.PermInspectionRead), controllers.GetMstrInspectionVersion) //assurance-master/snapshot
+
+		api.PUT("/mstr-inspections/:id/pass-threshold", perm(utils.PermInspectionWrite), controllers.UpdateMstrInspectionPassThreshold) //assurance-master/scoring
+
+		api.GET("/mstr-inspections/:id/export", perm(utils.PermInspectionRead), controllers.ExportMstrInspection) //assurance-master/package ZIP
+		api.POST("/mstr-inspections/import", perm(utils.PermInspectionWrite), controllers.ImportMstrInspection)   //assurance-master/package ZIP, dry_run default
 
-		api.DELETE("/mstr-inspection-details/:id", controllers.DeleteMstrInspectionDetailByID) //assurance-master//delete-sam
-		api.PUT("/mstr-inspection-details/:id", controllers.UpdateMstrInspectionDetailByID)    //assurance-master//update-sam
-		api.PATCH("/mstr-inspection-position-move/:id", controllers.UpdateInspectionPosition)  //assurance-master//move-sam
-		api.POST("/mstr-inspection-details", controllers.CreateMstrInspectionDetail)           //assurance-master//copy-sam
+		api.DELETE("/mstr-inspection-details/:id", perm(utils.PermInspectionWrite), controllers.DeleteMstrInspectionDetailByID) //assurance-master//delete-sam
+		api.PUT("/mstr-inspection-details/:id", perm(utils.PermInspectionWrite), controllers.UpdateMstrInspectionDetailByID)    //assurance-master//update-sam
+		api.PATCH("/mstr-inspection-position-move/:id", perm(utils.PermInspectionWrite), controllers.UpdateInspectionPosition)  //assurance-master//move-sam
+		api.POST("/mstr-inspection-details", perm(utils.PermInspectionWrite), controllers.CreateMstrInspectionDetail)           //assurance-master//copy-sam
+
+		api.PATCH("/mstr-inspection-geometry/:id", perm(utils.PermInspectionWrite), controllers.UpdateInspectionGeometry) //assurance-master//reshape-sam
+
+		api.GET("/mstr-inspections/:id/pages", perm(utils.PermInspectionRead), controllers.GetMstrInspectionPages)     //assurance-master/pages
+		api.POST("/mstr-inspections/:id/pages", perm(utils.PermInspectionWrite), controllers.CreateMstrInspectionPage) //assurance-master/pages
+		api.PUT("/mstr-inspection-pages/:id", perm(utils.PermInspectionWrite), controllers.UpdateMstrInspectionPage)
+		api.DELETE("/mstr-inspection-pages/:id", perm(utils.PermInspectionWrite), controllers.DeleteMstrInspectionPage)
 
 		//TRX Inspection
-		api.POST("/trx-inspections", controllers.CreateTRXInspection)
-		api.PUT("/trx-inspections/:id", controllers.UpdateTRXInspectionByID)
-		api.DELETE("/trx-inspections/:id", controllers.DeleteTRXInspectionByID)
-		api.GET("/trx-inspections/filter", controllers.GetFilteredTRXInspections)
+		api.POST("/trx-inspections", perm(utils.PermSubmissionCreate), controllers.CreateTRXInspection)
+		api.PUT("/trx-inspections/:id", perm(utils.PermTrxInspectionWrite), controllers.UpdateTRXInspectionByID)
+		api.DELETE("/trx-inspections/:id", perm(utils.PermTrxInspectionWrite), controllers.DeleteTRXInspectionByID)
+		api.PUT("/trx-inspections/:id/legal-hold", perm(utils.PermLegalHold), controllers.SetTRXInspectionLegalHold)
+		api.GET("/trx-inspections/filter", perm(utils.PermTrxInspectionRead), controllers.GetFilteredTRXInspections)
+
+		// Evidence submission (resumable, kunci = submission_uuid)
+		api.POST("/submissions/:uuid/evidence", perm(utils.PermSubmissionCreate), controllers.UploadSubmissionEvidence)
+		api.GET("/submissions/:uuid/evidence", perm(utils.PermSubmissionCreate), controllers.GetSubmissionEvidence)
+
+		// Upload langsung ke storage (presigned PUT / multipart)
+		api.POST("/uploads/presign", perm(utils.PermSubmissionCreate), controllers.PresignUpload)
+		api.POST("/uploads/:uploadID/complete", perm(utils.PermSubmissionCreate), controllers.CompleteUpload)
+		api.DELETE("/uploads/:uploadID", perm(utils.PermSubmissionCreate), controllers.AbortUpload)
 
 		//MSTR COMPANY
-		api.POST("/mstr-company", controllers.CreateCompany)
-		api.PUT("/mstr-company/:id", controllers.UpdateCompany)
-		api.DELETE("/mstr-company/:id", controllers.DeleteCompany)
-		api.GET("/mstr-company/filter", controllers.GetFilteredCompanies)
+		api.POST("/mstr-company", perm(utils.PermCompanyManage), controllers.CreateCompany)
+		api.PUT("/mstr-company/:id", perm(utils.PermCompanyManage), controllers.UpdateCompany)
+		api.DELETE("/mstr-company/:id", perm(utils.PermCompanyManage), controllers.DeleteCompany)
+		api.GET("/mstr-company/filter", perm(utils.PermCompanyView), controllers.GetFilteredCompanies)
+		api.POST("/mstr-company/:id/storage/test", perm(utils.PermCompanyManage), controllers.TestCompanyStorage)
+		api.POST("/mstr-company/rotate-secrets", perm(utils.PermPlatformAdmin), controllers.RotateCompanySecretsHandler)
 
 		//MSTR Device
-		api.PUT("/mstr-device/:id", controllers.UpdateDeviceByID)
-		api.DELETE("/mstr-device/:id", controllers.DeleteDeviceByID)
-		api.GET("/mstr-device/filter", controllers.GetFilteredDevices)
+		api.PUT("/mstr-device/:id", perm(utils.PermDeviceManage), controllers.UpdateDeviceByID)
+		api.DELETE("/mstr-device/:id", perm(utils.PermDeviceManage), controllers.DeleteDeviceByID)
+		api.GET("/mstr-device/filter", perm(utils.PermDeviceManage), controllers.GetFilteredDevices)
+
+		api.GET("/mstr-device/:id/credentials", perm(utils.PermDeviceManage), controllers.GetDeviceCredentials)
+		api.POST("/mstr-device/:id/credentials", perm(utils.PermDeviceManage), controllers.RotateDeviceCredential) // rotate, secret hanya sekali
+		api.DELETE("/mstr-device/:id/credentials", perm(utils.PermDeviceManage), controllers.RevokeDeviceCredentials)
+		api.GET("/mstr-device/:id/enrolment-logs", perm(utils.PermDeviceManage), controllers.GetDeviceEnrolmentLogs)
+		api.GET("/mstr-device/:id/heartbeats", perm(utils.PermDeviceManage), controllers.GetDeviceHeartbeats)
+
+		// Device enrolment (pairing code + antrian pending)
+		api.POST("/device-pairing-codes", perm(utils.PermDeviceManage), controllers.CreatePairingCode)
+		api.GET("/device-pairing-codes", perm(utils.PermDeviceManage), controllers.GetPairingCodes)
+		api.DELETE("/device-pairing-codes/:id", perm(utils.PermDeviceManage), controllers.RevokePairingCode)
+		api.GET("/device-enrolments", perm(utils.PermDeviceManage), controllers.GetDeviceEnrolments)
+		api.POST("/device-enrolments/:id/approve", perm(utils.PermDeviceManage), controllers.ApproveDeviceEnrolment)
+		api.POST("/device-enrolments/:id/reject", perm(utils.PermDeviceManage), controllers.RejectDeviceEnrolment)
 
 		//MSTR Group
-		api.POST("/mstr-group", controllers.CreateGroup)
-		api.PUT("/mstr-group/:id", controllers.UpdateGroupByID)
-		api.DELETE("/mstr-group/:id", controllers.DeleteGroupByID)
-		api.GET("/mstr-group/filter", controllers.GetFilteredGroups)
+		api.POST("/mstr-group", perm(utils.PermGroupManage), controllers.CreateGroup)
+		api.PUT("/mstr-group/:id", perm(utils.PermGroupManage), controllers.UpdateGroupByID)
+		api.DELETE("/mstr-group/:id", perm(utils.PermGroupManage), controllers.DeleteGroupByID)
+		api.GET("/mstr-group/filter", perm(utils.PermGroupManage), controllers.GetFilteredGroups)
 
 		//ASSIGN DEVICE TO GROUP
-		api.POST("/groups/:groupId/devices/bulk", controllers.ManageGroupDeviceBulk)
-		api.GET("/groups/:id/devices", controllers.GetGroupDevices)
+		api.POST("/groups/:groupId/devices/bulk", perm(utils.PermGroupManage), controllers.ManageGroupDeviceBulk)
+		api.GET("/groups/:id/devices", perm(utils.PermGroupManage), controllers.GetGroupDevices)
 
 		//ASSIGN CHAINING TO GROUPE
-		api.POST("/groups/:groupId/chainings/bulk", controllers.ManageGroupChainingBulk)
-		api.GET("/groups/:id/chainings", controllers.GetGroupChainings)
+		api.POST("/groups/:groupId/chainings/bulk", perm(utils.PermGroupManage), controllers.ManageGroupChainingBulk)
+		api.GET("/groups/:id/chainings", perm(utils.PermGroupManage), controllers.GetGroupChainings)
 
 		//GROUP INSPECTION
-		api.POST("/groups/:groupId/inspections/bulk", controllers.ManageGroupInspectionBulk)
-		api.GET("/groups/:id/inspections", controllers.GetGroupInspections)
+		api.POST("/groups/:groupId/inspections/bulk", perm(utils.PermGroupManage), controllers.ManageGroupInspectionBulk)
+		api.GET("/groups/:id/inspections", perm(utils.PermGroupManage), controllers.GetGroupInspections)
 
 		//GROUP Questionnaire
-		api.POST("/groups/:groupId/questionnaires/bulk", controllers.ManageGroupQuestionnaireBulk)
-		api.GET("/groups/:id/questionnaires", controllers.GetGroupQuestionnaires)
+		api.POST("/groups/:groupId/questionnaires/bulk", perm(utils.PermGroupManage), controllers.ManageGroupQuestionnaireBulk)
+		api.GET("/groups/:id/questionnaires", perm(utils.PermGroupManage), controllers.GetGroupQuestionnaires)
 
 		// Questionnaire CRUD
-		api.POST("/questionnaires", controllers.CreateQuestionnaire)
-		api.GET("/questionnaires", controllers.ListQuestionnaires)
-		api.GET("/questionnaires/:id", controllers.GetQuestionnaire)
-		api.PUT("/questionnaires/:id", controllers.UpdateQuestionnaire)
-		api.DELETE("/questionnaires/:id", controllers.DeleteQuestionnaire)
+		api.POST("/questionnaires", perm(utils.PermQuestionnaireWrite), controllers.CreateQuestionnaire)
+		api.GET("/questionnaires", perm(utils.PermQuestionnaireRead), controllers.ListQuestionnaires)
+		api.GET("/questionnaires/:id", perm(utils.PermQuestionnaireRead), controllers.GetQuestionnaire)
+		api.PUT("/questionnaires/:id", perm(utils.PermQuestionnaireWrite), controllers.UpdateQuestionnaire)
+		api.DELETE("/questionnaires/:id", perm(utils.PermQuestionnaireWrite), controllers.DeleteQuestionnaire)
+
+		api.GET("/questionnaires/:id/export", perm(utils.PermQuestionnaireRead), controllers.ExportQuestionnaire)
+		api.POST("/questionnaires/import", perm(utils.PermQuestionnaireWrite), controllers.ImportQuestionnaire)
 
 		// Question CRUD
-		api.POST("/questionnaires/:questionnaireId/questions", controllers.CreateQuestion)
-		api.PUT("/questions/:id", controllers.UpdateQuestion)
-		api.DELETE("/questions/:id", controllers.DeleteQuestion)
+		api.POST("/questionnaires/:questionnaireId/questions", perm(utils.PermQuestionnaireWrite), controllers.CreateQuestion)
+		api.PUT("/questions/:id", perm(utils.PermQuestionnaireWrite), controllers.UpdateQuestion)
+		api.DELETE("/questions/:id", perm(utils.PermQuestionnaireWrite), controllers.DeleteQuestion)
 
 		// Answers
-		api.POST("/questions/:id/answers", controllers.SubmitAnswer)
-		api.GET("/questions/:id/answers", controllers.ListAnswers)
+		api.POST("/questions/:id/answers", perm(utils.PermSubmissionCreate), controllers.SubmitAnswer)
+		api.GET("/questions/:id/answers", perm(utils.PermAnswerRead), controllers.ListAnswers)
 		//api.POST("/questions/answers-all", controllers.SubmitAllAnswers)
-		api.POST("/questions/answers-all", controllers.SubmitAllAnswersWithMaster)
+		api.POST("/questions/answers-all", perm(utils.PermSubmissionCreate), controllers.SubmitAllAnswersWithMaster)
 
-		api.GET("/questionnaires/:id/users/:userId/answers", controllers.GetUserAnswers)
-		api.GET("/questionnaires/:id/users/:userId/answersflat", controllers.GetUserAnswersFlat)
+		api.GET("/questionnaires/:id/users/:userId/answers", perm(utils.PermAnswerRead), controllers.GetUserAnswers)
+		api.GET("/questionnaires/:id/users/:userId/answersflat", perm(utils.PermAnswerRead), controllers.GetUserAnswersFlat)
+		api.GET("/mstr-answers/filter", perm(utils.PermAnswerRead), controllers.GetFilteredMstrAnswers)
 
 		// SUPERSET
-		api.GET("/superset/guest-token", controllers.GetSupersetGuestToken)
+		api.GET("/superset/guest-token", perm(utils.PermReportView), controllers.GetSupersetGuestToken)
 
 		//FOR TABLET
-		api.GET("/devices/:deviceID/inspections", controllers.GetDeviceInspection)
-		api.GET("/devices/:deviceID/preinspections", controllers.GetDevicePreInspection)
-		api.GET("/devices/:deviceID/postinspections", controllers.GetDevicePostInspection)
-		api.GET("/devices/:deviceID/chaining", controllers.GetChainingByDevice)
-		api.GET("/devices/:deviceID/chainingnew", controllers.GetChainingByDeviceNew)
+		api.GET("/devices/:deviceID/inspections", perm(utils.PermDeviceSync), controllers.GetDeviceInspection)
+		api.GET("/devices/:deviceID/preinspections", perm(utils.PermDeviceSync), controllers.GetDevicePreInspection)
+		api.GET("/devices/:deviceID/postinspections", perm(utils.PermDeviceSync), controllers.GetDevicePostInspection)
+		api.GET("/devices/:deviceID/chaining", perm(utils.PermDeviceSync), controllers.GetChainingByDevice)
+		api.GET("/devices/:deviceID/chainingnew", perm(utils.PermDeviceSync), controllers.GetChainingByDeviceNew)
+
+		api.POST("/devices/:deviceID/heartbeat", perm(utils.PermDeviceSync), controllers.PostDeviceHeartbeat)
+		api.GET("/devices/:deviceID/bundle", perm(utils.PermDeviceSync), controllers.GetDeviceBundle) // semua data offline, ETag + since
 
 		//CHAINING
-		api.GET("/chainings/filter", controllers.GetFilteredChainings)
-		api.GET("/chainings/:id", controllers.GetChainingByID)
-		api.POST("/chainings/", controllers.CreateChaining)
-		api.PUT("/chainings/:id", controllers.UpdateChainingByID)
-		api.DELETE("/chainings/:id", controllers.DeleteChainingByID)
+		api.GET("/chainings/filter", perm(utils.PermChainingRead), controllers.GetFilteredChainings)
+		api.GET("/chainings/:id", perm(utils.PermChainingRead), controllers.GetChainingByID)
+		api.POST("/chainings/", perm(utils.PermChainingWrite), controllers.CreateChaining)
+		api.PUT("/chainings/:id", perm(utils.PermChainingWrite), controllers.UpdateChainingByID)
+		api.DELETE("/chainings/:id", perm(utils.PermChainingWrite), controllers.DeleteChainingByID)
 
 		//EVENT
-		api.GET("/events/filter", controllers.GetFilteredEvents)
+		api.GET("/events/filter", perm(utils.PermTriggerRead), controllers.GetFilteredEvents)
 		//api.GET("/events/:id", controllers.GetEventByID)
-		api.POST("/events/", controllers.CreateEvent)
-		api.PUT("/events/:id", controllers.UpdateEventByID)
-		api.DELETE("/events/:id", controllers.DeleteEventByID)
+		api.POST("/events/", perm(utils.PermTriggerWrite), controllers.CreateEvent)
+		api.PUT("/events/:id", perm(utils.PermTriggerWrite), controllers.UpdateEventByID)
+		api.DELETE("/events/:id", perm(utils.PermTriggerWrite), controllers.DeleteEventByID)
 
 		//Type
-		api.GET("/types/filter", controllers.GetFilteredTypes)
-		api.POST("/types/", controllers.CreateType)
-		api.PUT("/types/:id", controllers.UpdateTypeByID)
-		api.DELETE("/types/:id", controllers.DeleteTypeByID)
+		api.GET("/types/filter", perm(utils.PermTriggerRead), controllers.GetFilteredTypes)
+		api.POST("/types/", perm(utils.PermTriggerWrite), controllers.CreateType)
+		api.PUT("/types/:id", perm(utils.PermTriggerWrite), controllers.UpdateTypeByID)
+		api.DELETE("/types/:id", perm(utils.PermTriggerWrite), controllers.DeleteTypeByID)
+
+		//ROLE & PERMISSION
+		api.GET("/permissions", perm(utils.PermRoleManage), controllers.ListPermissions)
+		api.GET("/roles", perm(utils.PermRoleManage), controllers.ListRoles)
+		api.POST("/roles", perm(utils.PermRoleManage), controllers.CreateRole)
+		api.PUT("/roles/:id", perm(utils.PermRoleManage), controllers.UpdateRoleByID)
+		api.DELETE("/roles/:id", perm(utils.PermRoleManage), controllers.DeleteRoleByID)
 
 		//E2 IDrive
-		api.GET("/e2-signed/*objectKey", controllers.GetSignedFileURL)
+		api.GET("/e2-signed/*objectKey", perm(utils.PermInspectionRead), controllers.GetSignedFileURL)
+		api.HEAD("/e2-signed/*objectKey", perm(utils.PermInspectionRead), controllers.GetSignedFileURL)
+		api.GET("/file-url/*objectKey", perm(utils.PermInspectionRead), controllers.CreateFileURL) // URL proxy bertanda tangan, ttl dalam menit
+		api.GET("/evidence-metadata", perm(utils.PermTrxInspectionRead), controllers.GetEvidenceMetadata)
+		api.POST("/evidence-metadata/:id/verify", perm(utils.PermTrxInspectionRead), controllers.VerifyEvidenceMetadata) // hitung ulang sha256 object di storage
+
+		//JOBS (batch / backfill)
+		api.GET("/jobs", perm(utils.PermPlatformAdmin), controllers.GetJobRuns)
+		api.GET("/jobs/:id", perm(utils.PermPlatformAdmin), controllers.GetJobRunByID)
+		api.POST("/jobs/thumbnail-backfill", perm(utils.PermPlatformAdmin), controllers.StartThumbnailBackfill)
+		api.POST("/jobs/retention-purge", perm(utils.PermPlatformAdmin), controllers.StartRetentionPurge) // ?dry_run=true hanya laporan
+
+		//RETENTION (masa simpan transaksi & evidence per company)
+		api.GET("/retention-policy", perm(utils.PermCompanyView), controllers.GetRetentionPolicy)
+		api.PUT("/retention-policy", perm(utils.PermCompanyManage), controllers.UpsertRetentionPolicy)
+
+		//USAGE & QUOTA (storage per module/bulan, submission, device, user)
+		api.GET("/usage", perm(utils.PermCompanyView), controllers.GetCompanyUsage)
+		api.PUT("/usage/quota", perm(utils.PermCompanyManage), controllers.UpsertCompanyQuota)
 
 	}
 }
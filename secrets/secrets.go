@@ -0,0 +1,213 @@
+// Package secrets = envelope encryption untuk credential yang disimpan di database.
+//
+// Setiap nilai dienkripsi dengan data key (DEK) acak, lalu DEK di-wrap dengan master key dari env.
+// Format tersimpan: enc:v1:<key id>:<base64 wrapped DEK>:<base64 ciphertext>
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const prefix = "enc:v1:"
+
+var (
+	ErrNoMasterKey  = errors.New("SECRETS_MASTER_KEY is not configured")
+	ErrUnknownKey   = errors.New("secret was encrypted with an unknown master key")
+	ErrInvalidValue = errors.New("invalid encrypted secret")
+)
+
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+var (
+	keysOnce  sync.Once
+	activeKey *masterKey
+	allKeys   map[string]*masterKey
+	keysErr   error
+)
+
+// loadKeys membaca SECRETS_MASTER_KEY (aktif) dan SECRETS_OLD_MASTER_KEYS (koma, hanya untuk dekripsi saat rotasi).
+// Key = base64 dari 32 byte acak (openssl rand -base64 32).
+func loadKeys() {
+	allKeys = make(map[string]*masterKey)
+
+	active := strings.TrimSpace(os.Getenv("SECRETS_MASTER_KEY"))
+	if active == "" {
+		keysErr = ErrNoMasterKey
+	} else if k, err := parseKey(active); err != nil {
+		keysErr = fmt.Errorf("SECRETS_MASTER_KEY: %v", err)
+	} else {
+		activeKey = k
+		allKeys[k.id] = k
+	}
+
+	for _, raw := range strings.Split(os.Getenv("SECRETS_OLD_MASTER_KEYS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if k, err := parseKey(raw); err == nil {
+			allKeys[k.id] = k
+		}
+	}
+}
+
+func parseKey(raw string) (*masterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errors.New("must be base64")
+	}
+	if len(key) != 32 {
+		return nil, errors.New("must be 32 bytes")
+	}
+	sum := sha256.Sum256(key)
+	return &masterKey{id: hex.EncodeToString(sum[:4]), key: key}, nil
+}
+
+func keys() (*masterKey, map[string]*masterKey, error) {
+	keysOnce.Do(loadKeys)
+	return activeKey, allKeys, keysErr
+}
+
+// ActiveKeyID = id master key yang dipakai untuk enkripsi baru
+func ActiveKeyID() (string, error) {
+	active, _, err := keys()
+	if err != nil {
+		return "", err
+	}
+	return active.id, nil
+}
+
+// IsEncrypted cek apakah nilai dari DB sudah terenkripsi
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, prefix)
+}
+
+// KeyID = id master key sebuah nilai terenkripsi ("" untuk plaintext lama)
+func KeyID(stored string) string {
+	if !IsEncrypted(stored) {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(stored, prefix), ":", 2)
+	return parts[0]
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidValue
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+// Encrypt mengenkripsi plaintext dengan master key aktif
+func Encrypt(plaintext string) (string, error) {
+	active, _, err := keys()
+	if err != nil {
+		return "", err
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(active.key, dek)
+	if err != nil {
+		return "", err
+	}
+	data, err := seal(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.StdEncoding
+	return prefix + active.id + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(data), nil
+}
+
+// Decrypt membuka nilai terenkripsi. Plaintext lama (sebelum enkripsi) dikembalikan apa adanya.
+func Decrypt(stored string) (string, error) {
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(stored, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrInvalidValue
+	}
+
+	_, all, _ := keys()
+	master, ok := all[parts[0]]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	enc := base64.StdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+	data, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+
+	dek, err := open(master.key, wrapped)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+	plaintext, err := open(dek, data)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+	return string(plaintext), nil
+}
+
+// Mask untuk response API: hanya 4 karakter terakhir yang terlihat
+func Mask(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	if len(plaintext) < 16 {
+		return "********"
+	}
+	return "********" + plaintext[len(plaintext)-4:]
+}
+
+// IsMasked cek input yang ternyata nilai mask dari response (form edit dikirim balik apa adanya)
+func IsMasked(value string) bool {
+	return strings.HasPrefix(value, "********")
+}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// useKeys mengganti master key dari env dan memaksa loadKeys dibaca ulang
+func useKeys(t *testing.T, active string, old ...string) {
+	t.Helper()
+	t.Setenv("SECRETS_MASTER_KEY", active)
+	t.Setenv("SECRETS_OLD_MASTER_KEYS", strings.Join(old, ","))
+	reset := func() {
+		keysOnce = sync.Once{}
+		activeKey, allKeys, keysErr = nil, nil, nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	useKeys(t, newKey(t))
+
+	stored, err := Encrypt("AKIA-secret-value")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !IsEncrypted(stored) || strings.Contains(stored, "AKIA-secret-value") {
+		t.Fatalf("stored = %q, want encrypted value", stored)
+	}
+	keyID, _ := ActiveKeyID()
+	if KeyID(stored) != keyID {
+		t.Errorf("KeyID = %q, want active key %q", KeyID(stored), keyID)
+	}
+	got, err := Decrypt(stored)
+	if err != nil || got != "AKIA-secret-value" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	useKeys(t, newKey(t))
+	stored, _ := Encrypt("value")
+
+	useKeys(t, newKey(t))
+	if _, err := Decrypt(stored); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	useKeys(t, newKey(t))
+	stored, _ := Encrypt("value")
+	parts := strings.Split(stored, ":")
+
+	// flip satu byte ciphertext → GCM menolak
+	data, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	data[len(data)-1] ^= 0xFF
+	parts[len(parts)-1] = base64.StdEncoding.EncodeToString(data)
+
+	tests := map[string]string{
+		"ciphertext":     strings.Join(parts, ":"),
+		"missing part":   strings.Join(parts[:len(parts)-1], ":"),
+		"invalid base64": stored + "!",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decrypt(value); !errors.Is(err, ErrInvalidValue) {
+				t.Fatalf("err = %v, want ErrInvalidValue", err)
+			}
+		})
+	}
+}
+
+// Plaintext sebelum enkripsi diaktifkan tetap terbaca
+func TestDecryptLegacyPlaintext(t *testing.T) {
+	useKeys(t, newKey(t))
+
+	got, err := Decrypt("legacy-access-key")
+	if err != nil || got != "legacy-access-key" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+	if KeyID("legacy-access-key") != "" {
+		t.Errorf("legacy plaintext has key id %q", KeyID("legacy-access-key"))
+	}
+}
+
+// Setelah rotasi, nilai lama tetap terbaca lewat SECRETS_OLD_MASTER_KEYS dan enkripsi baru memakai key aktif
+func TestDecryptAfterRotation(t *testing.T) {
+	oldKey := newKey(t)
+	useKeys(t, oldKey)
+	stored, _ := Encrypt("value")
+	oldID := KeyID(stored)
+
+	useKeys(t, newKey(t), oldKey)
+	got, err := Decrypt(stored)
+	if err != nil || got != "value" {
+		t.Fatalf("decrypt with old key = %q, %v", got, err)
+	}
+	fresh, _ := Encrypt(got)
+	if KeyID(fresh) == oldID {
+		t.Fatalf("re-encrypted value still uses old key %q", oldID)
+	}
+}
+
+func TestMissingMasterKey(t *testing.T) {
+	useKeys(t, "")
+	if _, err := Encrypt("value"); !errors.Is(err, ErrNoMasterKey) {
+		t.Fatalf("encrypt err = %v, want ErrNoMasterKey", err)
+	}
+	if _, err := ActiveKeyID(); !errors.Is(err, ErrNoMasterKey) {
+		t.Fatalf("ActiveKeyID err = %v, want ErrNoMasterKey", err)
+	}
+}
+
+func TestStringValueScan(t *testing.T) {
+	useKeys(t, newKey(t))
+
+	stored, err := String("secret-access-key-1234").Value()
+	if err != nil || !IsEncrypted(stored.(string)) {
+		t.Fatalf("Value = %v, %v", stored, err)
+	}
+	var s String
+	if err := s.Scan([]byte(stored.(string))); err != nil || s.Reveal() != "secret-access-key-1234" {
+		t.Fatalf("Scan = %q, %v", s.Reveal(), err)
+	}
+	if s.String() != "********1234" {
+		t.Errorf("String() = %q, want masked", s.String())
+	}
+	if empty, _ := String("").Value(); empty != "" {
+		t.Errorf("empty Value = %v, want empty string", empty)
+	}
+}
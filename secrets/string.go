@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// String = kolom credential: terenkripsi di DB, plaintext di memory, ter-mask di JSON
+type String string
+
+// Value mengenkripsi sebelum disimpan ke DB
+func (s String) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	return Encrypt(string(s))
+}
+
+// Scan mendekripsi nilai dari DB (plaintext lama tetap terbaca)
+func (s *String) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("secrets.String: unsupported type %T", value)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	*s = String(plaintext)
+	return nil
+}
+
+// MarshalJSON tidak pernah mengeluarkan nilai asli
+func (s String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Mask(string(s)))
+}
+
+func (s String) String() string {
+	return Mask(string(s))
+}
+
+// Reveal = plaintext, hanya untuk dipakai server (mis. client storage)
+func (s String) Reveal() string {
+	return string(s)
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix route API yang melayani presigned URL backend lokal (lihat routes.go)
+const LocalRoutePrefix = "/api/storage/local/"
+
+// LocalBackend = file di filesystem server (on-prem tanpa bucket cloud).
+// Presigned URL mengarah ke API ini dan ditandatangani HMAC.
+type LocalBackend struct {
+	root       string
+	bucket     string
+	baseURL    string
+	signingKey []byte
+}
+
+func NewLocal(cfg Config) (*LocalBackend, error) {
+	if cfg.Root == "" {
+		return nil, errors.New("local storage root is not configured")
+	}
+	if len(cfg.SigningKey) == 0 {
+		return nil, errors.New("local storage signing key is not configured")
+	}
+	if err := os.MkdirAll(cfg.Root, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalBackend{
+		root:       cfg.Root,
+		bucket:     cfg.Bucket,
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		signingKey: cfg.SigningKey,
+	}, nil
+}
+
+// path mengubah key jadi path file, key tidak boleh keluar dari root
+func (b *LocalBackend) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(b.root, filepath.FromSlash(clean)), nil
+}
+
+func (b *LocalBackend) info(key, p string) (*ObjectInfo, error) {
+	st, err := os.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if st.IsDir() {
+		return nil, ErrNotFound
+	}
+	ct := mime.TypeByExtension(filepath.Ext(p))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         st.Size(),
+		ContentType:  ct,
+		ETag:         fmt.Sprintf("%x-%x", st.ModTime().UnixNano(), st.Size()),
+		LastModified: st.ModTime(),
+	}, nil
+}
+
+// Put menulis ke file sementara lalu rename, supaya reader tidak melihat file setengah jadi
+func (b *LocalBackend) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (b *LocalBackend) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := b.info(key, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+func (b *LocalBackend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(f, offset, length), f}, nil
+}
+
+func (b *LocalBackend) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return b.info(key, p)
+}
+
+func (b *LocalBackend) Delete(ctx context.Context, key string) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *LocalBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	err := filepath.Walk(b.root, func(p string, st os.FileInfo, err error) error {
+		if err != nil || st.IsDir() || strings.HasPrefix(st.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return nil
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := b.info(key, p)
+		if err == nil {
+			result = append(result, *info)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (b *LocalBackend) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return b.presign("GET", key, ttl)
+}
+
+func (b *LocalBackend) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	return b.presign("PUT", key, ttl)
+}
+
+func (b *LocalBackend) presign(method, key string, ttl time.Duration) (string, error) {
+	if _, err := b.path(key); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(ttl).Unix()
+	sig := SignLocal(b.signingKey, method, b.bucket, key, exp)
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", sig)
+	return b.baseURL + LocalRoutePrefix + url.PathEscape(b.bucket) + "/" + escapeKey(key) + "?" + q.Encode(), nil
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// SignLocal = HMAC-SHA256 atas method, bucket, key dan expiry
+func SignLocal(signingKey []byte, method, bucket, key string, exp int64) string {
+	mac := hmac.New(sha256.New, signingKey)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", method, bucket, key, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyLocal cek signature & expiry presigned URL lokal
+func VerifyLocal(signingKey []byte, method, bucket, key string, exp int64, sig string) bool {
+	if time.Now().Unix() > exp {
+		return false
+	}
+	expected := SignLocal(signingKey, method, bucket, key, exp)
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
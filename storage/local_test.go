@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *LocalBackend {
+	t.Helper()
+	b, err := NewLocal(Config{Root: t.TempDir(), Bucket: "COMP-A", BaseURL: "https://api.example.com/", SigningKey: []byte("secret")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestLocalPath(t *testing.T) {
+	b := newTestLocal(t)
+
+	tests := []struct {
+		key     string
+		want    string // relatif terhadap root, kosong = ditolak
+		wantErr bool
+	}{
+		{"a.jpg", "a.jpg", false},
+		{"Assurance/COMP-A/a.jpg", "Assurance/COMP-A/a.jpg", false},
+		{"/leading/slash.jpg", "leading/slash.jpg", false},
+		{"dir//double.jpg", "dir/double.jpg", false},
+		{"", "", true},
+		{"/", "", true},
+		{"..", "", true},
+		{"../escape.jpg", "", true},
+		{"a/../../escape.jpg", "", true},
+		{"a/..", "", true},
+		{"nested/../inside.jpg", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := b.path(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("path(%q) = %q, %v, want ErrInvalidKey", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q): %v", tt.key, err)
+			}
+			if want := filepath.Join(b.root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, want)
+			}
+			if !strings.HasPrefix(got, b.root+string(filepath.Separator)) {
+				t.Errorf("path(%q) = %q escapes root %q", tt.key, got, b.root)
+			}
+		})
+	}
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	b := newTestLocal(t)
+	ctx := context.Background()
+
+	if err := b.Put(ctx, "docs/a.txt", strings.NewReader("hello world"), 11, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	rc, info, err := b.Get(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello world" || info.Size != 11 {
+		t.Errorf("Get = %q (size %d)", data, info.Size)
+	}
+
+	rc, err = b.GetRange(ctx, "docs/a.txt", 6, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "world" {
+		t.Errorf("GetRange = %q, want world", data)
+	}
+
+	list, err := b.List(ctx, "docs/")
+	if err != nil || len(list) != 1 || list[0].Key != "docs/a.txt" {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+
+	if err := b.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Head(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head after delete = %v, want ErrNotFound", err)
+	}
+	if err := b.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put outside root = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalPresignVerify(t *testing.T) {
+	b := newTestLocal(t)
+
+	raw, err := b.PresignGet(context.Background(), "docs/a b.jpg", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := LocalRoutePrefix + "COMP-A/docs/a b.jpg"; u.Path != want {
+		t.Errorf("presigned path = %q, want %q", u.Path, want)
+	}
+	exp, _ := strconv.ParseInt(u.Query().Get("exp"), 10, 64)
+	sig := u.Query().Get("sig")
+
+	if !VerifyLocal(b.signingKey, "GET", "COMP-A", "docs/a b.jpg", exp, sig) {
+		t.Error("valid signature rejected")
+	}
+
+	tests := []struct {
+		name                string
+		key                 []byte
+		method, bucket, obj string
+		exp                 int64
+	}{
+		{"other method", b.signingKey, "PUT", "COMP-A", "docs/a b.jpg", exp},
+		{"other bucket", b.signingKey, "GET", "COMP-B", "docs/a b.jpg", exp},
+		{"other key", b.signingKey, "GET", "COMP-A", "docs/other.jpg", exp},
+		{"extended expiry", b.signingKey, "GET", "COMP-A", "docs/a b.jpg", exp + 3600},
+		{"other signing key", []byte("other"), "GET", "COMP-A", "docs/a b.jpg", exp},
+	}
+	for _, tt := range tests {
+		if VerifyLocal(tt.key, tt.method, tt.bucket, tt.obj, tt.exp, sig) {
+			t.Errorf("%s: signature accepted", tt.name)
+		}
+	}
+
+	expired := time.Now().Add(-time.Second).Unix()
+	if VerifyLocal(b.signingKey, "GET", "COMP-A", "docs/a b.jpg", expired, SignLocal(b.signingKey, "GET", "COMP-A", "docs/a b.jpg", expired)) {
+		t.Error("expired signature accepted")
+	}
+
+	if _, err := b.PresignPut(context.Background(), "../escape.jpg", "image/jpeg", time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("PresignPut outside root = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestNewLocalRequiresSigningKey(t *testing.T) {
+	if _, err := NewLocal(Config{Root: t.TempDir()}); err == nil {
+		t.Error("NewLocal without signing key succeeded")
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// Semua MemoryBackend dengan bucket yang sama berbagi isi (backend dibuat ulang tiap request)
+var (
+	memoryMu      sync.RWMutex
+	memoryBuckets = make(map[string]map[string]memoryObject)
+)
+
+// MemoryBackend = penyimpanan in-memory untuk test / development, isi hilang saat restart
+type MemoryBackend struct {
+	bucket string
+}
+
+func NewMemory(bucket string) *MemoryBackend {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	if memoryBuckets[bucket] == nil {
+		memoryBuckets[bucket] = make(map[string]memoryObject)
+	}
+	return &MemoryBackend{bucket: bucket}
+}
+
+func (o memoryObject) info(key string) *ObjectInfo {
+	sum := md5.Sum(o.data)
+	return &ObjectInfo{
+		Key:          key,
+		Size:         int64(len(o.data)),
+		ContentType:  o.contentType,
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: o.modified,
+	}
+}
+
+func (b *MemoryBackend) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	memoryBuckets[b.bucket][key] = memoryObject{data: data, contentType: contentType, modified: time.Now()}
+	return nil
+}
+
+func (b *MemoryBackend) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	memoryMu.RLock()
+	defer memoryMu.RUnlock()
+	o, ok := memoryBuckets[b.bucket][key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(o.data)), o.info(key), nil
+}
+
+func (b *MemoryBackend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	memoryMu.RLock()
+	defer memoryMu.RUnlock()
+	o, ok := memoryBuckets[b.bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(io.NewSectionReader(bytes.NewReader(o.data), offset, length)), nil
+}
+
+func (b *MemoryBackend) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	memoryMu.RLock()
+	defer memoryMu.RUnlock()
+	o, ok := memoryBuckets[b.bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return o.info(key), nil
+}
+
+func (b *MemoryBackend) Delete(ctx context.Context, key string) error {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	delete(memoryBuckets[b.bucket], key)
+	return nil
+}
+
+func (b *MemoryBackend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	memoryMu.RLock()
+	defer memoryMu.RUnlock()
+	var result []ObjectInfo
+	for key, o := range memoryBuckets[b.bucket] {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, *o.info(key))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func (b *MemoryBackend) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return "memory://" + b.bucket + "/" + key, nil
+}
+
+func (b *MemoryBackend) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	return "memory://" + b.bucket + "/" + key, nil
+}
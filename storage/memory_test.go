@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemory(t.Name())
+
+	for _, key := range []string{"b/2.txt", "a/1.txt", "b/1.txt"} {
+		if err := b.Put(ctx, key, strings.NewReader(key), int64(len(key)), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Backend baru dengan bucket yang sama melihat isi yang sama
+	rc, info, err := NewMemory(t.Name()).Get(ctx, "a/1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	if string(data) != "a/1.txt" || info.ContentType != "text/plain" || info.Size != 7 {
+		t.Errorf("Get = %q, %+v", data, info)
+	}
+
+	rc, err = b.GetRange(ctx, "a/1.txt", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(rc)
+	if string(data) != "1.t" {
+		t.Errorf("GetRange = %q, want 1.t", data)
+	}
+
+	list, _ := b.List(ctx, "b/")
+	if len(list) != 2 || list[0].Key != "b/1.txt" || list[1].Key != "b/2.txt" {
+		t.Errorf("List = %+v, want sorted b/1.txt, b/2.txt", list)
+	}
+
+	if err := b.Delete(ctx, "a/1.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Head(ctx, "a/1.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head after delete = %v, want ErrNotFound", err)
+	}
+
+	// Bucket lain terpisah
+	if _, err := NewMemory(t.Name()+"-other").Head(ctx, "b/1.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other bucket Head = %v, want ErrNotFound", err)
+	}
+}
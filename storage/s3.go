@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Backend = bucket S3-compatible (IDrive E2 dll), path-style
+type S3Backend struct {
+	client *s3.S3
+	bucket string
+}
+
+func NewS3(cfg Config) (*S3Backend, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String(cfg.Region),
+		Credentials:      credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
+		Endpoint:         aws.String(cfg.Endpoint),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Backend{client: s3.New(sess), bucket: cfg.Bucket}, nil
+}
+
+func isS3NotFound(err error) bool {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+// Put meng-upload secara streaming (s3manager), body tidak perlu io.ReadSeeker
+func (b *S3Backend) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	uploader := s3manager.NewUploaderWithClient(b.client)
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("private"),
+	})
+	return err
+}
+
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return out.Body, &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         strings.Trim(aws.StringValue(out.ETag), `"`),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+// GetRange = sebagian object (HTTP Range ke bucket), untuk streaming video
+func (b *S3Backend) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (b *S3Backend) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := b.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         strings.Trim(aws.StringValue(out.ETag), `"`),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	err := b.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			result = append(result, ObjectInfo{
+				Key:          aws.StringValue(o.Key),
+				Size:         aws.Int64Value(o.Size),
+				ETag:         strings.Trim(aws.StringValue(o.ETag), `"`),
+				LastModified: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	return result, err
+}
+
+func (b *S3Backend) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	req, _ := b.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(ttl)
+}
+
+func (b *S3Backend) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	req, _ := b.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	return req.Presign(ttl)
+}
+
+func (b *S3Backend) CreateMultipart(ctx context.Context, key, contentType string) (string, error) {
+	out, err := b.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (b *S3Backend) PresignPart(ctx context.Context, key, uploadID string, partNumber int64, ttl time.Duration) (string, error) {
+	req, _ := b.client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(b.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(partNumber),
+	})
+	return req.Presign(ttl)
+}
+
+func (b *S3Backend) CompleteMultipart(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			PartNumber: aws.Int64(p.PartNumber),
+			ETag:       aws.String(p.ETag),
+		})
+	}
+	_, err := b.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+func (b *S3Backend) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	_, err := b.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(b.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Driver yang didukung (MstrCompany.StorageBackend)
+const (
+	DriverS3     = "s3"
+	DriverLocal  = "local"
+	DriverMemory = "memory"
+)
+
+var (
+	ErrNotFound             = errors.New("object not found")
+	ErrInvalidKey           = errors.New("invalid object key")
+	ErrMultipartUnsupported = errors.New("multipart upload not supported by storage backend")
+)
+
+// ObjectInfo = metadata object tanpa isinya
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Backend = object storage milik satu company (S3-compatible, filesystem lokal, atau memory untuk test)
+type Backend interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Head(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error)
+}
+
+// CompletedPart = part multipart yang sudah di-upload client
+type CompletedPart struct {
+	PartNumber int64
+	ETag       string
+}
+
+// MultipartBackend diimplementasikan backend yang bisa menerima upload multipart langsung dari client
+type MultipartBackend interface {
+	CreateMultipart(ctx context.Context, key, contentType string) (string, error)
+	PresignPart(ctx context.Context, key, uploadID string, partNumber int64, ttl time.Duration) (string, error)
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	AbortMultipart(ctx context.Context, key, uploadID string) error
+}
+
+// Config = konfigurasi backend satu company
+type Config struct {
+	Driver string
+
+	// S3-compatible (IDrive E2, MinIO, AWS)
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+
+	// Local filesystem: file disimpan di Root, presigned URL diarahkan ke BaseURL (API ini) dan ditandatangani SigningKey
+	Root       string
+	BaseURL    string
+	SigningKey []byte
+}
+
+// New membuat backend sesuai driver (default s3 untuk company lama)
+func New(cfg Config) (Backend, error) {
+	switch cfg.Driver {
+	case "", DriverS3:
+		return NewS3(cfg)
+	case DriverLocal:
+		return NewLocal(cfg)
+	case DriverMemory:
+		return NewMemory(cfg.Bucket), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Driver)
+	}
+}
+
+// IsValidDriver cek nama driver yang boleh dipilih company (memory hanya untuk test)
+func IsValidDriver(driver string) bool {
+	return driver == DriverS3 || driver == DriverLocal
+}
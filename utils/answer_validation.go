@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationRules = aturan validasi jawaban per question (kolom validation_rules, jsonb).
+// Semua field opsional; question tanpa rules hanya dicek sesuai tipenya.
+type ValidationRules struct {
+	Required         bool     `json:"required,omitempty"`
+	Min              *float64 `json:"min,omitempty"`                // number: nilai minimum, rating: awal skala, checkbox: jumlah pilihan minimum
+	Max              *float64 `json:"max,omitempty"`                // number: nilai maksimum, rating: akhir skala, checkbox: jumlah pilihan maksimum
+	MinLength        *int     `json:"min_length,omitempty"`         // text: jumlah karakter minimum
+	MaxLength        *int     `json:"max_length,omitempty"`         // text: jumlah karakter maksimum
+	Pattern          string   `json:"pattern,omitempty"`            // regex, harus cocok dengan seluruh jawaban
+	AllowedLabels    []string `json:"allowed_labels,omitempty"`     // multiple/checkbox: subset label option yang boleh dipilih
+	MaxFileSize      int64    `json:"max_file_size,omitempty"`      // image/signature: ukuran file maksimum (byte)
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"` // image/signature: mis. "image/jpeg" atau "image/*"
+}
+
+// AnswerInput = satu jawaban dari tablet yang akan divalidasi
+type AnswerInput struct {
+	Text     string
+	HasFile  bool
+	FileSize int64
+	FileType string
+}
+
+// AnswerError = daftar pelanggaran untuk satu question, dikirim ke tablet apa adanya
+type AnswerError struct {
+	QuestionID uint     `json:"question_id"`
+	SamID      uint     `json:"sam_id,omitempty"`
+	Errors     []string `json:"errors"`
+}
+
+// ParseValidationRules membaca kolom validation_rules (kosong / null = tanpa rules)
+func ParseValidationRules(raw []byte) (*ValidationRules, error) {
+	rules := &ValidationRules{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return rules, nil
+	}
+	if err := json.Unmarshal(raw, rules); err != nil {
+		return nil, fmt.Errorf("invalid validation_rules: %v", err)
+	}
+	return rules, nil
+}
+
+// CheckValidationRules dipakai saat question disimpan supaya rules yang rusak tidak sampai ke tablet
+func CheckValidationRules(questionType string, raw []byte) error {
+	rules, err := ParseValidationRules(raw)
+	if err != nil {
+		return err
+	}
+	if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+		return errors.New("validation_rules: min must not be greater than max")
+	}
+	if (rules.MinLength != nil && *rules.MinLength < 0) || (rules.MaxLength != nil && *rules.MaxLength < 0) {
+		return errors.New("validation_rules: min_length and max_length must not be negative")
+	}
+	if rules.MinLength != nil && rules.MaxLength != nil && *rules.MinLength > *rules.MaxLength {
+		return errors.New("validation_rules: min_length must not be greater than max_length")
+	}
+	if rules.Pattern != "" {
+		if _, err := compileAnswerPattern(rules.Pattern); err != nil {
+			return fmt.Errorf("validation_rules: invalid pattern: %v", err)
+		}
+	}
+	if rules.MaxFileSize < 0 {
+		return errors.New("validation_rules: max_file_size must not be negative")
+	}
+	if len(rules.AllowedLabels) > 0 && !QuestionTypeHasOptions(questionType) {
+		return errors.New("validation_rules: allowed_labels only applies to multiple choice and checkbox questions")
+	}
+	if strings.ToLower(questionType) == QuestionRating {
+		min, max := ratingScale(rules)
+		if min < 0 || max > maxRatingScale || min >= max {
+			return fmt.Errorf("validation_rules: rating scale must be within 0..%d", maxRatingScale)
+		}
+	}
+	return nil
+}
+
+var answerPatterns sync.Map // pattern → *regexp.Regexp
+
+func compileAnswerPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := answerPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	answerPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// ValidateAnswer mengecek satu jawaban terhadap tipe question, label option dan rules.
+// Return daftar pesan error (kosong = valid).
+func ValidateAnswer(questionType string, optionLabels []string, rules *ValidationRules, in AnswerInput) []string {
+	if rules == nil {
+		rules = &ValidationRules{}
+	}
+	qt := strings.ToLower(questionType)
+	text := strings.TrimSpace(in.Text)
+
+	// essay dari dulu wajib diisi
+	required := rules.Required || qt == "essay"
+
+	if IsFileQuestionType(qt) {
+		if !in.HasFile {
+			if required {
+				return []string{"file is required"}
+			}
+			return nil
+		}
+		var errs []string
+		if rules.MaxFileSize > 0 && in.FileSize > rules.MaxFileSize {
+			errs = append(errs, fmt.Sprintf("file must not be larger than %d bytes", rules.MaxFileSize))
+		}
+		if len(rules.AllowedMimeTypes) > 0 && !mimeAllowed(in.FileType, rules.AllowedMimeTypes) {
+			errs = append(errs, fmt.Sprintf("file type %s is not allowed (allowed: %s)", in.FileType, strings.Join(rules.AllowedMimeTypes, ", ")))
+		}
+		return errs
+	}
+
+	if text == "" {
+		if required {
+			return []string{"answer is required"}
+		}
+		return nil
+	}
+
+	var errs []string
+	switch qt {
+	case "yesno":
+		up := strings.ToUpper(text)
+		if up != "YES" && up != "NO" {
+			errs = append(errs, "answer must be Yes or No")
+		}
+	case "multiple":
+		if !containsFold(optionLabels, text) {
+			errs = append(errs, fmt.Sprintf("answer must be one of the option labels (%s)", strings.Join(optionLabels, ", ")))
+		} else if len(rules.AllowedLabels) > 0 && !containsFold(rules.AllowedLabels, text) {
+			errs = append(errs, fmt.Sprintf("option %s is not allowed (allowed: %s)", strings.ToUpper(text), strings.Join(rules.AllowedLabels, ", ")))
+		}
+	case QuestionCheckbox:
+		labels := SplitAnswerLabels(text)
+		if len(labels) == 0 {
+			errs = append(errs, "answer must be a list of option labels")
+			break
+		}
+		for _, l := range labels {
+			if !containsFold(optionLabels, l) {
+				errs = append(errs, fmt.Sprintf("option %s is not one of the option labels (%s)", l, strings.Join(optionLabels, ", ")))
+			} else if len(rules.AllowedLabels) > 0 && !containsFold(rules.AllowedLabels, l) {
+				errs = append(errs, fmt.Sprintf("option %s is not allowed (allowed: %s)", l, strings.Join(rules.AllowedLabels, ", ")))
+			}
+		}
+		if rules.Min != nil && float64(len(labels)) < *rules.Min {
+			errs = append(errs, fmt.Sprintf("at least %v options must be selected", *rules.Min))
+		}
+		if rules.Max != nil && float64(len(labels)) > *rules.Max {
+			errs = append(errs, fmt.Sprintf("at most %v options may be selected", *rules.Max))
+		}
+		return errs
+	case QuestionRating:
+		min, max := ratingScale(rules)
+		n, err := strconv.Atoi(text)
+		if err != nil || n < min || n > max {
+			errs = append(errs, fmt.Sprintf("answer must be a whole number from %d to %d", min, max))
+		}
+		return errs
+	case QuestionDate, QuestionTime, QuestionDateTime:
+		if _, err := parseAnswerDateTime(qt, text); err != nil {
+			layout := map[string]string{QuestionDate: "YYYY-MM-DD", QuestionTime: "HH:MM", QuestionDateTime: "RFC 3339"}[qt]
+			errs = append(errs, fmt.Sprintf("answer must be a %s in %s format", qt, layout))
+		}
+		return errs
+	case QuestionGPS:
+		if _, err := ParseGPSAnswer(text); err != nil {
+			errs = append(errs, err.Error())
+		}
+		return errs
+	case "number":
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			errs = append(errs, "answer must be a number")
+			break
+		}
+		if rules.Min != nil && n < *rules.Min {
+			errs = append(errs, fmt.Sprintf("answer must be at least %v", *rules.Min))
+		}
+		if rules.Max != nil && n > *rules.Max {
+			errs = append(errs, fmt.Sprintf("answer must be at most %v", *rules.Max))
+		}
+	}
+
+	length := utf8.RuneCountInString(text)
+	if rules.MinLength != nil && length < *rules.MinLength {
+		errs = append(errs, fmt.Sprintf("answer must be at least %d characters", *rules.MinLength))
+	}
+	if rules.MaxLength != nil && length > *rules.MaxLength {
+		errs = append(errs, fmt.Sprintf("answer must be at most %d characters", *rules.MaxLength))
+	}
+	if rules.Pattern != "" {
+		if re, err := compileAnswerPattern(rules.Pattern); err == nil && !re.MatchString(text) {
+			errs = append(errs, "answer does not match the required format")
+		}
+	}
+	return errs
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// mimeAllowed mendukung wildcard subtype ("image/*")
+func mimeAllowed(contentType string, allowed []string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	major, _, _ := strings.Cut(contentType, "/")
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == contentType || a == "*/*" || (strings.HasSuffix(a, "/*") && strings.TrimSuffix(a, "/*") == major) {
+			return true
+		}
+	}
+	return false
+}
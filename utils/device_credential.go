@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Header yang dipakai tablet: X-Device-Key: <key_id>.<secret>
+const DeviceKeyHeader = "X-Device-Key"
+
+var ErrInvalidDeviceKey = errors.New("invalid device key")
+
+// IssueDeviceCredential membuat credential baru untuk device, mengembalikan "<key_id>.<secret>" (hanya sekali)
+func IssueDeviceCredential(tx *gorm.DB, device models.MstrDevice, issuedBy string) (*models.MstrDeviceCredential, string, error) {
+	kb := make([]byte, 12)
+	if _, err := rand.Read(kb); err != nil {
+		return nil, "", err
+	}
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	cred := models.MstrDeviceCredential{
+		DeviceID:   device.Id,
+		CompanyID:  device.CompanyID,
+		KeyID:      "dk_" + hex.EncodeToString(kb),
+		SecretHash: HashToken(secret),
+		CreatedBy:  issuedBy,
+	}
+	if err := tx.Create(&cred).Error; err != nil {
+		return nil, "", err
+	}
+	return &cred, cred.KeyID + "." + secret, nil
+}
+
+// RevokeDeviceCredentials revoke semua credential aktif milik device
+func RevokeDeviceCredentials(tx *gorm.DB, deviceID uint, revokedBy string) error {
+	return tx.Model(&models.MstrDeviceCredential{}).
+		Where("device_id = ? AND revoked_at IS NULL", deviceID).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"revoked_by": revokedBy,
+		}).Error
+}
+
+// HasActiveDeviceCredential cek apakah device masih punya credential yang belum di-revoke
+func HasActiveDeviceCredential(deviceID uint) bool {
+	var count int64
+	config.DB.Model(&models.MstrDeviceCredential{}).
+		Where("device_id = ? AND revoked_at IS NULL", deviceID).
+		Count(&count)
+	return count > 0
+}
+
+// VerifyDeviceKey validasi header X-Device-Key, device harus aktif dan credential belum di-revoke
+func VerifyDeviceKey(raw string) (*models.MstrDeviceCredential, *models.MstrDevice, error) {
+	keyID, secret, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok || keyID == "" || secret == "" {
+		return nil, nil, ErrInvalidDeviceKey
+	}
+
+	var cred models.MstrDeviceCredential
+	if err := config.DB.Where("key_id = ? AND revoked_at IS NULL", keyID).First(&cred).Error; err != nil {
+		return nil, nil, ErrInvalidDeviceKey
+	}
+	if subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(cred.SecretHash)) != 1 {
+		return nil, nil, ErrInvalidDeviceKey
+	}
+
+	var device models.MstrDevice
+	if err := config.DB.First(&device, cred.DeviceID).Error; err != nil || !device.IsActive {
+		return nil, nil, ErrInvalidDeviceKey
+	}
+
+	// last_used_at cukup diupdate maksimal sekali per menit
+	if cred.LastUsedAt == nil || time.Since(*cred.LastUsedAt) >= time.Minute {
+		config.DB.Model(&models.MstrDeviceCredential{}).
+			Where("id = ?", cred.Id).
+			UpdateColumn("last_used_at", time.Now())
+	}
+
+	return &cred, &device, nil
+}
+
+var ErrDeviceMismatch = errors.New("device key does not match device")
+
+// AuthDeviceID mencocokkan device_id dari path / body dengan device pemilik X-Device-Key.
+// Tanpa credential device (JWT + API key legacy) deviceID dikembalikan apa adanya;
+// dengan credential device, deviceID kosong diisi device tersebut dan device lain ditolak.
+func AuthDeviceID(c *gin.Context, deviceID string) (string, error) {
+	authDevice := c.GetString("auth_device_id")
+	if authDevice == "" {
+		return deviceID, nil
+	}
+	if deviceID != "" && deviceID != authDevice {
+		return "", ErrDeviceMismatch
+	}
+	return authDevice, nil
+}
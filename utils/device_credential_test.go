@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAuthDeviceID(t *testing.T) {
+	tests := []struct {
+		name       string
+		authDevice string // device pemilik X-Device-Key, kosong = JWT + API key legacy
+		deviceID   string // device_id dari path / body
+		want       string
+		wantErr    error
+	}{
+		{"legacy key keeps body device", "", "TAB-1", "TAB-1", nil},
+		{"legacy key without device", "", "", "", nil},
+		{"device key same device", "TAB-1", "TAB-1", "TAB-1", nil},
+		{"device key fills empty device", "TAB-1", "", "TAB-1", nil},
+		{"device key other device", "TAB-1", "TAB-2", "", ErrDeviceMismatch},
+		{"device key is case sensitive", "TAB-1", "tab-1", "", ErrDeviceMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tenantContext("COMP-A", RoleUser)
+			if tt.authDevice != "" {
+				c.Set("auth_device_id", tt.authDevice)
+			}
+			got, err := AuthDeviceID(c, tt.deviceID)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("AuthDeviceID(%q) = %q, %v; want %q, %v", tt.deviceID, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// Tanpa karakter yang mirip (0/O, 1/I/L) supaya mudah diketik di tablet
+const pairingCodeLetters = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const PairingCodeLength = 8
+
+// NewPairingCode membuat kode pairing acak (crypto/rand)
+func NewPairingCode() (string, error) {
+	b := make([]byte, PairingCodeLength)
+	max := big.NewInt(int64(len(pairingCodeLetters)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = pairingCodeLetters[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// NewEnrolmentToken token sekali pakai untuk tablet mengecek status enrolment-nya
+func NewEnrolmentToken() (string, error) {
+	return newOpaqueToken()
+}
@@ -0,0 +1,344 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DisplayCondition = kapan sebuah question ditampilkan (kolom display_condition, jsonb).
+// Bisa berupa satu perbandingan terhadap jawaban question lain di SAM / questionnaire yang sama:
+//
+//	{"question_id": 3, "op": "eq", "value": "No"}
+//
+// atau gabungan beberapa kondisi:
+//
+//	{"all": [{...}, {...}]}  → AND
+//	{"any": [{...}, {...}]}  → OR
+type DisplayCondition struct {
+	QuestionID uint               `json:"question_id,omitempty"`
+	Op         string             `json:"op,omitempty"`
+	Value      ConditionValue     `json:"value,omitempty"`
+	Values     []ConditionValue   `json:"values,omitempty"` // untuk op in / not_in
+	All        []DisplayCondition `json:"all,omitempty"`
+	Any        []DisplayCondition `json:"any,omitempty"`
+}
+
+// Operator kondisi. Jika question yang direferensikan belum dijawab (atau ikut tersembunyi)
+// semua operator bernilai false kecuali not_answered.
+const (
+	ConditionEq          = "eq"
+	ConditionNeq         = "neq"
+	ConditionIn          = "in"
+	ConditionNotIn       = "not_in"
+	ConditionContains    = "contains" // checkbox: label dipilih
+	ConditionGt          = "gt"
+	ConditionGte         = "gte"
+	ConditionLt          = "lt"
+	ConditionLte         = "lte"
+	ConditionAnswered    = "answered"
+	ConditionNotAnswered = "not_answered"
+)
+
+// ConditionValue menerima string, angka atau boolean di JSON dan disimpan sebagai string
+type ConditionValue string
+
+func (v *ConditionValue) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch val := raw.(type) {
+	case nil:
+		*v = ""
+	case string:
+		*v = ConditionValue(val)
+	case float64:
+		*v = ConditionValue(strconv.FormatFloat(val, 'f', -1, 64))
+	case bool:
+		*v = ConditionValue(strconv.FormatBool(val))
+	default:
+		return errors.New("condition value must be a string, number or boolean")
+	}
+	return nil
+}
+
+// ConditionalQuestion = question yang ikut dievaluasi (ID 0 = question baru yang belum tersimpan)
+type ConditionalQuestion struct {
+	ID        uint
+	Condition []byte
+}
+
+// ParseDisplayCondition membaca kolom display_condition (kosong / null / {} = selalu tampil)
+func ParseDisplayCondition(raw []byte) (*DisplayCondition, error) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" || s == "{}" {
+		return nil, nil
+	}
+	var cond DisplayCondition
+	if err := json.Unmarshal(raw, &cond); err != nil {
+		return nil, fmt.Errorf("invalid display_condition: %v", err)
+	}
+	return &cond, nil
+}
+
+func (d *DisplayCondition) validate() error {
+	isGroup := len(d.All) > 0 || len(d.Any) > 0
+	if isGroup {
+		if d.QuestionID != 0 || d.Op != "" {
+			return errors.New("a condition must be either a comparison or an all/any group, not both")
+		}
+		if len(d.All) > 0 && len(d.Any) > 0 {
+			return errors.New("use nested conditions to combine all and any")
+		}
+		for i := range d.All {
+			if err := d.All[i].validate(); err != nil {
+				return err
+			}
+		}
+		for i := range d.Any {
+			if err := d.Any[i].validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if d.QuestionID == 0 {
+		return errors.New("question_id is required")
+	}
+	switch d.Op {
+	case ConditionEq, ConditionNeq, ConditionContains:
+	case ConditionIn, ConditionNotIn:
+		if len(d.Values) == 0 {
+			return fmt.Errorf("op %s requires values", d.Op)
+		}
+	case ConditionGt, ConditionGte, ConditionLt, ConditionLte:
+		if _, err := strconv.ParseFloat(string(d.Value), 64); err != nil {
+			return fmt.Errorf("op %s requires a numeric value", d.Op)
+		}
+	case ConditionAnswered, ConditionNotAnswered:
+	default:
+		return fmt.Errorf("unknown op %q", d.Op)
+	}
+	return nil
+}
+
+// references = semua question_id yang dipakai kondisi ini
+func (d *DisplayCondition) references(out []uint) []uint {
+	if d.QuestionID != 0 {
+		out = append(out, d.QuestionID)
+	}
+	for i := range d.All {
+		out = d.All[i].references(out)
+	}
+	for i := range d.Any {
+		out = d.Any[i].references(out)
+	}
+	return out
+}
+
+// CheckDisplayConditions dipakai saat question disimpan: kondisi harus valid, hanya mereferensikan
+// question lain dalam daftar yang sama (yang sudah punya ID) dan tidak boleh membentuk siklus.
+func CheckDisplayConditions(questions []ConditionalQuestion) error {
+	known := make(map[uint]bool, len(questions))
+	for _, q := range questions {
+		if q.ID != 0 {
+			known[q.ID] = true
+		}
+	}
+
+	deps := make(map[uint][]uint, len(questions))
+	for i, q := range questions {
+		cond, err := ParseDisplayCondition(q.Condition)
+		if err != nil {
+			return err
+		}
+		if cond == nil {
+			continue
+		}
+		if err := cond.validate(); err != nil {
+			return fmt.Errorf("display_condition of question #%d: %v", i+1, err)
+		}
+		for _, ref := range cond.references(nil) {
+			if q.ID != 0 && ref == q.ID {
+				return fmt.Errorf("display_condition of question #%d refers to itself", i+1)
+			}
+			if !known[ref] {
+				return fmt.Errorf("display_condition of question #%d refers to unknown question %d", i+1, ref)
+			}
+		}
+		if q.ID != 0 {
+			deps[q.ID] = cond.references(nil)
+		}
+	}
+
+	// DFS untuk mendeteksi siklus (Q1 tergantung Q2, Q2 tergantung Q1)
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[uint]int, len(deps))
+	var visit func(id uint) bool
+	visit = func(id uint) bool {
+		switch state[id] {
+		case visiting:
+			return false
+		case done:
+			return true
+		}
+		state[id] = visiting
+		for _, dep := range deps[id] {
+			if !visit(dep) {
+				return false
+			}
+		}
+		state[id] = done
+		return true
+	}
+	for id := range deps {
+		if !visit(id) {
+			return fmt.Errorf("display_condition of question %d forms a cycle", id)
+		}
+	}
+	return nil
+}
+
+// RemapDisplayCondition mengganti question_id lama dengan ID baru (copy assurance / ID sementara dari client)
+func RemapDisplayCondition(raw []byte, ids map[uint]uint) ([]byte, error) {
+	cond, err := ParseDisplayCondition(raw)
+	if err != nil || cond == nil {
+		return raw, err
+	}
+	cond.remap(ids)
+	return json.Marshal(cond)
+}
+
+func (d *DisplayCondition) remap(ids map[uint]uint) {
+	if newID, ok := ids[d.QuestionID]; ok {
+		d.QuestionID = newID
+	}
+	for i := range d.All {
+		d.All[i].remap(ids)
+	}
+	for i := range d.Any {
+		d.Any[i].remap(ids)
+	}
+}
+
+// VisibleQuestions mengevaluasi display_condition semua question terhadap jawaban yang dikirim.
+// answers = jawaban per question (teks, atau object key untuk image); kosong = tidak dijawab.
+// Question yang tersembunyi dianggap tidak dijawab oleh kondisi question lain.
+func VisibleQuestions(questions []ConditionalQuestion, answers map[uint]string) map[uint]bool {
+	conds := make(map[uint]*DisplayCondition, len(questions))
+	for _, q := range questions {
+		cond, err := ParseDisplayCondition(q.Condition)
+		if err != nil {
+			cond = nil // kondisi rusak → question tetap tampil
+		}
+		conds[q.ID] = cond
+	}
+
+	visible := make(map[uint]bool, len(questions))
+	evaluating := make(map[uint]bool)
+	var isVisible func(id uint) bool
+	isVisible = func(id uint) bool {
+		if v, ok := visible[id]; ok {
+			return v
+		}
+		cond, ok := conds[id]
+		if !ok {
+			return false // question di luar daftar tidak bisa dijawab di submission ini
+		}
+		if cond == nil || evaluating[id] {
+			visible[id] = true
+			return true
+		}
+		evaluating[id] = true
+		answerOf := func(ref uint) string {
+			if !isVisible(ref) {
+				return ""
+			}
+			return strings.TrimSpace(answers[ref])
+		}
+		v := cond.eval(answerOf)
+		delete(evaluating, id)
+		visible[id] = v
+		return v
+	}
+	for _, q := range questions {
+		isVisible(q.ID)
+	}
+	return visible
+}
+
+func (d *DisplayCondition) eval(answerOf func(uint) string) bool {
+	if len(d.All) > 0 {
+		for i := range d.All {
+			if !d.All[i].eval(answerOf) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(d.Any) > 0 {
+		for i := range d.Any {
+			if d.Any[i].eval(answerOf) {
+				return true
+			}
+		}
+		return false
+	}
+
+	answer := answerOf(d.QuestionID)
+	if d.Op == ConditionNotAnswered {
+		return answer == ""
+	}
+	if answer == "" {
+		return false
+	}
+
+	switch d.Op {
+	case ConditionAnswered:
+		return true
+	case ConditionEq:
+		return strings.EqualFold(answer, strings.TrimSpace(string(d.Value)))
+	case ConditionNeq:
+		return !strings.EqualFold(answer, strings.TrimSpace(string(d.Value)))
+	case ConditionContains:
+		for _, l := range SplitAnswerLabels(answer) {
+			if strings.EqualFold(l, strings.TrimSpace(string(d.Value))) {
+				return true
+			}
+		}
+		return false
+	case ConditionIn, ConditionNotIn:
+		found := false
+		for _, v := range d.Values {
+			if strings.EqualFold(answer, strings.TrimSpace(string(v))) {
+				found = true
+				break
+			}
+		}
+		return found == (d.Op == ConditionIn)
+	case ConditionGt, ConditionGte, ConditionLt, ConditionLte:
+		a, err1 := strconv.ParseFloat(answer, 64)
+		b, err2 := strconv.ParseFloat(strings.TrimSpace(string(d.Value)), 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		switch d.Op {
+		case ConditionGt:
+			return a > b
+		case ConditionGte:
+			return a >= b
+		case ConditionLt:
+			return a < b
+		default:
+			return a <= b
+		}
+	}
+	return false
+}
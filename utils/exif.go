@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// EXIFInfo = field EXIF yang dipakai sebagai bukti asal evidence
+type EXIFInfo struct {
+	CaptureTime *time.Time
+	Latitude    *float64
+	Longitude   *float64
+	Make        string
+	Model       string
+}
+
+var ErrNoEXIF = errors.New("no exif data")
+
+const (
+	exifTagMake             = 0x010f
+	exifTagModel            = 0x0110
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagGPSIFD           = 0x8825
+	exifTagDateTimeOriginal = 0x9003
+	exifTagGPSLatRef        = 0x0001
+	exifTagGPSLat           = 0x0002
+	exifTagGPSLngRef        = 0x0003
+	exifTagGPSLng           = 0x0004
+)
+
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // isi mentah (inline atau dari offset)
+}
+
+// ParseEXIF membaca segmen APP1 Exif dari awal file JPEG (cukup beberapa puluh KB pertama)
+func ParseEXIF(head []byte) (*EXIFInfo, error) {
+	if len(head) < 4 || head[0] != 0xFF || head[1] != 0xD8 {
+		return nil, ErrNoEXIF
+	}
+
+	pos := 2
+	for pos+4 <= len(head) {
+		if head[pos] != 0xFF {
+			return nil, ErrNoEXIF
+		}
+		marker := head[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return nil, ErrNoEXIF
+		}
+		segLen := int(binary.BigEndian.Uint16(head[pos+2 : pos+4]))
+		if segLen < 2 || pos+2+segLen > len(head) {
+			return nil, ErrNoEXIF
+		}
+		seg := head[pos+4 : pos+2+segLen]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return parseTIFF(seg[6:])
+		}
+		pos += 2 + segLen
+	}
+	return nil, ErrNoEXIF
+}
+
+func parseTIFF(tiff []byte) (*EXIFInfo, error) {
+	if len(tiff) < 8 {
+		return nil, ErrNoEXIF
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, ErrNoEXIF
+	}
+
+	ifd0 := readIFD(tiff, order, order.Uint32(tiff[4:8]))
+	info := &EXIFInfo{
+		Make:  exifString(ifd0[exifTagMake]),
+		Model: exifString(ifd0[exifTagModel]),
+	}
+
+	captured := exifString(ifd0[exifTagDateTime])
+	if e, ok := ifd0[exifTagExifIFD]; ok {
+		sub := readIFD(tiff, order, exifUint(e, order))
+		if v := exifString(sub[exifTagDateTimeOriginal]); v != "" {
+			captured = v
+		}
+	}
+	if t, err := time.Parse("2006:01:02 15:04:05", captured); err == nil {
+		info.CaptureTime = &t
+	}
+
+	if e, ok := ifd0[exifTagGPSIFD]; ok {
+		gps := readIFD(tiff, order, exifUint(e, order))
+		lat, latOK := exifDegrees(gps[exifTagGPSLat], order)
+		lng, lngOK := exifDegrees(gps[exifTagGPSLng], order)
+		if latOK && lngOK {
+			if strings.HasPrefix(exifString(gps[exifTagGPSLatRef]), "S") {
+				lat = -lat
+			}
+			if strings.HasPrefix(exifString(gps[exifTagGPSLngRef]), "W") {
+				lng = -lng
+			}
+			info.Latitude, info.Longitude = &lat, &lng
+		}
+	}
+	return info, nil
+}
+
+// ukuran per komponen tipe TIFF (BYTE, ASCII, SHORT, LONG, RATIONAL, ...)
+var exifTypeSize = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) map[uint16]exifEntry {
+	entries := make(map[uint16]exifEntry)
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return entries
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < n; i++ {
+		p := uint64(offset) + 2 + uint64(i)*12
+		if p+12 > uint64(len(tiff)) {
+			break
+		}
+		tag := order.Uint16(tiff[p:])
+		typ := order.Uint16(tiff[p+2:])
+		count := order.Uint32(tiff[p+4:])
+		size, ok := exifTypeSize[typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(count)
+		var value []byte
+		if total <= 4 {
+			value = tiff[p+8 : p+8+total]
+		} else {
+			off := uint64(order.Uint32(tiff[p+8:]))
+			if off+total > uint64(len(tiff)) {
+				continue
+			}
+			value = tiff[off : off+total]
+		}
+		entries[tag] = exifEntry{typ: typ, count: count, value: value}
+	}
+	return entries
+}
+
+func exifString(e exifEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+func exifUint(e exifEntry, order binary.ByteOrder) uint32 {
+	switch {
+	case e.typ == 4 && len(e.value) >= 4:
+		return order.Uint32(e.value)
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(order.Uint16(e.value))
+	}
+	return 0
+}
+
+// exifDegrees mengubah 3 RATIONAL (derajat, menit, detik) ke desimal
+func exifDegrees(e exifEntry, order binary.ByteOrder) (float64, bool) {
+	if e.typ != 5 || e.count < 3 || len(e.value) < 24 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := order.Uint32(e.value[i*8:])
+		den := order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
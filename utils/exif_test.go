@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type exifField struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiField(tag uint16, s string) exifField {
+	data := append([]byte(s), 0)
+	return exifField{tag, 2, uint32(len(data)), data}
+}
+
+func longField(order binary.ByteOrder, tag uint16, v uint32) exifField {
+	data := make([]byte, 4)
+	order.PutUint32(data, v)
+	return exifField{tag, 4, 1, data}
+}
+
+// rationalField = derajat, menit, detik sebagai 3 RATIONAL
+func rationalField(order binary.ByteOrder, tag uint16, vals ...[2]uint32) exifField {
+	data := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		order.PutUint32(data[i*8:], v[0])
+		order.PutUint32(data[i*8+4:], v[1])
+	}
+	return exifField{tag, 5, uint32(len(vals)), data}
+}
+
+// encodeIFD menulis IFD yang dimulai di offset base, data > 4 byte diletakkan tepat setelahnya
+func encodeIFD(order binary.ByteOrder, base uint32, fields []exifField) []byte {
+	out := make([]byte, 2+12*len(fields)+4)
+	order.PutUint16(out, uint16(len(fields)))
+	var extra []byte
+	for i, f := range fields {
+		p := 2 + 12*i
+		order.PutUint16(out[p:], f.tag)
+		order.PutUint16(out[p+2:], f.typ)
+		order.PutUint32(out[p+4:], f.count)
+		if len(f.data) <= 4 {
+			copy(out[p+8:], f.data)
+			continue
+		}
+		order.PutUint32(out[p+8:], base+uint32(len(out)+len(extra)))
+		extra = append(extra, f.data...)
+	}
+	return append(out, extra...)
+}
+
+// buildTIFF menyusun IFD0 lalu Exif IFD dan GPS IFD (nil = tidak ada)
+func buildTIFF(order binary.ByteOrder, ifd0, exif, gps []exifField) []byte {
+	fields := append([]exifField{}, ifd0...)
+	if exif != nil {
+		fields = append(fields, longField(order, exifTagExifIFD, 0))
+	}
+	if gps != nil {
+		fields = append(fields, longField(order, exifTagGPSIFD, 0))
+	}
+
+	// Panjang IFD tidak tergantung nilai pointer, jadi offset bisa dihitung dulu
+	exifOff := 8 + uint32(len(encodeIFD(order, 8, fields)))
+	gpsOff := exifOff
+	if exif != nil {
+		gpsOff += uint32(len(encodeIFD(order, exifOff, exif)))
+	}
+	for i, f := range fields {
+		switch f.tag {
+		case exifTagExifIFD:
+			fields[i] = longField(order, f.tag, exifOff)
+		case exifTagGPSIFD:
+			fields[i] = longField(order, f.tag, gpsOff)
+		}
+	}
+
+	tiff := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	tiff = append(tiff, encodeIFD(order, 8, fields)...)
+	if exif != nil {
+		tiff = append(tiff, encodeIFD(order, exifOff, exif)...)
+	}
+	if gps != nil {
+		tiff = append(tiff, encodeIFD(order, gpsOff, gps)...)
+	}
+	return tiff
+}
+
+// jpegWithEXIF membungkus TIFF ke segmen APP1 setelah segmen APP0
+func jpegWithEXIF(tiff []byte) []byte {
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 'J', 'F'}
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	out = append(out, 0xFF, 0xE1, byte((len(seg)+2)>>8), byte(len(seg)+2))
+	out = append(out, seg...)
+	return append(out, 0xFF, 0xD9)
+}
+
+func gpsFields(order binary.ByteOrder, latRef, lngRef string) []exifField {
+	fields := []exifField{
+		rationalField(order, exifTagGPSLat, [2]uint32{6, 1}, [2]uint32{12, 1}, [2]uint32{3600, 100}),
+		rationalField(order, exifTagGPSLng, [2]uint32{106, 1}, [2]uint32{49, 1}, [2]uint32{0, 1}),
+	}
+	if latRef != "" {
+		fields = append(fields, asciiField(exifTagGPSLatRef, latRef))
+	}
+	if lngRef != "" {
+		fields = append(fields, asciiField(exifTagGPSLngRef, lngRef))
+	}
+	return fields
+}
+
+func sampleEXIF(order binary.ByteOrder, latRef, lngRef string) []byte {
+	return jpegWithEXIF(buildTIFF(order,
+		[]exifField{asciiField(exifTagMake, "Samsung"), asciiField(exifTagModel, "SM-T225"), asciiField(exifTagDateTime, "2024:01:02 03:04:05")},
+		[]exifField{asciiField(exifTagDateTimeOriginal, "2024:01:01 08:30:00")},
+		gpsFields(order, latRef, lngRef),
+	))
+}
+
+func TestParseEXIF(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+	lat, lng := 6.0+12.0/60+36.0/3600, 106.0+49.0/60
+	original := time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// IFD0 dengan Make di luar batas data, dipakai untuk kasus offset rusak
+	badOffset := buildTIFF(le, []exifField{asciiField(exifTagMake, "Samsung"), asciiField(exifTagModel, "SM-T225")}, nil, nil)
+	le.PutUint32(badOffset[8+2+8:], 0xFFFFFF00)
+
+	// Pointer GPS IFD menunjuk ke luar TIFF
+	badGPS := buildTIFF(le, []exifField{asciiField(exifTagMake, "Samsung")}, nil, gpsFields(le, "S", "W"))
+	le.PutUint32(badGPS[8+2+12+8:], uint32(len(badGPS)+100))
+
+	// IFD0 terpotong: jumlah entry 3 tapi hanya entry pertama yang lengkap
+	full := buildTIFF(be, []exifField{asciiField(exifTagModel, "M1"), asciiField(exifTagMake, "ACME"), asciiField(exifTagDateTime, "2024:01:02 03:04:05")}, nil, nil)
+	truncated := full[:8+2+12+6]
+
+	// IFD0 offset di header melewati akhir TIFF
+	badIFD := buildTIFF(le, []exifField{asciiField(exifTagMake, "Samsung")}, nil, nil)
+	le.PutUint32(badIFD[4:], 1<<31)
+
+	zeroDen := jpegWithEXIF(buildTIFF(be, nil, nil, []exifField{
+		rationalField(be, exifTagGPSLat, [2]uint32{6, 0}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+		rationalField(be, exifTagGPSLng, [2]uint32{106, 1}, [2]uint32{0, 1}, [2]uint32{0, 1}),
+	}))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantErr   bool
+		make      string
+		model     string
+		captured  *time.Time
+		lat, lng  *float64
+		wantNoGPS bool
+	}{
+		{name: "little endian", data: sampleEXIF(le, "N", "E"), make: "Samsung", model: "SM-T225", captured: &original, lat: &lat, lng: &lng},
+		{name: "big endian", data: sampleEXIF(be, "N", "E"), make: "Samsung", model: "SM-T225", captured: &original, lat: &lat, lng: &lng},
+		{name: "south west refs", data: sampleEXIF(be, "S", "W"), make: "Samsung", model: "SM-T225", captured: &original, lat: ptrFloat(-lat), lng: ptrFloat(-lng)},
+		{name: "missing refs default north east", data: sampleEXIF(le, "", ""), make: "Samsung", model: "SM-T225", captured: &original, lat: &lat, lng: &lng},
+		{name: "datetime without exif ifd", data: jpegWithEXIF(full), make: "ACME", model: "M1", captured: &modified, wantNoGPS: true},
+		{name: "value offset past end", data: jpegWithEXIF(badOffset), model: "SM-T225", wantNoGPS: true},
+		{name: "gps ifd past end", data: jpegWithEXIF(badGPS), make: "Samsung", wantNoGPS: true},
+		{name: "truncated ifd", data: jpegWithEXIF(truncated), model: "M1", wantNoGPS: true},
+		{name: "ifd0 offset past end", data: jpegWithEXIF(badIFD), wantNoGPS: true},
+		{name: "zero denominator", data: zeroDen, wantNoGPS: true},
+		{name: "not jpeg", data: []byte("\x89PNG\r\n\x1a\n"), wantErr: true},
+		{name: "jpeg without exif", data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 'J', 'F', 0xFF, 0xDA, 0x00, 0x02}, wantErr: true},
+		{name: "segment longer than data", data: sampleEXIF(le, "N", "E")[:40], wantErr: true},
+		{name: "short tiff header", data: jpegWithEXIF([]byte("II*")), wantErr: true},
+		{name: "unknown byte order", data: jpegWithEXIF([]byte("XX\x00\x2a\x00\x00\x00\x08")), wantErr: true},
+		{name: "empty", data: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseEXIF(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoEXIF) {
+					t.Fatalf("ParseEXIF err = %v, want ErrNoEXIF", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEXIF: %v", err)
+			}
+			if info.Make != tt.make || info.Model != tt.model {
+				t.Errorf("make/model = %q/%q, want %q/%q", info.Make, info.Model, tt.make, tt.model)
+			}
+			switch {
+			case tt.captured == nil && info.CaptureTime != nil:
+				t.Errorf("capture time = %v, want none", info.CaptureTime)
+			case tt.captured != nil && (info.CaptureTime == nil || !info.CaptureTime.Equal(*tt.captured)):
+				t.Errorf("capture time = %v, want %v", info.CaptureTime, tt.captured)
+			}
+			if tt.wantNoGPS {
+				if info.Latitude != nil || info.Longitude != nil {
+					t.Errorf("gps = %v,%v, want none", *info.Latitude, *info.Longitude)
+				}
+				return
+			}
+			if info.Latitude == nil || info.Longitude == nil {
+				t.Fatal("gps missing")
+			}
+			if math.Abs(*info.Latitude-*tt.lat) > 1e-9 || math.Abs(*info.Longitude-*tt.lng) > 1e-9 {
+				t.Errorf("gps = %v,%v, want %v,%v", *info.Latitude, *info.Longitude, *tt.lat, *tt.lng)
+			}
+		})
+	}
+}
+
+func ptrFloat(v float64) *float64 { return &v }
+
+func FuzzParseEXIF(f *testing.F) {
+	f.Add(sampleEXIF(binary.LittleEndian, "N", "E"))
+	f.Add(sampleEXIF(binary.BigEndian, "S", "W"))
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x08, 'E', 'x', 'i', 'f', 0, 0})
+	f.Add([]byte{0xFF, 0xD8})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := ParseEXIF(data)
+		if err != nil {
+			if info != nil || !errors.Is(err, ErrNoEXIF) {
+				t.Fatalf("ParseEXIF = %+v, %v", info, err)
+			}
+			return
+		}
+		if (info.Latitude == nil) != (info.Longitude == nil) {
+			t.Fatalf("only one gps coordinate set: %+v", info)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"path"
+	"strings"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Ukuran turunan evidence gambar (sisi terpanjang, px)
+const (
+	ImageSizeThumb   = "thumb"
+	ImageSizePreview = "preview"
+)
+
+var ImageVariantSizes = map[string]int{
+	ImageSizeThumb:   256,
+	ImageSizePreview: 1024,
+}
+
+const maxImagePixels = 50_000_000 // tolak gambar raksasa supaya decode tidak menghabiskan memory
+
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+}
+
+// IsImageVariantKey cek apakah key adalah thumbnail/preview (bukan file asli)
+func IsImageVariantKey(key string) bool {
+	for size := range ImageVariantSizes {
+		if strings.HasSuffix(key, "."+size+".jpg") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsImageObject menentukan apakah object perlu dibuatkan thumbnail (dari content type atau extension)
+func IsImageObject(key, contentType string) bool {
+	if IsImageVariantKey(key) {
+		return false
+	}
+	if strings.HasPrefix(contentType, "image/") && contentType != "image/svg+xml" {
+		return true
+	}
+	return imageExtensions[strings.ToLower(path.Ext(key))]
+}
+
+// ImageVariantKey = key thumbnail/preview di samping file asli: a/b/123.png → a/b/123.thumb.jpg
+func ImageVariantKey(key, size string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "." + size + ".jpg"
+}
+
+// GenerateImageVariants decode gambar sekali lalu resize ke semua ukuran (JPEG)
+func GenerateImageVariants(r io.Reader) (map[string][]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[string][]byte, len(ImageVariantSizes))
+	for size, maxSide := range ImageVariantSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeImage(src, maxSide), &jpeg.Options{Quality: 80}); err != nil {
+			return nil, err
+		}
+		variants[size] = buf.Bytes()
+	}
+	return variants, nil
+}
+
+// resizeImage mengecilkan dengan rasio tetap (tidak pernah memperbesar)
+func resizeImage(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		maxSide = max(w, h)
+	}
+
+	nw, nh := maxSide, maxSide
+	if w >= h {
+		nh = max(1, h*maxSide/w)
+	} else {
+		nw = max(1, w*maxSide/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	// latar putih untuk PNG transparan (JPEG tidak punya alpha)
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
@@ -22,10 +22,12 @@ type TokenPair struct {
 	ExpiresAt    int64  `json:"expires_at"`
 }
 
-func GenerateAccessToken(userID uint, username string, email string, role string, company_id string, ttl time.Duration) (string, int64, error) {
+// GenerateAccessToken membuat access token berumur pendek yang terikat ke sesi (claim "sid")
+func GenerateAccessToken(userID uint, username string, email string, role string, company_id string, sessionID uint, ttl time.Duration) (string, int64, error) {
 	expiresAt := time.Now().Add(ttl).Unix()
 	claims := jwt.MapClaims{
 		"sub":        userID,
+		"sid":        sessionID,
 		"username":   username,
 		"email":      email,
 		"role":       role,
@@ -39,18 +41,6 @@ func GenerateAccessToken(userID uint, username string, email string, role string
 	return signed, expiresAt, err
 }
 
-func GenerateRefreshToken(userID uint, ttl time.Duration) (string, error) {
-	expiresAt := time.Now().Add(ttl).Unix()
-	claims := jwt.MapClaims{
-		"sub":   userID,
-		"exp":   expiresAt,
-		"iat":   time.Now().Unix(),
-		"scope": "refresh",
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
 func ParseToken(tokenStr string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"errors"
+	"go-api/config"
+	"go-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Daftar permission yang dipakai di routes.SetupRoutes
+const (
+	PermPlatformAdmin      = "platform.admin" // akses lintas company (dulu: role == "super-admin")
+	PermCompanyManage      = "company.manage"
+	PermCompanyView        = "company.view"
+	PermUserManage         = "user.manage"
+	PermRoleManage         = "role.manage"
+	PermDeviceManage       = "device.manage"
+	PermDeviceSync         = "device.sync" // endpoint tablet, login wajib pakai device_id
+	PermGroupManage        = "group.manage"
+	PermInspectionRead     = "inspection.read"
+	PermInspectionWrite    = "inspection.write"
+	PermQuestionnaireRead  = "questionnaire.read"
+	PermQuestionnaireWrite = "questionnaire.write"
+	PermChainingRead       = "chaining.read"
+	PermChainingWrite      = "chaining.write"
+	PermTriggerRead        = "trigger.read"
+	PermTriggerWrite       = "trigger.write"
+	PermTrxInspectionRead  = "trx_inspection.read"
+	PermTrxInspectionWrite = "trx_inspection.write"
+	PermLegalHold          = "trx_inspection.legal_hold" // pasang/lepas legal hold, hanya admin & custom role compliance
+	PermSubmissionCreate   = "submission.create"         // submit trx-inspection & jawaban questionnaire
+	PermAnswerRead         = "answer.read"
+	PermReportView         = "report.view"
+)
+
+var AllPermissions = []string{
+	PermPlatformAdmin,
+	PermCompanyManage,
+	PermCompanyView,
+	PermUserManage,
+	PermRoleManage,
+	PermDeviceManage,
+	PermDeviceSync,
+	PermGroupManage,
+	PermInspectionRead,
+	PermInspectionWrite,
+	PermQuestionnaireRead,
+	PermQuestionnaireWrite,
+	PermChainingRead,
+	PermChainingWrite,
+	PermTriggerRead,
+	PermTriggerWrite,
+	PermTrxInspectionRead,
+	PermTrxInspectionWrite,
+	PermLegalHold,
+	PermSubmissionCreate,
+	PermAnswerRead,
+	PermReportView,
+}
+
+// Permission level platform, tidak boleh diberikan lewat custom role milik company
+var platformPermissions = map[string]bool{
+	PermPlatformAdmin: true,
+	PermCompanyManage: true,
+}
+
+const (
+	RoleSuperAdmin = "super-admin"
+	RoleAdmin      = "admin"
+	RoleUser       = "user"
+)
+
+// DefaultRolePermissions = matrix bawaan untuk role sistem, berlaku di semua company
+var DefaultRolePermissions = map[string][]string{
+	RoleSuperAdmin: AllPermissions,
+	RoleAdmin: {
+		PermCompanyView,
+		PermUserManage,
+		PermRoleManage,
+		PermDeviceManage,
+		PermGroupManage,
+		PermInspectionRead,
+		PermInspectionWrite,
+		PermQuestionnaireRead,
+		PermQuestionnaireWrite,
+		PermChainingRead,
+		PermChainingWrite,
+		PermTriggerRead,
+		PermTriggerWrite,
+		PermTrxInspectionRead,
+		PermTrxInspectionWrite,
+		PermLegalHold,
+		PermSubmissionCreate,
+		PermAnswerRead,
+		PermReportView,
+	},
+	RoleUser: {
+		PermDeviceSync,
+		PermInspectionRead,
+		PermQuestionnaireRead,
+		PermChainingRead,
+		PermTriggerRead,
+		PermSubmissionCreate,
+		PermReportView,
+	},
+}
+
+func IsKnownPermission(perm string) bool {
+	for _, p := range AllPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func IsPlatformPermission(perm string) bool {
+	return platformPermissions[perm]
+}
+
+func IsSystemRole(role string) bool {
+	_, ok := DefaultRolePermissions[role]
+	return ok
+}
+
+// ResolvePermissions mengembalikan set permission untuk role di company tertentu.
+// Role sistem memakai DefaultRolePermissions, selain itu dicari di MstrRole milik company.
+func ResolvePermissions(companyID, role string) (map[string]bool, error) {
+	granted := make(map[string]bool)
+
+	if perms, ok := DefaultRolePermissions[role]; ok {
+		for _, p := range perms {
+			granted[p] = true
+		}
+		return granted, nil
+	}
+
+	var custom models.MstrRole
+	err := config.DB.Preload("Permissions").
+		Where("company_id = ? AND role_name = ?", companyID, role).
+		First(&custom).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// role tidak dikenal → tanpa permission
+		return granted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !custom.IsActive {
+		return granted, nil
+	}
+
+	for _, p := range custom.Permissions {
+		if !IsPlatformPermission(p.Permission) {
+			granted[p.Permission] = true
+		}
+	}
+	return granted, nil
+}
+
+// GetPermissions mengambil permission user yang sedang login (di-cache per request)
+func GetPermissions(c *gin.Context) map[string]bool {
+	if v, ok := c.Get("permissions"); ok {
+		if granted, ok := v.(map[string]bool); ok {
+			return granted
+		}
+	}
+
+	granted, err := ResolvePermissions(c.GetString("company_id"), c.GetString("role"))
+	if err != nil {
+		// gagal baca role → anggap tanpa permission, jangan di-cache
+		return map[string]bool{}
+	}
+	c.Set("permissions", granted)
+	return granted
+}
+
+func HasPermission(c *gin.Context, perm string) bool {
+	return GetPermissions(c)[perm]
+}
+
+// PermissionList mengubah set permission jadi list (urutan mengikuti AllPermissions)
+func PermissionList(granted map[string]bool) []string {
+	list := []string{}
+	for _, p := range AllPermissions {
+		if granted[p] {
+			list = append(list, p)
+		}
+	}
+	return list
+}
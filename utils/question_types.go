@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipe question yang didukung Question dan MstrInspectionQuestion
+const (
+	QuestionYesNo    = "yesno"
+	QuestionMultiple = "multiple" // pilih satu option
+	QuestionCheckbox = "checkbox" // pilih beberapa option
+	QuestionText     = "text"
+	QuestionEssay    = "essay"
+	QuestionNumber   = "number"
+	QuestionRating   = "rating" // skala 1..5 (default) atau sesuai min/max validation_rules, maksimal 10
+	QuestionDate     = "date"   // YYYY-MM-DD
+	QuestionTime     = "time"   // HH:MM
+	QuestionDateTime = "datetime"
+	QuestionImage    = "image"
+	QuestionSign     = "signature" // tanda tangan, disimpan sebagai object image
+	QuestionGPS      = "gps"
+	QuestionBarcode  = "barcode" // hasil scan barcode / QR
+)
+
+var questionTypes = []string{
+	QuestionYesNo, QuestionMultiple, QuestionCheckbox, QuestionText, QuestionEssay, QuestionNumber,
+	QuestionRating, QuestionDate, QuestionTime, QuestionDateTime, QuestionImage, QuestionSign, QuestionGPS, QuestionBarcode,
+}
+
+const (
+	answerDateLayout = "2006-01-02"
+	answerTimeLayout = "15:04"
+
+	defaultRatingMin = 1
+	defaultRatingMax = 5
+	maxRatingScale   = 10
+)
+
+// ValidQuestionType mengecek tipe question (case-insensitive)
+func ValidQuestionType(questionType string) bool {
+	qt := strings.ToLower(strings.TrimSpace(questionType))
+	for _, t := range questionTypes {
+		if t == qt {
+			return true
+		}
+	}
+	return false
+}
+
+// QuestionTypeError = pesan error standar untuk tipe yang tidak dikenal
+func QuestionTypeError(questionType string) error {
+	return fmt.Errorf("invalid question type %q (allowed: %s)", questionType, strings.Join(questionTypes, ", "))
+}
+
+// IsFileQuestionType = jawaban berupa file (object key di answer_file)
+func IsFileQuestionType(questionType string) bool {
+	qt := strings.ToLower(questionType)
+	return qt == QuestionImage || qt == QuestionSign
+}
+
+// QuestionTypeHasOptions = tipe yang jawabannya label option
+func QuestionTypeHasOptions(questionType string) bool {
+	qt := strings.ToLower(questionType)
+	return qt == QuestionMultiple || qt == QuestionCheckbox
+}
+
+// GPSPoint = representasi jawaban gps di answer_json
+type GPSPoint struct {
+	Lat      float64  `json:"lat"`
+	Lng      float64  `json:"lng"`
+	Accuracy *float64 `json:"accuracy,omitempty"` // meter
+}
+
+// SplitAnswerLabels membaca jawaban checkbox: "A,C" atau ["A","C"]
+func SplitAnswerLabels(answer string) []string {
+	answer = strings.TrimSpace(answer)
+	var labels []string
+	if strings.HasPrefix(answer, "[") {
+		if err := json.Unmarshal([]byte(answer), &labels); err != nil {
+			return nil
+		}
+	} else {
+		labels = strings.Split(answer, ",")
+	}
+	seen := make(map[string]bool, len(labels))
+	result := make([]string, 0, len(labels))
+	for _, l := range labels {
+		l = strings.ToUpper(strings.TrimSpace(l))
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		result = append(result, l)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ParseGPSAnswer membaca jawaban gps: "lat,lng" atau {"lat":..,"lng":..,"accuracy":..}
+func ParseGPSAnswer(answer string) (*GPSPoint, error) {
+	answer = strings.TrimSpace(answer)
+	var p GPSPoint
+	if strings.HasPrefix(answer, "{") {
+		if err := json.Unmarshal([]byte(answer), &p); err != nil {
+			return nil, errors.New("answer must be a GPS point (lat,lng)")
+		}
+	} else {
+		parts := strings.Split(answer, ",")
+		if len(parts) != 2 {
+			return nil, errors.New("answer must be a GPS point (lat,lng)")
+		}
+		lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		lng, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("answer must be a GPS point (lat,lng)")
+		}
+		p.Lat, p.Lng = lat, lng
+	}
+	if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+		return nil, errors.New("GPS point is out of range")
+	}
+	return &p, nil
+}
+
+func parseAnswerDateTime(questionType, answer string) (time.Time, error) {
+	switch questionType {
+	case QuestionDate:
+		return time.Parse(answerDateLayout, answer)
+	case QuestionTime:
+		return time.Parse(answerTimeLayout, answer)
+	default:
+		return time.Parse(time.RFC3339, answer)
+	}
+}
+
+// ratingScale = batas skala rating dari validation_rules (default 1..5)
+func ratingScale(rules *ValidationRules) (int, int) {
+	min, max := defaultRatingMin, defaultRatingMax
+	if rules != nil && rules.Min != nil {
+		min = int(*rules.Min)
+	}
+	if rules != nil && rules.Max != nil {
+		max = int(*rules.Max)
+	}
+	return min, max
+}
+
+// NormalizeAnswer mengubah jawaban teks yang sudah valid ke bentuk simpan:
+// answer_text yang seragam dan answer_json untuk tipe terstruktur (checkbox, gps).
+func NormalizeAnswer(questionType, answer string) (string, []byte) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", nil
+	}
+	switch strings.ToLower(questionType) {
+	case QuestionCheckbox:
+		labels := SplitAnswerLabels(answer)
+		raw, _ := json.Marshal(labels)
+		return strings.Join(labels, ","), raw
+	case QuestionGPS:
+		p, err := ParseGPSAnswer(answer)
+		if err != nil {
+			return answer, nil
+		}
+		raw, _ := json.Marshal(p)
+		return strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lng, 'f', -1, 64), raw
+	case QuestionDateTime:
+		if t, err := time.Parse(time.RFC3339, answer); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return answer, nil
+}
@@ -47,3 +47,12 @@ func JSONValidationError(c *gin.Context, err error) {
 		"error":   err.Error(),
 	})
 }
+
+// JSONAnswerErrors response 422 dengan detail error per question (validasi jawaban submit)
+func JSONAnswerErrors(c *gin.Context, errs []AnswerError) {
+	c.JSON(http.StatusUnprocessableEntity, JSONResponse{
+		Status:  "error",
+		Message: "Answer validation failed",
+		Data:    gin.H{"errors": errs},
+	})
+}
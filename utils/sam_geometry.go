@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Bentuk area SAM di gambar master. Koordinat dinormalisasi ke ukuran gambar (0..1),
+// jadi tidak tergantung resolusi gambar yang ditampilkan di tablet / web.
+const (
+	GeometryPoint   = "point"   // 1 titik
+	GeometryRect    = "rect"    // 2 titik: pojok kiri atas & kanan bawah
+	GeometryPolygon = "polygon" // 3..maxPolygonPoints titik, tanpa titik penutup
+
+	maxPolygonPoints = 100
+)
+
+// SamPoint = satu titik ternormalisasi
+type SamPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// SamGeometry = isi kolom geometry MstrInspectionDetail (null = SAM titik lama di X/Y)
+//
+//	{"type": "rect", "points": [{"x": 0.1, "y": 0.2}, {"x": 0.4, "y": 0.5}]}
+type SamGeometry struct {
+	Type   string     `json:"type"`
+	Points []SamPoint `json:"points"`
+}
+
+// ParseSamGeometry membaca kolom geometry (kosong / null / {} = tanpa geometry)
+func ParseSamGeometry(raw []byte) (*SamGeometry, error) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" || s == "{}" {
+		return nil, nil
+	}
+	var g SamGeometry
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("invalid geometry: %v", err)
+	}
+	return &g, nil
+}
+
+// NormalizeSamGeometry memvalidasi geometry dan mengembalikan bentuk simpan yang seragam
+// (rect diurutkan kiri atas → kanan bawah, titik penutup polygon dibuang). nil = tanpa geometry.
+func NormalizeSamGeometry(raw []byte) ([]byte, error) {
+	g, err := ParseSamGeometry(raw)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	g.Type = strings.ToLower(strings.TrimSpace(g.Type))
+	if g.Type == "rectangle" {
+		g.Type = GeometryRect
+	}
+	for _, p := range g.Points {
+		if p.X < 0 || p.X > 1 || p.Y < 0 || p.Y > 1 {
+			return nil, errors.New("geometry points must be normalized to the image size (0..1)")
+		}
+	}
+
+	switch g.Type {
+	case GeometryPoint:
+		if len(g.Points) != 1 {
+			return nil, errors.New("point geometry requires exactly 1 point")
+		}
+	case GeometryRect:
+		if len(g.Points) != 2 {
+			return nil, errors.New("rect geometry requires 2 corner points")
+		}
+		a, b := g.Points[0], g.Points[1]
+		if a.X == b.X || a.Y == b.Y {
+			return nil, errors.New("rect geometry must have a non-zero width and height")
+		}
+		g.Points = []SamPoint{
+			{X: math.Min(a.X, b.X), Y: math.Min(a.Y, b.Y)},
+			{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y)},
+		}
+	case GeometryPolygon:
+		if n := len(g.Points); n > 3 && g.Points[0] == g.Points[n-1] {
+			g.Points = g.Points[:n-1]
+		}
+		if len(g.Points) < 3 || len(g.Points) > maxPolygonPoints {
+			return nil, fmt.Errorf("polygon geometry requires 3 to %d points", maxPolygonPoints)
+		}
+		if polygonArea(g.Points) == 0 {
+			return nil, errors.New("polygon geometry must have a non-zero area")
+		}
+	default:
+		return nil, fmt.Errorf("unknown geometry type %q (allowed: point, rect, polygon)", g.Type)
+	}
+	return json.Marshal(g)
+}
+
+// polygonArea = luas polygon (shoelace)
+func polygonArea(points []SamPoint) float64 {
+	area := 0.0
+	for i := range points {
+		j := (i + 1) % len(points)
+		area += points[i].X*points[j].Y - points[j].X*points[i].Y
+	}
+	return math.Abs(area) / 2
+}
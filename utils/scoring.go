@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// ScoreQuestion = question yang ikut dinilai. Question hanya dinilai jika punya option
+// dengan is_correct atau score; question lain (essay, image, ...) tidak mempengaruhi skor.
+type ScoreQuestion struct {
+	ID       uint
+	Weight   float64 // 0 = bobot 1
+	Critical bool
+	Multi    bool // checkbox: jawaban berisi beberapa label
+	Options  []ScoreOption
+}
+
+// ScoreOption: Score = bagian dari bobot question yang didapat jika option dipilih (0..1).
+// Jika Score kosong, option is_correct bernilai 1 dan lainnya 0.
+type ScoreOption struct {
+	Label     string
+	IsCorrect bool
+	Score     *float64
+}
+
+// ScoreResult = hasil penilaian satu submission
+type ScoreResult struct {
+	Score          float64  `json:"score"`
+	MaxScore       float64  `json:"max_score"`
+	Percent        *float64 `json:"score_percent"`             // nil jika tidak ada question yang dinilai
+	Passed         *bool    `json:"passed"`                    // nil jika tidak ada threshold maupun question critical
+	CriticalFailed []uint   `json:"critical_failed_questions"` // question critical yang dijawab salah / tidak dijawab
+}
+
+var errUnscoredQuestion = errors.New("weight and is_critical only apply to multiple/checkbox questions with is_correct or score options")
+
+// CheckScoring dipakai saat question disimpan. is_critical dan weight selain default (0/1) ditolak
+// untuk question yang tidak bisa dinilai, karena ScoreAnswers akan mengabaikannya.
+func CheckScoring(questionType string, weight float64, critical bool, options []ScoreOption) error {
+	if weight < 0 {
+		return errors.New("weight must not be negative")
+	}
+	for _, o := range options {
+		if o.Score != nil && (*o.Score < 0 || *o.Score > 1) {
+			return errors.New("option score must be between 0 and 1")
+		}
+	}
+	scorable := QuestionTypeHasOptions(questionType) && ScoreQuestion{Options: options}.scored()
+	if !scorable && (critical || (weight != 0 && weight != 1)) {
+		return errUnscoredQuestion
+	}
+	return nil
+}
+
+func (q ScoreQuestion) scored() bool {
+	for _, o := range q.Options {
+		if o.IsCorrect || o.Score != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// earned = bagian bobot (0..1) yang didapat untuk jawaban ini
+func (q ScoreQuestion) earned(answer string) float64 {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return 0
+	}
+	if q.Multi {
+		return q.earnedMulti(SplitAnswerLabels(answer))
+	}
+	for _, o := range q.Options {
+		if !strings.EqualFold(strings.TrimSpace(o.Label), answer) {
+			continue
+		}
+		if o.Score != nil {
+			return *o.Score
+		}
+		if o.IsCorrect {
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+// earnedMulti: jika ada option dengan score → jumlah score option yang dipilih (maks 1),
+// selain itu 1 hanya jika yang dipilih tepat semua option is_correct.
+func (q ScoreQuestion) earnedMulti(labels []string) float64 {
+	selected := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		selected[l] = true
+	}
+	weighted := false
+	total := 0.0
+	exact := true
+	for _, o := range q.Options {
+		chosen := selected[strings.ToUpper(strings.TrimSpace(o.Label))]
+		if o.Score != nil {
+			weighted = true
+			if chosen {
+				total += *o.Score
+			}
+		}
+		if chosen != o.IsCorrect {
+			exact = false
+		}
+	}
+	if weighted {
+		return math.Min(total, 1)
+	}
+	if exact {
+		return 1
+	}
+	return 0
+}
+
+// ScoreAnswers menilai jawaban (label option per question). Question tersembunyi (display_condition)
+// tidak dihitung. threshold = persentase minimal untuk lulus (0 = tanpa threshold).
+func ScoreAnswers(questions []ScoreQuestion, answers map[uint]string, hidden map[uint]bool, threshold float64) ScoreResult {
+	var result ScoreResult
+	hasCritical := false
+	for _, q := range questions {
+		if hidden[q.ID] || !q.scored() {
+			continue
+		}
+		weight := q.Weight
+		if weight <= 0 {
+			weight = 1 // baris lama / client yang tidak mengirim weight
+		}
+		ratio := q.earned(answers[q.ID])
+		result.Score += weight * ratio
+		result.MaxScore += weight
+		if q.Critical {
+			hasCritical = true
+			if ratio < 1 {
+				result.CriticalFailed = append(result.CriticalFailed, q.ID)
+			}
+		}
+	}
+
+	result.Score = math.Round(result.Score*100) / 100
+	if result.MaxScore > 0 {
+		percent := math.Round(result.Score/result.MaxScore*10000) / 100
+		result.Percent = &percent
+	}
+
+	if threshold > 0 || hasCritical {
+		passed := len(result.CriticalFailed) == 0
+		if threshold > 0 && result.Percent != nil && *result.Percent < threshold {
+			passed = false
+		}
+		result.Passed = &passed
+	}
+	return result
+}
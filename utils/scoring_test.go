@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckScoring(t *testing.T) {
+	half, over := 0.5, 1.5
+	correct := []ScoreOption{{Label: "A", IsCorrect: true}, {Label: "B"}}
+	weighted := []ScoreOption{{Label: "A", Score: &half}, {Label: "B"}}
+	unscored := []ScoreOption{{Label: "A"}, {Label: "B"}}
+
+	tests := []struct {
+		name     string
+		qType    string
+		weight   float64
+		critical bool
+		options  []ScoreOption
+		wantErr  error // nil = valid, errAny = error apa saja
+	}{
+		{"multiple with correct option", QuestionMultiple, 3, true, correct, nil},
+		{"checkbox with option score", QuestionCheckbox, 2, true, weighted, nil},
+		{"default weight on text", QuestionText, 1, false, nil, nil},
+		{"zero weight on yesno", QuestionYesNo, 0, false, nil, nil},
+		{"critical yesno", QuestionYesNo, 1, true, nil, errUnscoredQuestion},
+		{"critical number", QuestionNumber, 0, true, nil, errUnscoredQuestion},
+		{"weighted text", QuestionText, 2, false, nil, errUnscoredQuestion},
+		{"critical multiple without correct option", QuestionMultiple, 1, true, unscored, errUnscoredQuestion},
+		{"options on non-option type", QuestionText, 1, true, correct, errUnscoredQuestion},
+		{"negative weight", QuestionMultiple, -1, false, correct, errAny},
+		{"option score above 1", QuestionMultiple, 1, false, []ScoreOption{{Label: "A", Score: &over}}, errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckScoring(tt.qType, tt.weight, tt.critical, tt.options)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("CheckScoring = %v, want nil", err)
+			case tt.wantErr == errAny && err == nil:
+				t.Error("CheckScoring = nil, want error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Errorf("CheckScoring = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("any error")
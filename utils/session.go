@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// SessionMaxLifetime = batas absolut sesi sejak login, refresh tidak bisa memperpanjang lewat batas ini
+	SessionMaxLifetime = 30 * 24 * time.Hour
+)
+
+// Alasan revoke sesi
+const (
+	RevokeLogout          = "logout"
+	RevokeLogoutAll       = "logout_all"
+	RevokeTokenReuse      = "token_reuse"
+	RevokeUserDeactivated = "user_deactivated"
+	RevokeUserDeleted     = "user_deleted"
+	RevokeDeviceDisabled  = "device_deactivated"
+	RevokeDeviceDeleted   = "device_deleted"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionRevoked      = errors.New("session revoked or expired")
+)
+
+// HashToken = sha256 hex, dipakai untuk menyimpan refresh token
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// issueRefreshToken membuat refresh token baru untuk sesi, mengembalikan token asli (hanya sekali)
+func issueRefreshToken(tx *gorm.DB, sessionID uint, expiresAt time.Time) (string, error) {
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	rt := models.TrxRefreshToken{
+		SessionID: sessionID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&rt).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// CreateSession membuat sesi baru saat login beserta refresh token pertamanya
+func CreateSession(user models.MstrUser, deviceID, ip, userAgent string) (*models.TrxUserSession, string, error) {
+	now := time.Now()
+	session := models.TrxUserSession{
+		UserID:     user.Id,
+		CompanyID:  user.CompanyID,
+		DeviceID:   deviceID,
+		IPAddress:  ip,
+		UserAgent:  truncate(userAgent, 255),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+
+	var raw string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		raw, err = issueRefreshToken(tx, session.Id, session.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &session, raw, nil
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru.
+// Token yang sudah pernah dipakai → sesi langsung di-revoke (kemungkinan token dicuri).
+func RotateRefreshToken(raw, ip string) (*models.TrxUserSession, string, error) {
+	var session models.TrxUserSession
+	var newRaw string
+	reused := false
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var rt models.TrxRefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", HashToken(raw)).
+			First(&rt).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, rt.SessionID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		now := time.Now()
+		if rt.UsedAt != nil {
+			reused = true
+			return nil
+		}
+		if session.RevokedAt != nil || now.After(session.ExpiresAt) || now.After(rt.ExpiresAt) {
+			return ErrSessionRevoked
+		}
+
+		if err := tx.Model(&rt).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		session.LastUsedAt = now
+		session.ExpiresAt = slidingExpiry(session.CreatedAt, now)
+		if ip != "" {
+			session.IPAddress = ip
+		}
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		newRaw, err = issueRefreshToken(tx, session.Id, session.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if reused {
+		// revoke di luar transaksi di atas supaya tetap tersimpan
+		RevokeSession(session.Id, RevokeTokenReuse)
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	return &session, newRaw, nil
+}
+
+// slidingExpiry = now + RefreshTokenTTL, tapi tidak melewati CreatedAt + SessionMaxLifetime
+func slidingExpiry(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(RefreshTokenTTL)
+	if limit := createdAt.Add(SessionMaxLifetime); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
+}
+
+// ActiveSession mengambil sesi yang belum di-revoke dan belum expired
+func ActiveSession(sessionID uint) (*models.TrxUserSession, error) {
+	var session models.TrxUserSession
+	if err := config.DB.First(&session, sessionID).Error; err != nil {
+		return nil, ErrSessionRevoked
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionRevoked
+	}
+	return &session, nil
+}
+
+// TouchSession update last_used_at, dibatasi maksimal sekali per menit supaya tidak menulis tiap request
+func TouchSession(session *models.TrxUserSession) {
+	if time.Since(session.LastUsedAt) < time.Minute {
+		return
+	}
+	config.DB.Model(&models.TrxUserSession{}).
+		Where("id = ?", session.Id).
+		UpdateColumn("last_used_at", time.Now())
+}
+
+func revokeSessions(query *gorm.DB, reason string) error {
+	return query.Model(&models.TrxUserSession{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+func RevokeSession(sessionID uint, reason string) error {
+	return revokeSessions(config.DB.Where("id = ?", sessionID), reason)
+}
+
+func RevokeUserSessions(userID uint, reason string) error {
+	return revokeSessions(config.DB.Where("user_id = ?", userID), reason)
+}
+
+// RevokeDeviceSessions revoke semua sesi yang login memakai device tersebut
+func RevokeDeviceSessions(companyID, deviceID string, reason string) error {
+	return revokeSessions(config.DB.Where("company_id = ? AND device_id = ?", companyID, deviceID), reason)
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
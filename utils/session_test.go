@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"errors"
+	"go-api/config"
+	"go-api/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newSessionTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "session.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.TrxUserSession{}, &models.TrxRefreshToken{}); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+	prev := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = prev })
+	return db
+}
+
+func newTestSession(t *testing.T) (*models.TrxUserSession, string) {
+	t.Helper()
+	session, raw, err := CreateSession(models.MstrUser{Id: 1, CompanyID: "COMP-A"}, "", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session, raw
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	db := newSessionTestDB(t)
+	session, raw := newTestSession(t)
+
+	rotated, next, err := RotateRefreshToken(raw, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if rotated.Id != session.Id || next == "" || next == raw || rotated.IPAddress != "10.0.0.1" {
+		t.Fatalf("rotated = %+v, token %q", rotated, next)
+	}
+
+	// token lama dipakai lagi → dianggap dicuri, seluruh sesi di-revoke
+	if _, _, err := RotateRefreshToken(raw, ""); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse err = %v, want ErrRefreshTokenReused", err)
+	}
+	var stored models.TrxUserSession
+	db.First(&stored, session.Id)
+	if stored.RevokedAt == nil || stored.RevokedReason != RevokeTokenReuse {
+		t.Fatalf("session after reuse = %+v, want revoked for token_reuse", stored)
+	}
+	if _, _, err := RotateRefreshToken(next, ""); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("newest token after reuse err = %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestRotateRefreshTokenRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(db *gorm.DB, session *models.TrxUserSession)
+	}{
+		{"expired session", func(db *gorm.DB, s *models.TrxUserSession) {
+			db.Model(s).Update("expires_at", time.Now().Add(-time.Minute))
+		}},
+		{"revoked session", func(db *gorm.DB, s *models.TrxUserSession) {
+			RevokeSession(s.Id, RevokeLogout)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newSessionTestDB(t)
+			session, raw := newTestSession(t)
+			tt.setup(db, session)
+
+			if _, _, err := RotateRefreshToken(raw, ""); !errors.Is(err, ErrSessionRevoked) {
+				t.Fatalf("err = %v, want ErrSessionRevoked", err)
+			}
+			var used int64
+			db.Model(&models.TrxRefreshToken{}).Where("used_at IS NOT NULL").Count(&used)
+			if used != 0 {
+				t.Errorf("rejected refresh marked %d tokens as used", used)
+			}
+		})
+	}
+
+	t.Run("unknown token", func(t *testing.T) {
+		newSessionTestDB(t)
+		if _, _, err := RotateRefreshToken("nope", ""); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Fatalf("err = %v, want ErrInvalidRefreshToken", err)
+		}
+	})
+}
+
+// Refresh menggeser expiry, tapi tidak melewati batas absolut sejak login
+func TestRotateRefreshTokenAbsoluteExpiry(t *testing.T) {
+	db := newSessionTestDB(t)
+	session, raw := newTestSession(t)
+	createdAt := time.Now().Add(-SessionMaxLifetime + time.Hour)
+	db.Model(session).UpdateColumn("created_at", createdAt)
+
+	rotated, _, err := RotateRefreshToken(raw, "")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if limit := createdAt.Add(SessionMaxLifetime); !rotated.ExpiresAt.Equal(limit) {
+		t.Fatalf("expires_at = %v, want capped at %v", rotated.ExpiresAt, limit)
+	}
+	var rt models.TrxRefreshToken
+	db.Where("used_at IS NULL").First(&rt)
+	if rt.ExpiresAt.After(createdAt.Add(SessionMaxLifetime)) {
+		t.Errorf("new refresh token expires %v, after session limit", rt.ExpiresAt)
+	}
+}
@@ -23,9 +23,8 @@ func init() {
 
 // --- helper function ---
 func CreateGuestToken(c *gin.Context, companyID string) (string, error) {
-	// Ambil role dari context
-	roleVal, _ := c.Get("role")
-	role, _ := roleVal.(string)
+	// Platform admin tidak dibatasi RLS per company
+	isPlatformAdmin := HasPermission(c, PermPlatformAdmin)
 
 	// login ke superset pakai service account
 	accessToken, err := supersetLogin()
@@ -52,7 +51,7 @@ func CreateGuestToken(c *gin.Context, companyID string) (string, error) {
 	// Default rls: array kosong (bukan nil)
 	rls := []map[string]interface{}{}
 
-	if role != "super-admin" {
+	if !isPlatformAdmin {
 		//datasetID := os.Getenv("SUPERSET_DATASET_ID") // <- simpan dataset_id di env
 		rls = append(rls, map[string]interface{}{
 			"clause": fmt.Sprintf("company_id = '%s'", companyID),
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"go-api/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// === Tenant scoping ===
+// Semua query by-ID di controller wajib lewat helper ini supaya user company A
+// tidak bisa baca/ubah data company B (hasilnya 404 karena record tidak ketemu).
+
+// TenantCompanyID mengembalikan company_id dari JWT dan apakah query perlu dibatasi.
+// Platform admin (super-admin) tidak dibatasi.
+func TenantCompanyID(c *gin.Context) (string, bool) {
+	if HasPermission(c, PermPlatformAdmin) {
+		return "", false
+	}
+	return c.GetString("company_id"), true
+}
+
+// CompanyScope membatasi query pada tabel yang punya kolom company_id
+func CompanyScope(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return CompanyColumnScope(c, "company_id")
+}
+
+// CompanyColumnScope sama seperti CompanyScope tapi kolom bisa di-qualify (mis. "md.company_id" saat join)
+func CompanyColumnScope(c *gin.Context, column string) func(*gorm.DB) *gorm.DB {
+	companyID, restricted := TenantCompanyID(c)
+	return func(db *gorm.DB) *gorm.DB {
+		if !restricted {
+			return db
+		}
+		return db.Where(fmt.Sprintf("%s = ?", column), companyID)
+	}
+}
+
+// ParentCompanyScope untuk tabel child yang tidak punya company_id,
+// dibatasi lewat parent-nya: fkColumn IN (SELECT id FROM parentTable WHERE company_id = ?)
+func ParentCompanyScope(c *gin.Context, fkColumn, parentTable string) func(*gorm.DB) *gorm.DB {
+	companyID, restricted := TenantCompanyID(c)
+	return func(db *gorm.DB) *gorm.DB {
+		if !restricted {
+			return db
+		}
+		return db.Where(
+			fmt.Sprintf("%s IN (?)", fkColumn),
+			config.DB.Table(parentTable).Select("id").Where("company_id = ?", companyID),
+		)
+	}
+}
+
+// TenantDB = config.DB yang sudah dibatasi company_id user login.
+// Hanya untuk model yang punya kolom company_id; aman dipakai ulang untuk beberapa query.
+func TenantDB(c *gin.Context) *gorm.DB {
+	return config.DB.WithContext(c.Request.Context()).
+		Scopes(CompanyScope(c)).
+		Session(&gorm.Session{})
+}
+
+// TenantChildDB = config.DB untuk tabel child yang dibatasi lewat parent (lihat ParentCompanyScope)
+func TenantChildDB(c *gin.Context, fkColumn, parentTable string) *gorm.DB {
+	return config.DB.WithContext(c.Request.Context()).
+		Scopes(ParentCompanyScope(c, fkColumn, parentTable)).
+		Session(&gorm.Session{})
+}